@@ -6,22 +6,33 @@ import (
 	"net"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"sync"
 	"syscall"
 
 	pgxtx "github.com/avito-tech/go-transaction-manager/drivers/pgxv5/v2"
+	sqltx "github.com/avito-tech/go-transaction-manager/drivers/sql/v2"
 	tx "github.com/avito-tech/go-transaction-manager/trm/v2/manager"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/recovery"
+	"golang.org/x/sync/semaphore"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/reflection"
 	"google.golang.org/grpc/status"
 
 	"file-service/internal/api"
+	"file-service/internal/config"
+	"file-service/internal/file"
+	"file-service/internal/policy"
 	"file-service/internal/ratelimit"
 	"file-service/internal/server"
 	"file-service/internal/service"
+	"file-service/internal/storage/disk"
+	"file-service/internal/storage/memory"
 	"file-service/internal/storage/postgres"
+	"file-service/internal/storage/s3"
+	"file-service/internal/storage/sqlite"
 )
 
 func main() {
@@ -31,46 +42,124 @@ func main() {
 	// 	logger.Error("failed to load .env file", "error", err)
 	// }
 
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Error("failed to load config", "error", err)
+		os.Exit(1)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	db, err := postgres.New(ctx, os.Getenv("DATABASE_URL"), os.Getenv("MIGRATIONS_PATH"))
+	metaStorage, transaction, closeStorage, err := newMetaStorage(ctx, cfg, logger)
 	if err != nil {
-		logger.Error("failed to connect to database", "error", err)
+		logger.Error("failed to create metadata storage", "error", err)
 		os.Exit(1)
 	}
-	defer db.Close()
+	defer closeStorage()
 
-	transaction := tx.Must(pgxtx.NewDefaultFactory(db))
+	blobStorage, err := newBlobStorage(ctx, cfg)
+	if err != nil {
+		logger.Error("failed to create blob storage", "error", err)
+		os.Exit(1)
+	}
 
-	metaStorage := postgres.NewFileMetaStorage(db, pgxtx.DefaultCtxGetter, logger)
-	fileService, err := service.NewDiskFileService(os.Getenv("FILES_UPLOAD_PATH"), metaStorage, transaction, logger)
+	uploadPolicy, err := policy.New(cfg.UploadAllowedTypes)
+	if err != nil {
+		logger.Error("failed to create upload policy", "error", err)
+		os.Exit(1)
+	}
+
+	thumbnailSizes := cfg.ThumbnailSizes
+	if len(thumbnailSizes) == 0 {
+		thumbnailSizes = service.DEFAULT_THUMBNAIL_SIZES
+	}
+	imageDecodes := cfg.ImageDecodes
+	if imageDecodes == 0 {
+		imageDecodes = service.IMAGE_DECODES
+	}
+	// Thumbnails and transformations share the limit, so decoded images
+	// can't take more memory than it allows.
+	decodes := semaphore.NewWeighted(int64(imageDecodes))
+	thumbnails, err := service.NewThumbnailer(blobStorage, thumbnailSizes, decodes, logger)
+	if err != nil {
+		logger.Error("failed to create thumbnailer", "error", err)
+		os.Exit(1)
+	}
+	transforms := service.NewTransformer(blobStorage, service.TRANSFORM_CACHE_SIZE, decodes, logger)
+
+	fileService, err := service.NewDiskFileService(cfg.FilesUploadPath, blobStorage, metaStorage, uploadPolicy, cfg.StripMetadata, []byte(cfg.ReferenceSecret), thumbnails, transforms, transaction, logger)
 	if err != nil {
 		logger.Error("failed to create file service", "error", err)
 		os.Exit(1)
 	}
 
+	var backfill sync.WaitGroup
+	backfill.Add(1)
+	go func() {
+		defer backfill.Done()
+		fileService.RunMetadataBackfill(ctx)
+	}()
+	thumbnails.Run(ctx, service.THUMBNAIL_WORKERS)
+	go fileService.RunUploadCleanup(ctx, service.UPLOAD_CLEANUP_INTERVAL)
+	if cfg.GCInterval > 0 {
+		go fileService.RunGarbageCollector(ctx, cfg.GCInterval, service.GarbageCollectorOptions{
+			GracePeriod: cfg.GCGracePeriod,
+			DryRun:      cfg.GCDryRun,
+		})
+	}
+
 	fileServer := server.NewFileServer(fileService)
-	limiter := ratelimit.NewRequestLimiter()
+	limits := ratelimit.DefaultConfig()
+	if cfg.RateLimitsPath != "" {
+		limits, err = ratelimit.LoadConfig(cfg.RateLimitsPath)
+		if err != nil {
+			logger.Error("failed to load rate limits", "error", err)
+			os.Exit(1)
+		}
+	}
+	limiter, err := ratelimit.NewRequestLimiter(&api.FileService_ServiceDesc, limits)
+	if err != nil {
+		logger.Error("failed to create request limiter", "error", err)
+		os.Exit(1)
+	}
+	if cfg.RateLimitsPath != "" && cfg.RateLimitsReloadInterval > 0 {
+		go limiter.RunConfigReload(ctx, cfg.RateLimitsPath, cfg.RateLimitsReloadInterval, logger)
+	}
+	if cfg.RateLimitsShared {
+		// A pool of its own, so requests aren't held up by the queries of
+		// the requests they limit.
+		db, err := postgres.New(ctx, cfg.DatabaseURL, cfg.MigrationsPath)
+		if err != nil {
+			logger.Error("failed to connect to shared rate limits", "error", err)
+			os.Exit(1)
+		}
+		defer db.Close()
+		limiter.ShareSlots(postgres.NewSlotStorage(db), logger)
+		go limiter.RunSlotRenewal(ctx, ratelimit.SLOT_LEASE/3)
+	}
+	recoveryHandler := recovery.WithRecoveryHandler(
+		func(p any) (err error) {
+			logger.Error("Recovered from panic", slog.Any("panic", p))
+			return status.Errorf(codes.Internal, "internal error")
+		})
+	loggerFunc := logging.LoggerFunc(
+		func(ctx context.Context, lvl logging.Level, msg string, fields ...any) {
+			logger.Log(ctx, slog.Level(lvl), msg, fields...)
+		},
+	)
 	server := grpc.NewServer(
 		grpc.ChainUnaryInterceptor(
-			recovery.UnaryServerInterceptor(
-				recovery.WithRecoveryHandler(
-					func(p any) (err error) {
-						logger.Error("Recovered from panic", slog.Any("panic", p))
-						return status.Errorf(codes.Internal, "internal error")
-					}),
-			),
-			logging.UnaryServerInterceptor(
-				logging.LoggerFunc(
-					func(ctx context.Context, lvl logging.Level, msg string, fields ...any) {
-						logger.Log(ctx, slog.Level(lvl), msg, fields...)
-					},
-				),
-				logging.WithLogOnEvents(logging.PayloadReceived, logging.PayloadSent),
-			),
+			recovery.UnaryServerInterceptor(recoveryHandler),
+			logging.UnaryServerInterceptor(loggerFunc, logging.WithLogOnEvents(logging.PayloadReceived, logging.PayloadSent)),
 			limiter.UnaryInterceptor,
 		),
+		grpc.ChainStreamInterceptor(
+			recovery.StreamServerInterceptor(recoveryHandler),
+			// Payloads of streams are file chunks, only calls are logged.
+			logging.StreamServerInterceptor(loggerFunc, logging.WithLogOnEvents(logging.StartCall, logging.FinishCall)),
+			limiter.StreamInterceptor,
+		),
 	)
 	reflection.Register(server)
 
@@ -109,4 +198,47 @@ func main() {
 
 	server.GracefulStop()
 	logger.Info("gRPC server stopped")
+
+	thumbnails.Wait()
+	// The storage is closed on return.
+	backfill.Wait()
+}
+
+func newMetaStorage(ctx context.Context, cfg *config.Config, logger *slog.Logger) (file.FileMetaRepository, *tx.Manager, func(), error) {
+	switch cfg.Storage {
+	case "memory":
+		return memory.New(), tx.Must(memory.NewDefaultFactory()), func() {}, nil
+	case "sqlite":
+		db, err := sqlite.New(ctx, cfg.SQLitePath)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		metaStorage := sqlite.NewFileMetaStorage(db, sqltx.DefaultCtxGetter, logger)
+		return metaStorage, tx.Must(sqltx.NewDefaultFactory(db)), func() { db.Close() }, nil
+	default:
+		db, err := postgres.New(ctx, cfg.DatabaseURL, cfg.MigrationsPath)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		metaStorage := postgres.NewFileMetaStorage(db, pgxtx.DefaultCtxGetter, logger)
+		return metaStorage, tx.Must(pgxtx.NewDefaultFactory(db)), db.Close, nil
+	}
+}
+
+func newBlobStorage(ctx context.Context, cfg *config.Config) (file.BlobStore, error) {
+	switch cfg.BlobStorage {
+	case "s3":
+		return s3.NewBlobStorage(ctx, s3.Config{
+			Endpoint:  cfg.S3.Endpoint,
+			Bucket:    cfg.S3.Bucket,
+			Region:    cfg.S3.Region,
+			AccessKey: cfg.S3.AccessKey,
+			SecretKey: cfg.S3.SecretKey,
+			UseSSL:    cfg.S3.UseSSL,
+			Prefix:    cfg.S3.Prefix,
+			TempDir:   filepath.Join(cfg.FilesUploadPath, disk.TEMP_DIR),
+		})
+	default:
+		return disk.NewBlobStorage(cfg.FilesUploadPath)
+	}
 }
@@ -22,29 +22,1832 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type SortField int32
+
+const (
+	// Same as SORT_FIELD_UPDATED_AT with descending order.
+	SortField_SORT_FIELD_UNSPECIFIED SortField = 0
+	SortField_SORT_FIELD_UPDATED_AT  SortField = 1
+	SortField_SORT_FIELD_CREATED_AT  SortField = 2
+	SortField_SORT_FIELD_NAME        SortField = 3
+	SortField_SORT_FIELD_SIZE        SortField = 4
+)
+
+// Enum value maps for SortField.
+var (
+	SortField_name = map[int32]string{
+		0: "SORT_FIELD_UNSPECIFIED",
+		1: "SORT_FIELD_UPDATED_AT",
+		2: "SORT_FIELD_CREATED_AT",
+		3: "SORT_FIELD_NAME",
+		4: "SORT_FIELD_SIZE",
+	}
+	SortField_value = map[string]int32{
+		"SORT_FIELD_UNSPECIFIED": 0,
+		"SORT_FIELD_UPDATED_AT":  1,
+		"SORT_FIELD_CREATED_AT":  2,
+		"SORT_FIELD_NAME":        3,
+		"SORT_FIELD_SIZE":        4,
+	}
+)
+
+func (x SortField) Enum() *SortField {
+	p := new(SortField)
+	*p = x
+	return p
+}
+
+func (x SortField) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SortField) Descriptor() protoreflect.EnumDescriptor {
+	return file_api_file_proto_enumTypes[0].Descriptor()
+}
+
+func (SortField) Type() protoreflect.EnumType {
+	return &file_api_file_proto_enumTypes[0]
+}
+
+func (x SortField) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SortField.Descriptor instead.
+func (SortField) EnumDescriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{0}
+}
+
+type FitMode int32
+
+const (
+	// Same as FIT_MODE_CONTAIN.
+	FitMode_FIT_MODE_UNSPECIFIED FitMode = 0
+	// Scales the image to fit inside the box keeping the aspect ratio.
+	FitMode_FIT_MODE_CONTAIN FitMode = 1
+	// Scales the image to cover the box keeping the aspect ratio, the
+	// overflow is cropped evenly from both sides.
+	FitMode_FIT_MODE_COVER FitMode = 2
+	// Stretches the image to the box.
+	FitMode_FIT_MODE_FILL FitMode = 3
+)
+
+// Enum value maps for FitMode.
+var (
+	FitMode_name = map[int32]string{
+		0: "FIT_MODE_UNSPECIFIED",
+		1: "FIT_MODE_CONTAIN",
+		2: "FIT_MODE_COVER",
+		3: "FIT_MODE_FILL",
+	}
+	FitMode_value = map[string]int32{
+		"FIT_MODE_UNSPECIFIED": 0,
+		"FIT_MODE_CONTAIN":     1,
+		"FIT_MODE_COVER":       2,
+		"FIT_MODE_FILL":        3,
+	}
+)
+
+func (x FitMode) Enum() *FitMode {
+	p := new(FitMode)
+	*p = x
+	return p
+}
+
+func (x FitMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (FitMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_api_file_proto_enumTypes[1].Descriptor()
+}
+
+func (FitMode) Type() protoreflect.EnumType {
+	return &file_api_file_proto_enumTypes[1]
+}
+
+func (x FitMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use FitMode.Descriptor instead.
+func (FitMode) EnumDescriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{1}
+}
+
+type OutputFormat int32
+
+const (
+	// JPEG for opaque images, PNG otherwise.
+	OutputFormat_OUTPUT_FORMAT_UNSPECIFIED OutputFormat = 0
+	OutputFormat_OUTPUT_FORMAT_JPEG        OutputFormat = 1
+	OutputFormat_OUTPUT_FORMAT_PNG         OutputFormat = 2
+)
+
+// Enum value maps for OutputFormat.
+var (
+	OutputFormat_name = map[int32]string{
+		0: "OUTPUT_FORMAT_UNSPECIFIED",
+		1: "OUTPUT_FORMAT_JPEG",
+		2: "OUTPUT_FORMAT_PNG",
+	}
+	OutputFormat_value = map[string]int32{
+		"OUTPUT_FORMAT_UNSPECIFIED": 0,
+		"OUTPUT_FORMAT_JPEG":        1,
+		"OUTPUT_FORMAT_PNG":         2,
+	}
+)
+
+func (x OutputFormat) Enum() *OutputFormat {
+	p := new(OutputFormat)
+	*p = x
+	return p
+}
+
+func (x OutputFormat) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (OutputFormat) Descriptor() protoreflect.EnumDescriptor {
+	return file_api_file_proto_enumTypes[2].Descriptor()
+}
+
+func (OutputFormat) Type() protoreflect.EnumType {
+	return &file_api_file_proto_enumTypes[2]
+}
+
+func (x OutputFormat) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use OutputFormat.Descriptor instead.
+func (OutputFormat) EnumDescriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{2}
+}
+
 type UploadFileRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Filename string                 `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
+	Data     []byte                 `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	// Removes EXIF, GPS and other embedded metadata from JPEG, PNG and WebP
+	// images before they are stored, unset means the server default. Other
+	// content is stored as is by default, setting it for other images fails
+	// with INVALID_ARGUMENT.
+	StripMetadata *bool `protobuf:"varint,3,opt,name=strip_metadata,json=stripMetadata,proto3,oneof" json:"strip_metadata,omitempty"`
+	// Keeps the content as uploaded besides the stripped one.
+	KeepOriginal bool `protobuf:"varint,4,opt,name=keep_original,json=keepOriginal,proto3" json:"keep_original,omitempty"`
+	// Expected checksums of data, the upload is rejected when they don't
+	// match. SHA-256 is lowercase hex encoded.
+	Sha256        string  `protobuf:"bytes,5,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	Crc32C        *uint32 `protobuf:"varint,6,opt,name=crc32c,proto3,oneof" json:"crc32c,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadFileRequest) Reset() {
+	*x = UploadFileRequest{}
+	mi := &file_api_file_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadFileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadFileRequest) ProtoMessage() {}
+
+func (x *UploadFileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_file_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadFileRequest.ProtoReflect.Descriptor instead.
+func (*UploadFileRequest) Descriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *UploadFileRequest) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *UploadFileRequest) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *UploadFileRequest) GetStripMetadata() bool {
+	if x != nil && x.StripMetadata != nil {
+		return *x.StripMetadata
+	}
+	return false
+}
+
+func (x *UploadFileRequest) GetKeepOriginal() bool {
+	if x != nil {
+		return x.KeepOriginal
+	}
+	return false
+}
+
+func (x *UploadFileRequest) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+func (x *UploadFileRequest) GetCrc32C() uint32 {
+	if x != nil && x.Crc32C != nil {
+		return *x.Crc32C
+	}
+	return 0
+}
+
+// The first message of the stream must carry info, the following ones carry
+// consecutive chunks of the file content.
+type UploadFileStreamRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*UploadFileStreamRequest_Info
+	//	*UploadFileStreamRequest_Chunk
+	Payload       isUploadFileStreamRequest_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadFileStreamRequest) Reset() {
+	*x = UploadFileStreamRequest{}
+	mi := &file_api_file_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadFileStreamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadFileStreamRequest) ProtoMessage() {}
+
+func (x *UploadFileStreamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_file_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadFileStreamRequest.ProtoReflect.Descriptor instead.
+func (*UploadFileStreamRequest) Descriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *UploadFileStreamRequest) GetPayload() isUploadFileStreamRequest_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *UploadFileStreamRequest) GetInfo() *UploadFileInfo {
+	if x != nil {
+		if x, ok := x.Payload.(*UploadFileStreamRequest_Info); ok {
+			return x.Info
+		}
+	}
+	return nil
+}
+
+func (x *UploadFileStreamRequest) GetChunk() []byte {
+	if x != nil {
+		if x, ok := x.Payload.(*UploadFileStreamRequest_Chunk); ok {
+			return x.Chunk
+		}
+	}
+	return nil
+}
+
+type isUploadFileStreamRequest_Payload interface {
+	isUploadFileStreamRequest_Payload()
+}
+
+type UploadFileStreamRequest_Info struct {
+	Info *UploadFileInfo `protobuf:"bytes,1,opt,name=info,proto3,oneof"`
+}
+
+type UploadFileStreamRequest_Chunk struct {
+	Chunk []byte `protobuf:"bytes,2,opt,name=chunk,proto3,oneof"`
+}
+
+func (*UploadFileStreamRequest_Info) isUploadFileStreamRequest_Payload() {}
+
+func (*UploadFileStreamRequest_Chunk) isUploadFileStreamRequest_Payload() {}
+
+type UploadFileInfo struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Filename string                 `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
+	// See UploadFileRequest.
+	StripMetadata *bool   `protobuf:"varint,2,opt,name=strip_metadata,json=stripMetadata,proto3,oneof" json:"strip_metadata,omitempty"`
+	KeepOriginal  bool    `protobuf:"varint,3,opt,name=keep_original,json=keepOriginal,proto3" json:"keep_original,omitempty"`
+	Sha256        string  `protobuf:"bytes,4,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	Crc32C        *uint32 `protobuf:"varint,5,opt,name=crc32c,proto3,oneof" json:"crc32c,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadFileInfo) Reset() {
+	*x = UploadFileInfo{}
+	mi := &file_api_file_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadFileInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadFileInfo) ProtoMessage() {}
+
+func (x *UploadFileInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_api_file_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadFileInfo.ProtoReflect.Descriptor instead.
+func (*UploadFileInfo) Descriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *UploadFileInfo) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *UploadFileInfo) GetStripMetadata() bool {
+	if x != nil && x.StripMetadata != nil {
+		return *x.StripMetadata
+	}
+	return false
+}
+
+func (x *UploadFileInfo) GetKeepOriginal() bool {
+	if x != nil {
+		return x.KeepOriginal
+	}
+	return false
+}
+
+func (x *UploadFileInfo) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+func (x *UploadFileInfo) GetCrc32C() uint32 {
+	if x != nil && x.Crc32C != nil {
+		return *x.Crc32C
+	}
+	return 0
+}
+
+type UploadFileResponse struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	FileId string                 `protobuf:"bytes,1,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+	// Checksums computed of the received content, which differs from the
+	// stored one when metadata was stripped, see FileInfo.
+	Sha256        string `protobuf:"bytes,2,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	Crc32C        uint32 `protobuf:"varint,3,opt,name=crc32c,proto3" json:"crc32c,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadFileResponse) Reset() {
+	*x = UploadFileResponse{}
+	mi := &file_api_file_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadFileResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadFileResponse) ProtoMessage() {}
+
+func (x *UploadFileResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_file_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadFileResponse.ProtoReflect.Descriptor instead.
+func (*UploadFileResponse) Descriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *UploadFileResponse) GetFileId() string {
+	if x != nil {
+		return x.FileId
+	}
+	return ""
+}
+
+func (x *UploadFileResponse) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+func (x *UploadFileResponse) GetCrc32C() uint32 {
+	if x != nil {
+		return x.Crc32C
+	}
+	return 0
+}
+
+type CheckContentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sha256        string                 `protobuf:"bytes,1,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckContentRequest) Reset() {
+	*x = CheckContentRequest{}
+	mi := &file_api_file_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckContentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckContentRequest) ProtoMessage() {}
+
+func (x *CheckContentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_file_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckContentRequest.ProtoReflect.Descriptor instead.
+func (*CheckContentRequest) Descriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CheckContentRequest) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+type CheckContentResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Proves possession of the content to UploadByReference within 10
+	// minutes. It is returned whether the content is stored or not.
+	Challenge     []byte `protobuf:"bytes,2,opt,name=challenge,proto3" json:"challenge,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckContentResponse) Reset() {
+	*x = CheckContentResponse{}
+	mi := &file_api_file_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckContentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckContentResponse) ProtoMessage() {}
+
+func (x *CheckContentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_file_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckContentResponse.ProtoReflect.Descriptor instead.
+func (*CheckContentResponse) Descriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CheckContentResponse) GetChallenge() []byte {
+	if x != nil {
+		return x.Challenge
+	}
+	return nil
+}
+
+// Creates a file with content already stored, so it isn't sent again. When
+// the content is not stored, e.g. it was removed by the garbage collector,
+// or the proof doesn't match, the call fails with NOT_FOUND and the file has
+// to be uploaded.
+type UploadByReferenceRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Filename string                 `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
+	Sha256   string                 `protobuf:"bytes,2,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	// See UploadFileRequest.
+	StripMetadata *bool `protobuf:"varint,3,opt,name=strip_metadata,json=stripMetadata,proto3,oneof" json:"strip_metadata,omitempty"`
+	KeepOriginal  bool  `protobuf:"varint,4,opt,name=keep_original,json=keepOriginal,proto3" json:"keep_original,omitempty"`
+	// Challenge returned by CheckContent for the sha256.
+	Challenge []byte `protobuf:"bytes,5,opt,name=challenge,proto3" json:"challenge,omitempty"`
+	// HMAC-SHA256 of the content keyed with the challenge.
+	Proof         []byte `protobuf:"bytes,6,opt,name=proof,proto3" json:"proof,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadByReferenceRequest) Reset() {
+	*x = UploadByReferenceRequest{}
+	mi := &file_api_file_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadByReferenceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadByReferenceRequest) ProtoMessage() {}
+
+func (x *UploadByReferenceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_file_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadByReferenceRequest.ProtoReflect.Descriptor instead.
+func (*UploadByReferenceRequest) Descriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *UploadByReferenceRequest) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *UploadByReferenceRequest) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+func (x *UploadByReferenceRequest) GetStripMetadata() bool {
+	if x != nil && x.StripMetadata != nil {
+		return *x.StripMetadata
+	}
+	return false
+}
+
+func (x *UploadByReferenceRequest) GetKeepOriginal() bool {
+	if x != nil {
+		return x.KeepOriginal
+	}
+	return false
+}
+
+func (x *UploadByReferenceRequest) GetChallenge() []byte {
+	if x != nil {
+		return x.Challenge
+	}
+	return nil
+}
+
+func (x *UploadByReferenceRequest) GetProof() []byte {
+	if x != nil {
+		return x.Proof
+	}
+	return nil
+}
+
+type UploadByReferenceResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FileId        string                 `protobuf:"bytes,1,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadByReferenceResponse) Reset() {
+	*x = UploadByReferenceResponse{}
+	mi := &file_api_file_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadByReferenceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadByReferenceResponse) ProtoMessage() {}
+
+func (x *UploadByReferenceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_file_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadByReferenceResponse.ProtoReflect.Descriptor instead.
+func (*UploadByReferenceResponse) Descriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *UploadByReferenceResponse) GetFileId() string {
+	if x != nil {
+		return x.FileId
+	}
+	return ""
+}
+
+type ViewFilesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Limit uint32                 `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	// Page number starting from 1, ignored when page_token is set.
+	//
+	// Deprecated: Marked as deprecated in api/file.proto.
+	Offset uint32 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	// Token of the next page returned by the previous call, valid with the
+	// same filter and sort only.
+	PageToken     string      `protobuf:"bytes,3,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	Filter        *FileFilter `protobuf:"bytes,4,opt,name=filter,proto3" json:"filter,omitempty"`
+	Sort          *FileSort   `protobuf:"bytes,5,opt,name=sort,proto3" json:"sort,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ViewFilesRequest) Reset() {
+	*x = ViewFilesRequest{}
+	mi := &file_api_file_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ViewFilesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ViewFilesRequest) ProtoMessage() {}
+
+func (x *ViewFilesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_file_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ViewFilesRequest.ProtoReflect.Descriptor instead.
+func (*ViewFilesRequest) Descriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ViewFilesRequest) GetLimit() uint32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+// Deprecated: Marked as deprecated in api/file.proto.
+func (x *ViewFilesRequest) GetOffset() uint32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ViewFilesRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *ViewFilesRequest) GetFilter() *FileFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *ViewFilesRequest) GetSort() *FileSort {
+	if x != nil {
+		return x.Sort
+	}
+	return nil
+}
+
+type FileFilter struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	NamePrefix   string                 `protobuf:"bytes,1,opt,name=name_prefix,json=namePrefix,proto3" json:"name_prefix,omitempty"`
+	NameContains string                 `protobuf:"bytes,2,opt,name=name_contains,json=nameContains,proto3" json:"name_contains,omitempty"`
+	// Time ranges include the start and exclude the end.
+	CreatedFrom *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=created_from,json=createdFrom,proto3" json:"created_from,omitempty"`
+	CreatedTo   *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_to,json=createdTo,proto3" json:"created_to,omitempty"`
+	UpdatedFrom *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=updated_from,json=updatedFrom,proto3" json:"updated_from,omitempty"`
+	UpdatedTo   *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=updated_to,json=updatedTo,proto3" json:"updated_to,omitempty"`
+	// Size range in bytes includes both ends.
+	MinSize       *uint64 `protobuf:"varint,7,opt,name=min_size,json=minSize,proto3,oneof" json:"min_size,omitempty"`
+	MaxSize       *uint64 `protobuf:"varint,8,opt,name=max_size,json=maxSize,proto3,oneof" json:"max_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileFilter) Reset() {
+	*x = FileFilter{}
+	mi := &file_api_file_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileFilter) ProtoMessage() {}
+
+func (x *FileFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_api_file_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileFilter.ProtoReflect.Descriptor instead.
+func (*FileFilter) Descriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *FileFilter) GetNamePrefix() string {
+	if x != nil {
+		return x.NamePrefix
+	}
+	return ""
+}
+
+func (x *FileFilter) GetNameContains() string {
+	if x != nil {
+		return x.NameContains
+	}
+	return ""
+}
+
+func (x *FileFilter) GetCreatedFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedFrom
+	}
+	return nil
+}
+
+func (x *FileFilter) GetCreatedTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedTo
+	}
+	return nil
+}
+
+func (x *FileFilter) GetUpdatedFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedFrom
+	}
+	return nil
+}
+
+func (x *FileFilter) GetUpdatedTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedTo
+	}
+	return nil
+}
+
+func (x *FileFilter) GetMinSize() uint64 {
+	if x != nil && x.MinSize != nil {
+		return *x.MinSize
+	}
+	return 0
+}
+
+func (x *FileFilter) GetMaxSize() uint64 {
+	if x != nil && x.MaxSize != nil {
+		return *x.MaxSize
+	}
+	return 0
+}
+
+type FileSort struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Field         SortField              `protobuf:"varint,1,opt,name=field,proto3,enum=file.SortField" json:"field,omitempty"`
+	Descending    bool                   `protobuf:"varint,2,opt,name=descending,proto3" json:"descending,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileSort) Reset() {
+	*x = FileSort{}
+	mi := &file_api_file_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileSort) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileSort) ProtoMessage() {}
+
+func (x *FileSort) ProtoReflect() protoreflect.Message {
+	mi := &file_api_file_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileSort.ProtoReflect.Descriptor instead.
+func (*FileSort) Descriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *FileSort) GetField() SortField {
+	if x != nil {
+		return x.Field
+	}
+	return SortField_SORT_FIELD_UNSPECIFIED
+}
+
+func (x *FileSort) GetDescending() bool {
+	if x != nil {
+		return x.Descending
+	}
+	return false
+}
+
+type FileInfo struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Filename  string                 `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	FileId    string                 `protobuf:"bytes,4,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+	// Size of the content in bytes.
+	Size uint64 `protobuf:"varint,5,opt,name=size,proto3" json:"size,omitempty"`
+	// Hex encoded SHA-256 of the content.
+	Sha256 string `protobuf:"bytes,6,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	// MIME type detected from the content.
+	ContentType string `protobuf:"bytes,7,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	// Set for images the service can read.
+	Image *ImageInfo `protobuf:"bytes,8,opt,name=image,proto3" json:"image,omitempty"`
+	// Hex encoded SHA-256 of the content as uploaded, set when metadata was
+	// stripped from it and the original was kept.
+	OriginalSha256 string `protobuf:"bytes,9,opt,name=original_sha256,json=originalSha256,proto3" json:"original_sha256,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *FileInfo) Reset() {
+	*x = FileInfo{}
+	mi := &file_api_file_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileInfo) ProtoMessage() {}
+
+func (x *FileInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_api_file_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileInfo.ProtoReflect.Descriptor instead.
+func (*FileInfo) Descriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *FileInfo) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *FileInfo) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *FileInfo) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *FileInfo) GetFileId() string {
+	if x != nil {
+		return x.FileId
+	}
+	return ""
+}
+
+func (x *FileInfo) GetSize() uint64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *FileInfo) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+func (x *FileInfo) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *FileInfo) GetImage() *ImageInfo {
+	if x != nil {
+		return x.Image
+	}
+	return nil
+}
+
+func (x *FileInfo) GetOriginalSha256() string {
+	if x != nil {
+		return x.OriginalSha256
+	}
+	return ""
+}
+
+type ImageInfo struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Stored dimensions in pixels, images with orientation 5 to 8 are
+	// displayed rotated by 90 degrees.
+	Width  uint32 `protobuf:"varint,1,opt,name=width,proto3" json:"width,omitempty"`
+	Height uint32 `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+	// Color model of the pixels, e.g. "ycbcr", "rgba" or "paletted".
+	ColorModel string `protobuf:"bytes,3,opt,name=color_model,json=colorModel,proto3" json:"color_model,omitempty"`
+	// Image format, e.g. "jpeg" or "png".
+	Format string `protobuf:"bytes,4,opt,name=format,proto3" json:"format,omitempty"`
+	// Capture time from EXIF, unset when unknown.
+	TakenAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=taken_at,json=takenAt,proto3" json:"taken_at,omitempty"`
+	CameraModel string                 `protobuf:"bytes,6,opt,name=camera_model,json=cameraModel,proto3" json:"camera_model,omitempty"`
+	// EXIF orientation from 1 to 8, 0 when unknown.
+	Orientation uint32 `protobuf:"varint,7,opt,name=orientation,proto3" json:"orientation,omitempty"`
+	// Whether EXIF holds the location of the capture.
+	HasGps        bool `protobuf:"varint,8,opt,name=has_gps,json=hasGps,proto3" json:"has_gps,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImageInfo) Reset() {
+	*x = ImageInfo{}
+	mi := &file_api_file_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImageInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImageInfo) ProtoMessage() {}
+
+func (x *ImageInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_api_file_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImageInfo.ProtoReflect.Descriptor instead.
+func (*ImageInfo) Descriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ImageInfo) GetWidth() uint32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *ImageInfo) GetHeight() uint32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *ImageInfo) GetColorModel() string {
+	if x != nil {
+		return x.ColorModel
+	}
+	return ""
+}
+
+func (x *ImageInfo) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *ImageInfo) GetTakenAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.TakenAt
+	}
+	return nil
+}
+
+func (x *ImageInfo) GetCameraModel() string {
+	if x != nil {
+		return x.CameraModel
+	}
+	return ""
+}
+
+func (x *ImageInfo) GetOrientation() uint32 {
+	if x != nil {
+		return x.Orientation
+	}
+	return 0
+}
+
+func (x *ImageInfo) GetHasGps() bool {
+	if x != nil {
+		return x.HasGps
+	}
+	return false
+}
+
+type ViewFilesResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Files []*FileInfo            `protobuf:"bytes,1,rep,name=files,proto3" json:"files,omitempty"`
+	// Empty on the last page.
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ViewFilesResponse) Reset() {
+	*x = ViewFilesResponse{}
+	mi := &file_api_file_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ViewFilesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ViewFilesResponse) ProtoMessage() {}
+
+func (x *ViewFilesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_file_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ViewFilesResponse.ProtoReflect.Descriptor instead.
+func (*ViewFilesResponse) Descriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ViewFilesResponse) GetFiles() []*FileInfo {
+	if x != nil {
+		return x.Files
+	}
+	return nil
+}
+
+func (x *ViewFilesResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type DownloadFileRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FileId        string                 `protobuf:"bytes,1,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DownloadFileRequest) Reset() {
+	*x = DownloadFileRequest{}
+	mi := &file_api_file_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadFileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadFileRequest) ProtoMessage() {}
+
+func (x *DownloadFileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_file_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadFileRequest.ProtoReflect.Descriptor instead.
+func (*DownloadFileRequest) Descriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *DownloadFileRequest) GetFileId() string {
+	if x != nil {
+		return x.FileId
+	}
+	return ""
+}
+
+type DownloadFileResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	Filename      string                 `protobuf:"bytes,2,opt,name=filename,proto3" json:"filename,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DownloadFileResponse) Reset() {
+	*x = DownloadFileResponse{}
+	mi := &file_api_file_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadFileResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadFileResponse) ProtoMessage() {}
+
+func (x *DownloadFileResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_file_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadFileResponse.ProtoReflect.Descriptor instead.
+func (*DownloadFileResponse) Descriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *DownloadFileResponse) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *DownloadFileResponse) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+// Requests the byte range [offset, offset + length) of the file. Zero length
+// means up to the end of the file.
+type DownloadFileStreamRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FileId        string                 `protobuf:"bytes,1,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+	Offset        uint64                 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	Length        uint64                 `protobuf:"varint,3,opt,name=length,proto3" json:"length,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DownloadFileStreamRequest) Reset() {
+	*x = DownloadFileStreamRequest{}
+	mi := &file_api_file_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadFileStreamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadFileStreamRequest) ProtoMessage() {}
+
+func (x *DownloadFileStreamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_file_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadFileStreamRequest.ProtoReflect.Descriptor instead.
+func (*DownloadFileStreamRequest) Descriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *DownloadFileStreamRequest) GetFileId() string {
+	if x != nil {
+		return x.FileId
+	}
+	return ""
+}
+
+func (x *DownloadFileStreamRequest) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *DownloadFileStreamRequest) GetLength() uint64 {
+	if x != nil {
+		return x.Length
+	}
+	return 0
+}
+
+// The first message of the stream carries the filename and the total size of
+// the file, every message carries the chunk and its offset in the file.
+type DownloadFileStreamResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Filename      string                 `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
+	Size          uint64                 `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	Offset        uint64                 `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	Chunk         []byte                 `protobuf:"bytes,4,opt,name=chunk,proto3" json:"chunk,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DownloadFileStreamResponse) Reset() {
+	*x = DownloadFileStreamResponse{}
+	mi := &file_api_file_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadFileStreamResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadFileStreamResponse) ProtoMessage() {}
+
+func (x *DownloadFileStreamResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_file_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadFileStreamResponse.ProtoReflect.Descriptor instead.
+func (*DownloadFileStreamResponse) Descriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *DownloadFileStreamResponse) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *DownloadFileStreamResponse) GetSize() uint64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *DownloadFileStreamResponse) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *DownloadFileStreamResponse) GetChunk() []byte {
+	if x != nil {
+		return x.Chunk
+	}
+	return nil
+}
+
+type GetFileMetadataRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FileId        string                 `protobuf:"bytes,1,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetFileMetadataRequest) Reset() {
+	*x = GetFileMetadataRequest{}
+	mi := &file_api_file_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetFileMetadataRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetFileMetadataRequest) ProtoMessage() {}
+
+func (x *GetFileMetadataRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_file_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetFileMetadataRequest.ProtoReflect.Descriptor instead.
+func (*GetFileMetadataRequest) Descriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *GetFileMetadataRequest) GetFileId() string {
+	if x != nil {
+		return x.FileId
+	}
+	return ""
+}
+
+type GetFileMetadataResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	File          *FileInfo              `protobuf:"bytes,1,opt,name=file,proto3" json:"file,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetFileMetadataResponse) Reset() {
+	*x = GetFileMetadataResponse{}
+	mi := &file_api_file_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetFileMetadataResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetFileMetadataResponse) ProtoMessage() {}
+
+func (x *GetFileMetadataResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_file_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetFileMetadataResponse.ProtoReflect.Descriptor instead.
+func (*GetFileMetadataResponse) Descriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *GetFileMetadataResponse) GetFile() *FileInfo {
+	if x != nil {
+		return x.File
+	}
+	return nil
+}
+
+type GetThumbnailRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	FileId string                 `protobuf:"bytes,1,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+	// Longest side of the thumbnail in pixels, one of the configured sizes.
+	Size          uint32 `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetThumbnailRequest) Reset() {
+	*x = GetThumbnailRequest{}
+	mi := &file_api_file_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetThumbnailRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetThumbnailRequest) ProtoMessage() {}
+
+func (x *GetThumbnailRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_file_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetThumbnailRequest.ProtoReflect.Descriptor instead.
+func (*GetThumbnailRequest) Descriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *GetThumbnailRequest) GetFileId() string {
+	if x != nil {
+		return x.FileId
+	}
+	return ""
+}
+
+func (x *GetThumbnailRequest) GetSize() uint32 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+type GetThumbnailResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// image/jpeg, or image/png for images with transparency.
+	ContentType   string `protobuf:"bytes,1,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	Data          []byte `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetThumbnailResponse) Reset() {
+	*x = GetThumbnailResponse{}
+	mi := &file_api_file_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetThumbnailResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetThumbnailResponse) ProtoMessage() {}
+
+func (x *GetThumbnailResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_file_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetThumbnailResponse.ProtoReflect.Descriptor instead.
+func (*GetThumbnailResponse) Descriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *GetThumbnailResponse) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *GetThumbnailResponse) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+// Region of an image in pixels from its top left corner.
+type CropRect struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	X             uint32                 `protobuf:"varint,1,opt,name=x,proto3" json:"x,omitempty"`
+	Y             uint32                 `protobuf:"varint,2,opt,name=y,proto3" json:"y,omitempty"`
+	Width         uint32                 `protobuf:"varint,3,opt,name=width,proto3" json:"width,omitempty"`
+	Height        uint32                 `protobuf:"varint,4,opt,name=height,proto3" json:"height,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CropRect) Reset() {
+	*x = CropRect{}
+	mi := &file_api_file_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CropRect) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CropRect) ProtoMessage() {}
+
+func (x *CropRect) ProtoReflect() protoreflect.Message {
+	mi := &file_api_file_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CropRect.ProtoReflect.Descriptor instead.
+func (*CropRect) Descriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *CropRect) GetX() uint32 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *CropRect) GetY() uint32 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+func (x *CropRect) GetWidth() uint32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *CropRect) GetHeight() uint32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+type DownloadTransformedRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	FileId string                 `protobuf:"bytes,1,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+	// Region of the image as shown, with its EXIF orientation applied,
+	// limited to the image bounds. Unset means the whole image. The region
+	// is extended to multiples of 8 pixels.
+	Crop *CropRect `protobuf:"bytes,2,opt,name=crop,proto3" json:"crop,omitempty"`
+	// Box the crop is scaled to, up to 4096 pixels and rounded up to a
+	// multiple of 8. Zero width or height is derived from the aspect ratio,
+	// both zero keep the size of the crop.
+	Width  uint32       `protobuf:"varint,3,opt,name=width,proto3" json:"width,omitempty"`
+	Height uint32       `protobuf:"varint,4,opt,name=height,proto3" json:"height,omitempty"`
+	Fit    FitMode      `protobuf:"varint,5,opt,name=fit,proto3,enum=file.FitMode" json:"fit,omitempty"`
+	Format OutputFormat `protobuf:"varint,6,opt,name=format,proto3,enum=file.OutputFormat" json:"format,omitempty"`
+	// JPEG quality from 1 to 100 rounded up to a multiple of 5, zero means
+	// 85.
+	Quality       uint32 `protobuf:"varint,7,opt,name=quality,proto3" json:"quality,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DownloadTransformedRequest) Reset() {
+	*x = DownloadTransformedRequest{}
+	mi := &file_api_file_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadTransformedRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadTransformedRequest) ProtoMessage() {}
+
+func (x *DownloadTransformedRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_file_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadTransformedRequest.ProtoReflect.Descriptor instead.
+func (*DownloadTransformedRequest) Descriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *DownloadTransformedRequest) GetFileId() string {
+	if x != nil {
+		return x.FileId
+	}
+	return ""
+}
+
+func (x *DownloadTransformedRequest) GetCrop() *CropRect {
+	if x != nil {
+		return x.Crop
+	}
+	return nil
+}
+
+func (x *DownloadTransformedRequest) GetWidth() uint32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *DownloadTransformedRequest) GetHeight() uint32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *DownloadTransformedRequest) GetFit() FitMode {
+	if x != nil {
+		return x.Fit
+	}
+	return FitMode_FIT_MODE_UNSPECIFIED
+}
+
+func (x *DownloadTransformedRequest) GetFormat() OutputFormat {
+	if x != nil {
+		return x.Format
+	}
+	return OutputFormat_OUTPUT_FORMAT_UNSPECIFIED
+}
+
+func (x *DownloadTransformedRequest) GetQuality() uint32 {
+	if x != nil {
+		return x.Quality
+	}
+	return 0
+}
+
+type DownloadTransformedResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Filename      string                 `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
+	ContentType   string                 `protobuf:"bytes,1,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
 	Data          []byte                 `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *UploadFileRequest) Reset() {
-	*x = UploadFileRequest{}
-	mi := &file_api_file_proto_msgTypes[0]
+func (x *DownloadTransformedResponse) Reset() {
+	*x = DownloadTransformedResponse{}
+	mi := &file_api_file_proto_msgTypes[24]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UploadFileRequest) String() string {
+func (x *DownloadTransformedResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UploadFileRequest) ProtoMessage() {}
+func (*DownloadTransformedResponse) ProtoMessage() {}
 
-func (x *UploadFileRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_file_proto_msgTypes[0]
+func (x *DownloadTransformedResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_file_proto_msgTypes[24]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -55,47 +1858,47 @@ func (x *UploadFileRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UploadFileRequest.ProtoReflect.Descriptor instead.
-func (*UploadFileRequest) Descriptor() ([]byte, []int) {
-	return file_api_file_proto_rawDescGZIP(), []int{0}
+// Deprecated: Use DownloadTransformedResponse.ProtoReflect.Descriptor instead.
+func (*DownloadTransformedResponse) Descriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{24}
 }
 
-func (x *UploadFileRequest) GetFilename() string {
+func (x *DownloadTransformedResponse) GetContentType() string {
 	if x != nil {
-		return x.Filename
+		return x.ContentType
 	}
 	return ""
 }
 
-func (x *UploadFileRequest) GetData() []byte {
+func (x *DownloadTransformedResponse) GetData() []byte {
 	if x != nil {
 		return x.Data
 	}
 	return nil
 }
 
-type UploadFileResponse struct {
+type DeleteFileRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	FileId        string                 `protobuf:"bytes,1,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *UploadFileResponse) Reset() {
-	*x = UploadFileResponse{}
-	mi := &file_api_file_proto_msgTypes[1]
+func (x *DeleteFileRequest) Reset() {
+	*x = DeleteFileRequest{}
+	mi := &file_api_file_proto_msgTypes[25]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UploadFileResponse) String() string {
+func (x *DeleteFileRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UploadFileResponse) ProtoMessage() {}
+func (*DeleteFileRequest) ProtoMessage() {}
 
-func (x *UploadFileResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_file_proto_msgTypes[1]
+func (x *DeleteFileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_file_proto_msgTypes[25]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -106,41 +1909,39 @@ func (x *UploadFileResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UploadFileResponse.ProtoReflect.Descriptor instead.
-func (*UploadFileResponse) Descriptor() ([]byte, []int) {
-	return file_api_file_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use DeleteFileRequest.ProtoReflect.Descriptor instead.
+func (*DeleteFileRequest) Descriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{25}
 }
 
-func (x *UploadFileResponse) GetFileId() string {
+func (x *DeleteFileRequest) GetFileId() string {
 	if x != nil {
 		return x.FileId
 	}
 	return ""
 }
 
-type ViewFilesRequest struct {
+type DeleteFileResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Limit         uint32                 `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
-	Offset        uint32                 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ViewFilesRequest) Reset() {
-	*x = ViewFilesRequest{}
-	mi := &file_api_file_proto_msgTypes[2]
+func (x *DeleteFileResponse) Reset() {
+	*x = DeleteFileResponse{}
+	mi := &file_api_file_proto_msgTypes[26]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ViewFilesRequest) String() string {
+func (x *DeleteFileResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ViewFilesRequest) ProtoMessage() {}
+func (*DeleteFileResponse) ProtoMessage() {}
 
-func (x *ViewFilesRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_file_proto_msgTypes[2]
+func (x *DeleteFileResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_file_proto_msgTypes[26]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -151,47 +1952,95 @@ func (x *ViewFilesRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ViewFilesRequest.ProtoReflect.Descriptor instead.
-func (*ViewFilesRequest) Descriptor() ([]byte, []int) {
-	return file_api_file_proto_rawDescGZIP(), []int{2}
+// Deprecated: Use DeleteFileResponse.ProtoReflect.Descriptor instead.
+func (*DeleteFileResponse) Descriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{26}
 }
 
-func (x *ViewFilesRequest) GetLimit() uint32 {
+type StartUploadRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Filename string                 `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
+	// See UploadFileRequest.
+	StripMetadata *bool `protobuf:"varint,2,opt,name=strip_metadata,json=stripMetadata,proto3,oneof" json:"strip_metadata,omitempty"`
+	KeepOriginal  bool  `protobuf:"varint,3,opt,name=keep_original,json=keepOriginal,proto3" json:"keep_original,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartUploadRequest) Reset() {
+	*x = StartUploadRequest{}
+	mi := &file_api_file_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartUploadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartUploadRequest) ProtoMessage() {}
+
+func (x *StartUploadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_file_proto_msgTypes[27]
 	if x != nil {
-		return x.Limit
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return 0
+	return mi.MessageOf(x)
 }
 
-func (x *ViewFilesRequest) GetOffset() uint32 {
+// Deprecated: Use StartUploadRequest.ProtoReflect.Descriptor instead.
+func (*StartUploadRequest) Descriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *StartUploadRequest) GetFilename() string {
 	if x != nil {
-		return x.Offset
+		return x.Filename
 	}
-	return 0
+	return ""
 }
 
-type ViewFilesResponse struct {
-	state         protoimpl.MessageState        `protogen:"open.v1"`
-	Files         []*ViewFilesResponse_FileInfo `protobuf:"bytes,1,rep,name=files,proto3" json:"files,omitempty"`
+func (x *StartUploadRequest) GetStripMetadata() bool {
+	if x != nil && x.StripMetadata != nil {
+		return *x.StripMetadata
+	}
+	return false
+}
+
+func (x *StartUploadRequest) GetKeepOriginal() bool {
+	if x != nil {
+		return x.KeepOriginal
+	}
+	return false
+}
+
+type StartUploadResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ViewFilesResponse) Reset() {
-	*x = ViewFilesResponse{}
-	mi := &file_api_file_proto_msgTypes[3]
+func (x *StartUploadResponse) Reset() {
+	*x = StartUploadResponse{}
+	mi := &file_api_file_proto_msgTypes[28]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ViewFilesResponse) String() string {
+func (x *StartUploadResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ViewFilesResponse) ProtoMessage() {}
+func (*StartUploadResponse) ProtoMessage() {}
 
-func (x *ViewFilesResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_file_proto_msgTypes[3]
+func (x *StartUploadResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_file_proto_msgTypes[28]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -202,40 +2051,51 @@ func (x *ViewFilesResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ViewFilesResponse.ProtoReflect.Descriptor instead.
-func (*ViewFilesResponse) Descriptor() ([]byte, []int) {
-	return file_api_file_proto_rawDescGZIP(), []int{3}
+// Deprecated: Use StartUploadResponse.ProtoReflect.Descriptor instead.
+func (*StartUploadResponse) Descriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{28}
 }
 
-func (x *ViewFilesResponse) GetFiles() []*ViewFilesResponse_FileInfo {
+func (x *StartUploadResponse) GetSessionId() string {
 	if x != nil {
-		return x.Files
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *StartUploadResponse) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
 	}
 	return nil
 }
 
-type DownloadFileRequest struct {
+// The offset must be equal to the number of bytes already written to the
+// session, see GetUploadStatus.
+type AppendChunkRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	FileId        string                 `protobuf:"bytes,1,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Offset        uint64                 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	Chunk         []byte                 `protobuf:"bytes,3,opt,name=chunk,proto3" json:"chunk,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DownloadFileRequest) Reset() {
-	*x = DownloadFileRequest{}
-	mi := &file_api_file_proto_msgTypes[4]
+func (x *AppendChunkRequest) Reset() {
+	*x = AppendChunkRequest{}
+	mi := &file_api_file_proto_msgTypes[29]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DownloadFileRequest) String() string {
+func (x *AppendChunkRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DownloadFileRequest) ProtoMessage() {}
+func (*AppendChunkRequest) ProtoMessage() {}
 
-func (x *DownloadFileRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_file_proto_msgTypes[4]
+func (x *AppendChunkRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_file_proto_msgTypes[29]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -246,41 +2106,55 @@ func (x *DownloadFileRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DownloadFileRequest.ProtoReflect.Descriptor instead.
-func (*DownloadFileRequest) Descriptor() ([]byte, []int) {
-	return file_api_file_proto_rawDescGZIP(), []int{4}
+// Deprecated: Use AppendChunkRequest.ProtoReflect.Descriptor instead.
+func (*AppendChunkRequest) Descriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{29}
 }
 
-func (x *DownloadFileRequest) GetFileId() string {
+func (x *AppendChunkRequest) GetSessionId() string {
 	if x != nil {
-		return x.FileId
+		return x.SessionId
 	}
 	return ""
 }
 
-type DownloadFileResponse struct {
+func (x *AppendChunkRequest) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *AppendChunkRequest) GetChunk() []byte {
+	if x != nil {
+		return x.Chunk
+	}
+	return nil
+}
+
+type AppendChunkResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
-	Filename      string                 `protobuf:"bytes,2,opt,name=filename,proto3" json:"filename,omitempty"`
+	Offset        uint64                 `protobuf:"varint,1,opt,name=offset,proto3" json:"offset,omitempty"`
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DownloadFileResponse) Reset() {
-	*x = DownloadFileResponse{}
-	mi := &file_api_file_proto_msgTypes[5]
+func (x *AppendChunkResponse) Reset() {
+	*x = AppendChunkResponse{}
+	mi := &file_api_file_proto_msgTypes[30]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DownloadFileResponse) String() string {
+func (x *AppendChunkResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DownloadFileResponse) ProtoMessage() {}
+func (*AppendChunkResponse) ProtoMessage() {}
 
-func (x *DownloadFileResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_file_proto_msgTypes[5]
+func (x *AppendChunkResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_file_proto_msgTypes[30]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -291,49 +2165,93 @@ func (x *DownloadFileResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DownloadFileResponse.ProtoReflect.Descriptor instead.
-func (*DownloadFileResponse) Descriptor() ([]byte, []int) {
-	return file_api_file_proto_rawDescGZIP(), []int{5}
+// Deprecated: Use AppendChunkResponse.ProtoReflect.Descriptor instead.
+func (*AppendChunkResponse) Descriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{30}
 }
 
-func (x *DownloadFileResponse) GetData() []byte {
+func (x *AppendChunkResponse) GetOffset() uint64 {
 	if x != nil {
-		return x.Data
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *AppendChunkResponse) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
 	}
 	return nil
 }
 
-func (x *DownloadFileResponse) GetFilename() string {
+type GetUploadStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUploadStatusRequest) Reset() {
+	*x = GetUploadStatusRequest{}
+	mi := &file_api_file_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUploadStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUploadStatusRequest) ProtoMessage() {}
+
+func (x *GetUploadStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_file_proto_msgTypes[31]
 	if x != nil {
-		return x.Filename
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUploadStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetUploadStatusRequest) Descriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *GetUploadStatusRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
 	}
 	return ""
 }
 
-type ViewFilesResponse_FileInfo struct {
+type GetUploadStatusResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Filename      string                 `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
-	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
-	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	Offset        uint64                 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ViewFilesResponse_FileInfo) Reset() {
-	*x = ViewFilesResponse_FileInfo{}
-	mi := &file_api_file_proto_msgTypes[6]
+func (x *GetUploadStatusResponse) Reset() {
+	*x = GetUploadStatusResponse{}
+	mi := &file_api_file_proto_msgTypes[32]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ViewFilesResponse_FileInfo) String() string {
+func (x *GetUploadStatusResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ViewFilesResponse_FileInfo) ProtoMessage() {}
+func (*GetUploadStatusResponse) ProtoMessage() {}
 
-func (x *ViewFilesResponse_FileInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_api_file_proto_msgTypes[6]
+func (x *GetUploadStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_file_proto_msgTypes[32]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -344,63 +2262,334 @@ func (x *ViewFilesResponse_FileInfo) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ViewFilesResponse_FileInfo.ProtoReflect.Descriptor instead.
-func (*ViewFilesResponse_FileInfo) Descriptor() ([]byte, []int) {
-	return file_api_file_proto_rawDescGZIP(), []int{3, 0}
+// Deprecated: Use GetUploadStatusResponse.ProtoReflect.Descriptor instead.
+func (*GetUploadStatusResponse) Descriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{32}
 }
 
-func (x *ViewFilesResponse_FileInfo) GetFilename() string {
+func (x *GetUploadStatusResponse) GetFilename() string {
 	if x != nil {
 		return x.Filename
 	}
 	return ""
 }
 
-func (x *ViewFilesResponse_FileInfo) GetCreatedAt() *timestamppb.Timestamp {
+func (x *GetUploadStatusResponse) GetOffset() uint64 {
 	if x != nil {
-		return x.CreatedAt
+		return x.Offset
 	}
-	return nil
+	return 0
 }
 
-func (x *ViewFilesResponse_FileInfo) GetUpdatedAt() *timestamppb.Timestamp {
+func (x *GetUploadStatusResponse) GetExpiresAt() *timestamppb.Timestamp {
 	if x != nil {
-		return x.UpdatedAt
+		return x.ExpiresAt
 	}
 	return nil
 }
 
+// The sha256 is the hex encoded hash of the whole uploaded content.
+type CommitUploadRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Sha256        string                 `protobuf:"bytes,2,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommitUploadRequest) Reset() {
+	*x = CommitUploadRequest{}
+	mi := &file_api_file_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommitUploadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommitUploadRequest) ProtoMessage() {}
+
+func (x *CommitUploadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_file_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommitUploadRequest.ProtoReflect.Descriptor instead.
+func (*CommitUploadRequest) Descriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *CommitUploadRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *CommitUploadRequest) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+type CommitUploadResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FileId        string                 `protobuf:"bytes,1,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommitUploadResponse) Reset() {
+	*x = CommitUploadResponse{}
+	mi := &file_api_file_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommitUploadResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommitUploadResponse) ProtoMessage() {}
+
+func (x *CommitUploadResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_file_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommitUploadResponse.ProtoReflect.Descriptor instead.
+func (*CommitUploadResponse) Descriptor() ([]byte, []int) {
+	return file_api_file_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *CommitUploadResponse) GetFileId() string {
+	if x != nil {
+		return x.FileId
+	}
+	return ""
+}
+
 var File_api_file_proto protoreflect.FileDescriptor
 
 const file_api_file_proto_rawDesc = "" +
 	"\n" +
-	"\x0eapi/file.proto\x12\x04file\x1a\x1fgoogle/protobuf/timestamp.proto\"C\n" +
+	"\x0eapi/file.proto\x12\x04file\x1a\x1fgoogle/protobuf/timestamp.proto\"\xe7\x01\n" +
 	"\x11UploadFileRequest\x12\x1a\n" +
 	"\bfilename\x18\x01 \x01(\tR\bfilename\x12\x12\n" +
-	"\x04data\x18\x02 \x01(\fR\x04data\"-\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\x12*\n" +
+	"\x0estrip_metadata\x18\x03 \x01(\bH\x00R\rstripMetadata\x88\x01\x01\x12#\n" +
+	"\rkeep_original\x18\x04 \x01(\bR\fkeepOriginal\x12\x16\n" +
+	"\x06sha256\x18\x05 \x01(\tR\x06sha256\x12\x1b\n" +
+	"\x06crc32c\x18\x06 \x01(\rH\x01R\x06crc32c\x88\x01\x01B\x11\n" +
+	"\x0f_strip_metadataB\t\n" +
+	"\a_crc32c\"h\n" +
+	"\x17UploadFileStreamRequest\x12*\n" +
+	"\x04info\x18\x01 \x01(\v2\x14.file.UploadFileInfoH\x00R\x04info\x12\x16\n" +
+	"\x05chunk\x18\x02 \x01(\fH\x00R\x05chunkB\t\n" +
+	"\apayload\"\xd0\x01\n" +
+	"\x0eUploadFileInfo\x12\x1a\n" +
+	"\bfilename\x18\x01 \x01(\tR\bfilename\x12*\n" +
+	"\x0estrip_metadata\x18\x02 \x01(\bH\x00R\rstripMetadata\x88\x01\x01\x12#\n" +
+	"\rkeep_original\x18\x03 \x01(\bR\fkeepOriginal\x12\x16\n" +
+	"\x06sha256\x18\x04 \x01(\tR\x06sha256\x12\x1b\n" +
+	"\x06crc32c\x18\x05 \x01(\rH\x01R\x06crc32c\x88\x01\x01B\x11\n" +
+	"\x0f_strip_metadataB\t\n" +
+	"\a_crc32c\"]\n" +
 	"\x12UploadFileResponse\x12\x17\n" +
-	"\afile_id\x18\x01 \x01(\tR\x06fileId\"@\n" +
+	"\afile_id\x18\x01 \x01(\tR\x06fileId\x12\x16\n" +
+	"\x06sha256\x18\x02 \x01(\tR\x06sha256\x12\x16\n" +
+	"\x06crc32c\x18\x03 \x01(\rR\x06crc32c\"-\n" +
+	"\x13CheckContentRequest\x12\x16\n" +
+	"\x06sha256\x18\x01 \x01(\tR\x06sha256\"B\n" +
+	"\x14CheckContentResponse\x12\x1c\n" +
+	"\tchallenge\x18\x02 \x01(\fR\tchallengeJ\x04\b\x01\x10\x02R\x06exists\"\xe6\x01\n" +
+	"\x18UploadByReferenceRequest\x12\x1a\n" +
+	"\bfilename\x18\x01 \x01(\tR\bfilename\x12\x16\n" +
+	"\x06sha256\x18\x02 \x01(\tR\x06sha256\x12*\n" +
+	"\x0estrip_metadata\x18\x03 \x01(\bH\x00R\rstripMetadata\x88\x01\x01\x12#\n" +
+	"\rkeep_original\x18\x04 \x01(\bR\fkeepOriginal\x12\x1c\n" +
+	"\tchallenge\x18\x05 \x01(\fR\tchallenge\x12\x14\n" +
+	"\x05proof\x18\x06 \x01(\fR\x05proofB\x11\n" +
+	"\x0f_strip_metadata\"4\n" +
+	"\x19UploadByReferenceResponse\x12\x17\n" +
+	"\afile_id\x18\x01 \x01(\tR\x06fileId\"\xb1\x01\n" +
 	"\x10ViewFilesRequest\x12\x14\n" +
-	"\x05limit\x18\x01 \x01(\rR\x05limit\x12\x16\n" +
-	"\x06offset\x18\x02 \x01(\rR\x06offset\"\xea\x01\n" +
-	"\x11ViewFilesResponse\x126\n" +
-	"\x05files\x18\x01 \x03(\v2 .file.ViewFilesResponse.FileInfoR\x05files\x1a\x9c\x01\n" +
+	"\x05limit\x18\x01 \x01(\rR\x05limit\x12\x1a\n" +
+	"\x06offset\x18\x02 \x01(\rB\x02\x18\x01R\x06offset\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x03 \x01(\tR\tpageToken\x12(\n" +
+	"\x06filter\x18\x04 \x01(\v2\x10.file.FileFilterR\x06filter\x12\"\n" +
+	"\x04sort\x18\x05 \x01(\v2\x0e.file.FileSortR\x04sort\"\xa0\x03\n" +
+	"\n" +
+	"FileFilter\x12\x1f\n" +
+	"\vname_prefix\x18\x01 \x01(\tR\n" +
+	"namePrefix\x12#\n" +
+	"\rname_contains\x18\x02 \x01(\tR\fnameContains\x12=\n" +
+	"\fcreated_from\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\vcreatedFrom\x129\n" +
+	"\n" +
+	"created_to\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedTo\x12=\n" +
+	"\fupdated_from\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\vupdatedFrom\x129\n" +
+	"\n" +
+	"updated_to\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedTo\x12\x1e\n" +
+	"\bmin_size\x18\a \x01(\x04H\x00R\aminSize\x88\x01\x01\x12\x1e\n" +
+	"\bmax_size\x18\b \x01(\x04H\x01R\amaxSize\x88\x01\x01B\v\n" +
+	"\t_min_sizeB\v\n" +
+	"\t_max_size\"Q\n" +
+	"\bFileSort\x12%\n" +
+	"\x05field\x18\x01 \x01(\x0e2\x0f.file.SortFieldR\x05field\x12\x1e\n" +
+	"\n" +
+	"descending\x18\x02 \x01(\bR\n" +
+	"descending\"\xd4\x02\n" +
 	"\bFileInfo\x12\x1a\n" +
 	"\bfilename\x18\x01 \x01(\tR\bfilename\x129\n" +
 	"\n" +
 	"created_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
 	"\n" +
-	"updated_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\".\n" +
+	"updated_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x17\n" +
+	"\afile_id\x18\x04 \x01(\tR\x06fileId\x12\x12\n" +
+	"\x04size\x18\x05 \x01(\x04R\x04size\x12\x16\n" +
+	"\x06sha256\x18\x06 \x01(\tR\x06sha256\x12!\n" +
+	"\fcontent_type\x18\a \x01(\tR\vcontentType\x12%\n" +
+	"\x05image\x18\b \x01(\v2\x0f.file.ImageInfoR\x05image\x12'\n" +
+	"\x0foriginal_sha256\x18\t \x01(\tR\x0eoriginalSha256\"\x87\x02\n" +
+	"\tImageInfo\x12\x14\n" +
+	"\x05width\x18\x01 \x01(\rR\x05width\x12\x16\n" +
+	"\x06height\x18\x02 \x01(\rR\x06height\x12\x1f\n" +
+	"\vcolor_model\x18\x03 \x01(\tR\n" +
+	"colorModel\x12\x16\n" +
+	"\x06format\x18\x04 \x01(\tR\x06format\x125\n" +
+	"\btaken_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\atakenAt\x12!\n" +
+	"\fcamera_model\x18\x06 \x01(\tR\vcameraModel\x12 \n" +
+	"\vorientation\x18\a \x01(\rR\vorientation\x12\x17\n" +
+	"\ahas_gps\x18\b \x01(\bR\x06hasGps\"a\n" +
+	"\x11ViewFilesResponse\x12$\n" +
+	"\x05files\x18\x01 \x03(\v2\x0e.file.FileInfoR\x05files\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\".\n" +
 	"\x13DownloadFileRequest\x12\x17\n" +
 	"\afile_id\x18\x01 \x01(\tR\x06fileId\"F\n" +
 	"\x14DownloadFileResponse\x12\x12\n" +
 	"\x04data\x18\x01 \x01(\fR\x04data\x12\x1a\n" +
-	"\bfilename\x18\x02 \x01(\tR\bfilename2\xd3\x01\n" +
+	"\bfilename\x18\x02 \x01(\tR\bfilename\"d\n" +
+	"\x19DownloadFileStreamRequest\x12\x17\n" +
+	"\afile_id\x18\x01 \x01(\tR\x06fileId\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x04R\x06offset\x12\x16\n" +
+	"\x06length\x18\x03 \x01(\x04R\x06length\"z\n" +
+	"\x1aDownloadFileStreamResponse\x12\x1a\n" +
+	"\bfilename\x18\x01 \x01(\tR\bfilename\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x04R\x04size\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x04R\x06offset\x12\x14\n" +
+	"\x05chunk\x18\x04 \x01(\fR\x05chunk\"1\n" +
+	"\x16GetFileMetadataRequest\x12\x17\n" +
+	"\afile_id\x18\x01 \x01(\tR\x06fileId\"=\n" +
+	"\x17GetFileMetadataResponse\x12\"\n" +
+	"\x04file\x18\x01 \x01(\v2\x0e.file.FileInfoR\x04file\"B\n" +
+	"\x13GetThumbnailRequest\x12\x17\n" +
+	"\afile_id\x18\x01 \x01(\tR\x06fileId\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\rR\x04size\"M\n" +
+	"\x14GetThumbnailResponse\x12!\n" +
+	"\fcontent_type\x18\x01 \x01(\tR\vcontentType\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\"T\n" +
+	"\bCropRect\x12\f\n" +
+	"\x01x\x18\x01 \x01(\rR\x01x\x12\f\n" +
+	"\x01y\x18\x02 \x01(\rR\x01y\x12\x14\n" +
+	"\x05width\x18\x03 \x01(\rR\x05width\x12\x16\n" +
+	"\x06height\x18\x04 \x01(\rR\x06height\"\xee\x01\n" +
+	"\x1aDownloadTransformedRequest\x12\x17\n" +
+	"\afile_id\x18\x01 \x01(\tR\x06fileId\x12\"\n" +
+	"\x04crop\x18\x02 \x01(\v2\x0e.file.CropRectR\x04crop\x12\x14\n" +
+	"\x05width\x18\x03 \x01(\rR\x05width\x12\x16\n" +
+	"\x06height\x18\x04 \x01(\rR\x06height\x12\x1f\n" +
+	"\x03fit\x18\x05 \x01(\x0e2\r.file.FitModeR\x03fit\x12*\n" +
+	"\x06format\x18\x06 \x01(\x0e2\x12.file.OutputFormatR\x06format\x12\x18\n" +
+	"\aquality\x18\a \x01(\rR\aquality\"T\n" +
+	"\x1bDownloadTransformedResponse\x12!\n" +
+	"\fcontent_type\x18\x01 \x01(\tR\vcontentType\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\",\n" +
+	"\x11DeleteFileRequest\x12\x17\n" +
+	"\afile_id\x18\x01 \x01(\tR\x06fileId\"\x14\n" +
+	"\x12DeleteFileResponse\"\x94\x01\n" +
+	"\x12StartUploadRequest\x12\x1a\n" +
+	"\bfilename\x18\x01 \x01(\tR\bfilename\x12*\n" +
+	"\x0estrip_metadata\x18\x02 \x01(\bH\x00R\rstripMetadata\x88\x01\x01\x12#\n" +
+	"\rkeep_original\x18\x03 \x01(\bR\fkeepOriginalB\x11\n" +
+	"\x0f_strip_metadata\"o\n" +
+	"\x13StartUploadResponse\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x129\n" +
+	"\n" +
+	"expires_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\"a\n" +
+	"\x12AppendChunkRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x04R\x06offset\x12\x14\n" +
+	"\x05chunk\x18\x03 \x01(\fR\x05chunk\"h\n" +
+	"\x13AppendChunkResponse\x12\x16\n" +
+	"\x06offset\x18\x01 \x01(\x04R\x06offset\x129\n" +
+	"\n" +
+	"expires_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\"7\n" +
+	"\x16GetUploadStatusRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\"\x88\x01\n" +
+	"\x17GetUploadStatusResponse\x12\x1a\n" +
+	"\bfilename\x18\x01 \x01(\tR\bfilename\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x04R\x06offset\x129\n" +
+	"\n" +
+	"expires_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\"L\n" +
+	"\x13CommitUploadRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x16\n" +
+	"\x06sha256\x18\x02 \x01(\tR\x06sha256\"/\n" +
+	"\x14CommitUploadResponse\x12\x17\n" +
+	"\afile_id\x18\x01 \x01(\tR\x06fileId*\x87\x01\n" +
+	"\tSortField\x12\x1a\n" +
+	"\x16SORT_FIELD_UNSPECIFIED\x10\x00\x12\x19\n" +
+	"\x15SORT_FIELD_UPDATED_AT\x10\x01\x12\x19\n" +
+	"\x15SORT_FIELD_CREATED_AT\x10\x02\x12\x13\n" +
+	"\x0fSORT_FIELD_NAME\x10\x03\x12\x13\n" +
+	"\x0fSORT_FIELD_SIZE\x10\x04*`\n" +
+	"\aFitMode\x12\x18\n" +
+	"\x14FIT_MODE_UNSPECIFIED\x10\x00\x12\x14\n" +
+	"\x10FIT_MODE_CONTAIN\x10\x01\x12\x12\n" +
+	"\x0eFIT_MODE_COVER\x10\x02\x12\x11\n" +
+	"\rFIT_MODE_FILL\x10\x03*\\\n" +
+	"\fOutputFormat\x12\x1d\n" +
+	"\x19OUTPUT_FORMAT_UNSPECIFIED\x10\x00\x12\x16\n" +
+	"\x12OUTPUT_FORMAT_JPEG\x10\x01\x12\x15\n" +
+	"\x11OUTPUT_FORMAT_PNG\x10\x022\xed\b\n" +
 	"\vFileService\x12?\n" +
 	"\n" +
-	"UploadFile\x12\x17.file.UploadFileRequest\x1a\x18.file.UploadFileResponse\x12<\n" +
+	"UploadFile\x12\x17.file.UploadFileRequest\x1a\x18.file.UploadFileResponse\x12M\n" +
+	"\x10UploadFileStream\x12\x1d.file.UploadFileStreamRequest\x1a\x18.file.UploadFileResponse(\x01\x12E\n" +
+	"\fCheckContent\x12\x19.file.CheckContentRequest\x1a\x1a.file.CheckContentResponse\x12T\n" +
+	"\x11UploadByReference\x12\x1e.file.UploadByReferenceRequest\x1a\x1f.file.UploadByReferenceResponse\x12<\n" +
 	"\tViewFiles\x12\x16.file.ViewFilesRequest\x1a\x17.file.ViewFilesResponse\x12E\n" +
-	"\fDownloadFile\x12\x19.file.DownloadFileRequest\x1a\x1a.file.DownloadFileResponseB\x10Z\x0e./internal/apib\x06proto3"
+	"\fDownloadFile\x12\x19.file.DownloadFileRequest\x1a\x1a.file.DownloadFileResponse\x12Y\n" +
+	"\x12DownloadFileStream\x12\x1f.file.DownloadFileStreamRequest\x1a .file.DownloadFileStreamResponse0\x01\x12?\n" +
+	"\n" +
+	"DeleteFile\x12\x17.file.DeleteFileRequest\x1a\x18.file.DeleteFileResponse\x12N\n" +
+	"\x0fGetFileMetadata\x12\x1c.file.GetFileMetadataRequest\x1a\x1d.file.GetFileMetadataResponse\x12E\n" +
+	"\fGetThumbnail\x12\x19.file.GetThumbnailRequest\x1a\x1a.file.GetThumbnailResponse\x12Z\n" +
+	"\x13DownloadTransformed\x12 .file.DownloadTransformedRequest\x1a!.file.DownloadTransformedResponse\x12B\n" +
+	"\vStartUpload\x12\x18.file.StartUploadRequest\x1a\x19.file.StartUploadResponse\x12B\n" +
+	"\vAppendChunk\x12\x18.file.AppendChunkRequest\x1a\x19.file.AppendChunkResponse\x12N\n" +
+	"\x0fGetUploadStatus\x12\x1c.file.GetUploadStatusRequest\x1a\x1d.file.GetUploadStatusResponse\x12E\n" +
+	"\fCommitUpload\x12\x19.file.CommitUploadRequest\x1a\x1a.file.CommitUploadResponseB\x10Z\x0e./internal/apib\x06proto3"
 
 var (
 	file_api_file_proto_rawDescOnce sync.Once
@@ -414,32 +2603,105 @@ func file_api_file_proto_rawDescGZIP() []byte {
 	return file_api_file_proto_rawDescData
 }
 
-var file_api_file_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_api_file_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_api_file_proto_msgTypes = make([]protoimpl.MessageInfo, 35)
 var file_api_file_proto_goTypes = []any{
-	(*UploadFileRequest)(nil),          // 0: file.UploadFileRequest
-	(*UploadFileResponse)(nil),         // 1: file.UploadFileResponse
-	(*ViewFilesRequest)(nil),           // 2: file.ViewFilesRequest
-	(*ViewFilesResponse)(nil),          // 3: file.ViewFilesResponse
-	(*DownloadFileRequest)(nil),        // 4: file.DownloadFileRequest
-	(*DownloadFileResponse)(nil),       // 5: file.DownloadFileResponse
-	(*ViewFilesResponse_FileInfo)(nil), // 6: file.ViewFilesResponse.FileInfo
-	(*timestamppb.Timestamp)(nil),      // 7: google.protobuf.Timestamp
+	(SortField)(0),                      // 0: file.SortField
+	(FitMode)(0),                        // 1: file.FitMode
+	(OutputFormat)(0),                   // 2: file.OutputFormat
+	(*UploadFileRequest)(nil),           // 3: file.UploadFileRequest
+	(*UploadFileStreamRequest)(nil),     // 4: file.UploadFileStreamRequest
+	(*UploadFileInfo)(nil),              // 5: file.UploadFileInfo
+	(*UploadFileResponse)(nil),          // 6: file.UploadFileResponse
+	(*CheckContentRequest)(nil),         // 7: file.CheckContentRequest
+	(*CheckContentResponse)(nil),        // 8: file.CheckContentResponse
+	(*UploadByReferenceRequest)(nil),    // 9: file.UploadByReferenceRequest
+	(*UploadByReferenceResponse)(nil),   // 10: file.UploadByReferenceResponse
+	(*ViewFilesRequest)(nil),            // 11: file.ViewFilesRequest
+	(*FileFilter)(nil),                  // 12: file.FileFilter
+	(*FileSort)(nil),                    // 13: file.FileSort
+	(*FileInfo)(nil),                    // 14: file.FileInfo
+	(*ImageInfo)(nil),                   // 15: file.ImageInfo
+	(*ViewFilesResponse)(nil),           // 16: file.ViewFilesResponse
+	(*DownloadFileRequest)(nil),         // 17: file.DownloadFileRequest
+	(*DownloadFileResponse)(nil),        // 18: file.DownloadFileResponse
+	(*DownloadFileStreamRequest)(nil),   // 19: file.DownloadFileStreamRequest
+	(*DownloadFileStreamResponse)(nil),  // 20: file.DownloadFileStreamResponse
+	(*GetFileMetadataRequest)(nil),      // 21: file.GetFileMetadataRequest
+	(*GetFileMetadataResponse)(nil),     // 22: file.GetFileMetadataResponse
+	(*GetThumbnailRequest)(nil),         // 23: file.GetThumbnailRequest
+	(*GetThumbnailResponse)(nil),        // 24: file.GetThumbnailResponse
+	(*CropRect)(nil),                    // 25: file.CropRect
+	(*DownloadTransformedRequest)(nil),  // 26: file.DownloadTransformedRequest
+	(*DownloadTransformedResponse)(nil), // 27: file.DownloadTransformedResponse
+	(*DeleteFileRequest)(nil),           // 28: file.DeleteFileRequest
+	(*DeleteFileResponse)(nil),          // 29: file.DeleteFileResponse
+	(*StartUploadRequest)(nil),          // 30: file.StartUploadRequest
+	(*StartUploadResponse)(nil),         // 31: file.StartUploadResponse
+	(*AppendChunkRequest)(nil),          // 32: file.AppendChunkRequest
+	(*AppendChunkResponse)(nil),         // 33: file.AppendChunkResponse
+	(*GetUploadStatusRequest)(nil),      // 34: file.GetUploadStatusRequest
+	(*GetUploadStatusResponse)(nil),     // 35: file.GetUploadStatusResponse
+	(*CommitUploadRequest)(nil),         // 36: file.CommitUploadRequest
+	(*CommitUploadResponse)(nil),        // 37: file.CommitUploadResponse
+	(*timestamppb.Timestamp)(nil),       // 38: google.protobuf.Timestamp
 }
 var file_api_file_proto_depIdxs = []int32{
-	6, // 0: file.ViewFilesResponse.files:type_name -> file.ViewFilesResponse.FileInfo
-	7, // 1: file.ViewFilesResponse.FileInfo.created_at:type_name -> google.protobuf.Timestamp
-	7, // 2: file.ViewFilesResponse.FileInfo.updated_at:type_name -> google.protobuf.Timestamp
-	0, // 3: file.FileService.UploadFile:input_type -> file.UploadFileRequest
-	2, // 4: file.FileService.ViewFiles:input_type -> file.ViewFilesRequest
-	4, // 5: file.FileService.DownloadFile:input_type -> file.DownloadFileRequest
-	1, // 6: file.FileService.UploadFile:output_type -> file.UploadFileResponse
-	3, // 7: file.FileService.ViewFiles:output_type -> file.ViewFilesResponse
-	5, // 8: file.FileService.DownloadFile:output_type -> file.DownloadFileResponse
-	6, // [6:9] is the sub-list for method output_type
-	3, // [3:6] is the sub-list for method input_type
-	3, // [3:3] is the sub-list for extension type_name
-	3, // [3:3] is the sub-list for extension extendee
-	0, // [0:3] is the sub-list for field type_name
+	5,  // 0: file.UploadFileStreamRequest.info:type_name -> file.UploadFileInfo
+	12, // 1: file.ViewFilesRequest.filter:type_name -> file.FileFilter
+	13, // 2: file.ViewFilesRequest.sort:type_name -> file.FileSort
+	38, // 3: file.FileFilter.created_from:type_name -> google.protobuf.Timestamp
+	38, // 4: file.FileFilter.created_to:type_name -> google.protobuf.Timestamp
+	38, // 5: file.FileFilter.updated_from:type_name -> google.protobuf.Timestamp
+	38, // 6: file.FileFilter.updated_to:type_name -> google.protobuf.Timestamp
+	0,  // 7: file.FileSort.field:type_name -> file.SortField
+	38, // 8: file.FileInfo.created_at:type_name -> google.protobuf.Timestamp
+	38, // 9: file.FileInfo.updated_at:type_name -> google.protobuf.Timestamp
+	15, // 10: file.FileInfo.image:type_name -> file.ImageInfo
+	38, // 11: file.ImageInfo.taken_at:type_name -> google.protobuf.Timestamp
+	14, // 12: file.ViewFilesResponse.files:type_name -> file.FileInfo
+	14, // 13: file.GetFileMetadataResponse.file:type_name -> file.FileInfo
+	25, // 14: file.DownloadTransformedRequest.crop:type_name -> file.CropRect
+	1,  // 15: file.DownloadTransformedRequest.fit:type_name -> file.FitMode
+	2,  // 16: file.DownloadTransformedRequest.format:type_name -> file.OutputFormat
+	38, // 17: file.StartUploadResponse.expires_at:type_name -> google.protobuf.Timestamp
+	38, // 18: file.AppendChunkResponse.expires_at:type_name -> google.protobuf.Timestamp
+	38, // 19: file.GetUploadStatusResponse.expires_at:type_name -> google.protobuf.Timestamp
+	3,  // 20: file.FileService.UploadFile:input_type -> file.UploadFileRequest
+	4,  // 21: file.FileService.UploadFileStream:input_type -> file.UploadFileStreamRequest
+	7,  // 22: file.FileService.CheckContent:input_type -> file.CheckContentRequest
+	9,  // 23: file.FileService.UploadByReference:input_type -> file.UploadByReferenceRequest
+	11, // 24: file.FileService.ViewFiles:input_type -> file.ViewFilesRequest
+	17, // 25: file.FileService.DownloadFile:input_type -> file.DownloadFileRequest
+	19, // 26: file.FileService.DownloadFileStream:input_type -> file.DownloadFileStreamRequest
+	28, // 27: file.FileService.DeleteFile:input_type -> file.DeleteFileRequest
+	21, // 28: file.FileService.GetFileMetadata:input_type -> file.GetFileMetadataRequest
+	23, // 29: file.FileService.GetThumbnail:input_type -> file.GetThumbnailRequest
+	26, // 30: file.FileService.DownloadTransformed:input_type -> file.DownloadTransformedRequest
+	30, // 31: file.FileService.StartUpload:input_type -> file.StartUploadRequest
+	32, // 32: file.FileService.AppendChunk:input_type -> file.AppendChunkRequest
+	34, // 33: file.FileService.GetUploadStatus:input_type -> file.GetUploadStatusRequest
+	36, // 34: file.FileService.CommitUpload:input_type -> file.CommitUploadRequest
+	6,  // 35: file.FileService.UploadFile:output_type -> file.UploadFileResponse
+	6,  // 36: file.FileService.UploadFileStream:output_type -> file.UploadFileResponse
+	8,  // 37: file.FileService.CheckContent:output_type -> file.CheckContentResponse
+	10, // 38: file.FileService.UploadByReference:output_type -> file.UploadByReferenceResponse
+	16, // 39: file.FileService.ViewFiles:output_type -> file.ViewFilesResponse
+	18, // 40: file.FileService.DownloadFile:output_type -> file.DownloadFileResponse
+	20, // 41: file.FileService.DownloadFileStream:output_type -> file.DownloadFileStreamResponse
+	29, // 42: file.FileService.DeleteFile:output_type -> file.DeleteFileResponse
+	22, // 43: file.FileService.GetFileMetadata:output_type -> file.GetFileMetadataResponse
+	24, // 44: file.FileService.GetThumbnail:output_type -> file.GetThumbnailResponse
+	27, // 45: file.FileService.DownloadTransformed:output_type -> file.DownloadTransformedResponse
+	31, // 46: file.FileService.StartUpload:output_type -> file.StartUploadResponse
+	33, // 47: file.FileService.AppendChunk:output_type -> file.AppendChunkResponse
+	35, // 48: file.FileService.GetUploadStatus:output_type -> file.GetUploadStatusResponse
+	37, // 49: file.FileService.CommitUpload:output_type -> file.CommitUploadResponse
+	35, // [35:50] is the sub-list for method output_type
+	20, // [20:35] is the sub-list for method input_type
+	20, // [20:20] is the sub-list for extension type_name
+	20, // [20:20] is the sub-list for extension extendee
+	0,  // [0:20] is the sub-list for field type_name
 }
 
 func init() { file_api_file_proto_init() }
@@ -447,18 +2709,28 @@ func file_api_file_proto_init() {
 	if File_api_file_proto != nil {
 		return
 	}
+	file_api_file_proto_msgTypes[0].OneofWrappers = []any{}
+	file_api_file_proto_msgTypes[1].OneofWrappers = []any{
+		(*UploadFileStreamRequest_Info)(nil),
+		(*UploadFileStreamRequest_Chunk)(nil),
+	}
+	file_api_file_proto_msgTypes[2].OneofWrappers = []any{}
+	file_api_file_proto_msgTypes[6].OneofWrappers = []any{}
+	file_api_file_proto_msgTypes[9].OneofWrappers = []any{}
+	file_api_file_proto_msgTypes[27].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_file_proto_rawDesc), len(file_api_file_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   7,
+			NumEnums:      3,
+			NumMessages:   35,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_api_file_proto_goTypes,
 		DependencyIndexes: file_api_file_proto_depIdxs,
+		EnumInfos:         file_api_file_proto_enumTypes,
 		MessageInfos:      file_api_file_proto_msgTypes,
 	}.Build()
 	File_api_file_proto = out.File
@@ -19,9 +19,21 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	FileService_UploadFile_FullMethodName   = "/file.FileService/UploadFile"
-	FileService_ViewFiles_FullMethodName    = "/file.FileService/ViewFiles"
-	FileService_DownloadFile_FullMethodName = "/file.FileService/DownloadFile"
+	FileService_UploadFile_FullMethodName          = "/file.FileService/UploadFile"
+	FileService_UploadFileStream_FullMethodName    = "/file.FileService/UploadFileStream"
+	FileService_CheckContent_FullMethodName        = "/file.FileService/CheckContent"
+	FileService_UploadByReference_FullMethodName   = "/file.FileService/UploadByReference"
+	FileService_ViewFiles_FullMethodName           = "/file.FileService/ViewFiles"
+	FileService_DownloadFile_FullMethodName        = "/file.FileService/DownloadFile"
+	FileService_DownloadFileStream_FullMethodName  = "/file.FileService/DownloadFileStream"
+	FileService_DeleteFile_FullMethodName          = "/file.FileService/DeleteFile"
+	FileService_GetFileMetadata_FullMethodName     = "/file.FileService/GetFileMetadata"
+	FileService_GetThumbnail_FullMethodName        = "/file.FileService/GetThumbnail"
+	FileService_DownloadTransformed_FullMethodName = "/file.FileService/DownloadTransformed"
+	FileService_StartUpload_FullMethodName         = "/file.FileService/StartUpload"
+	FileService_AppendChunk_FullMethodName         = "/file.FileService/AppendChunk"
+	FileService_GetUploadStatus_FullMethodName     = "/file.FileService/GetUploadStatus"
+	FileService_CommitUpload_FullMethodName        = "/file.FileService/CommitUpload"
 )
 
 // FileServiceClient is the client API for FileService service.
@@ -29,8 +41,20 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type FileServiceClient interface {
 	UploadFile(ctx context.Context, in *UploadFileRequest, opts ...grpc.CallOption) (*UploadFileResponse, error)
+	UploadFileStream(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[UploadFileStreamRequest, UploadFileResponse], error)
+	CheckContent(ctx context.Context, in *CheckContentRequest, opts ...grpc.CallOption) (*CheckContentResponse, error)
+	UploadByReference(ctx context.Context, in *UploadByReferenceRequest, opts ...grpc.CallOption) (*UploadByReferenceResponse, error)
 	ViewFiles(ctx context.Context, in *ViewFilesRequest, opts ...grpc.CallOption) (*ViewFilesResponse, error)
 	DownloadFile(ctx context.Context, in *DownloadFileRequest, opts ...grpc.CallOption) (*DownloadFileResponse, error)
+	DownloadFileStream(ctx context.Context, in *DownloadFileStreamRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DownloadFileStreamResponse], error)
+	DeleteFile(ctx context.Context, in *DeleteFileRequest, opts ...grpc.CallOption) (*DeleteFileResponse, error)
+	GetFileMetadata(ctx context.Context, in *GetFileMetadataRequest, opts ...grpc.CallOption) (*GetFileMetadataResponse, error)
+	GetThumbnail(ctx context.Context, in *GetThumbnailRequest, opts ...grpc.CallOption) (*GetThumbnailResponse, error)
+	DownloadTransformed(ctx context.Context, in *DownloadTransformedRequest, opts ...grpc.CallOption) (*DownloadTransformedResponse, error)
+	StartUpload(ctx context.Context, in *StartUploadRequest, opts ...grpc.CallOption) (*StartUploadResponse, error)
+	AppendChunk(ctx context.Context, in *AppendChunkRequest, opts ...grpc.CallOption) (*AppendChunkResponse, error)
+	GetUploadStatus(ctx context.Context, in *GetUploadStatusRequest, opts ...grpc.CallOption) (*GetUploadStatusResponse, error)
+	CommitUpload(ctx context.Context, in *CommitUploadRequest, opts ...grpc.CallOption) (*CommitUploadResponse, error)
 }
 
 type fileServiceClient struct {
@@ -51,6 +75,39 @@ func (c *fileServiceClient) UploadFile(ctx context.Context, in *UploadFileReques
 	return out, nil
 }
 
+func (c *fileServiceClient) UploadFileStream(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[UploadFileStreamRequest, UploadFileResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &FileService_ServiceDesc.Streams[0], FileService_UploadFileStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[UploadFileStreamRequest, UploadFileResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FileService_UploadFileStreamClient = grpc.ClientStreamingClient[UploadFileStreamRequest, UploadFileResponse]
+
+func (c *fileServiceClient) CheckContent(ctx context.Context, in *CheckContentRequest, opts ...grpc.CallOption) (*CheckContentResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CheckContentResponse)
+	err := c.cc.Invoke(ctx, FileService_CheckContent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileServiceClient) UploadByReference(ctx context.Context, in *UploadByReferenceRequest, opts ...grpc.CallOption) (*UploadByReferenceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UploadByReferenceResponse)
+	err := c.cc.Invoke(ctx, FileService_UploadByReference_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *fileServiceClient) ViewFiles(ctx context.Context, in *ViewFilesRequest, opts ...grpc.CallOption) (*ViewFilesResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ViewFilesResponse)
@@ -71,13 +128,124 @@ func (c *fileServiceClient) DownloadFile(ctx context.Context, in *DownloadFileRe
 	return out, nil
 }
 
+func (c *fileServiceClient) DownloadFileStream(ctx context.Context, in *DownloadFileStreamRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DownloadFileStreamResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &FileService_ServiceDesc.Streams[1], FileService_DownloadFileStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[DownloadFileStreamRequest, DownloadFileStreamResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FileService_DownloadFileStreamClient = grpc.ServerStreamingClient[DownloadFileStreamResponse]
+
+func (c *fileServiceClient) DeleteFile(ctx context.Context, in *DeleteFileRequest, opts ...grpc.CallOption) (*DeleteFileResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteFileResponse)
+	err := c.cc.Invoke(ctx, FileService_DeleteFile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileServiceClient) GetFileMetadata(ctx context.Context, in *GetFileMetadataRequest, opts ...grpc.CallOption) (*GetFileMetadataResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetFileMetadataResponse)
+	err := c.cc.Invoke(ctx, FileService_GetFileMetadata_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileServiceClient) GetThumbnail(ctx context.Context, in *GetThumbnailRequest, opts ...grpc.CallOption) (*GetThumbnailResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetThumbnailResponse)
+	err := c.cc.Invoke(ctx, FileService_GetThumbnail_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileServiceClient) DownloadTransformed(ctx context.Context, in *DownloadTransformedRequest, opts ...grpc.CallOption) (*DownloadTransformedResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DownloadTransformedResponse)
+	err := c.cc.Invoke(ctx, FileService_DownloadTransformed_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileServiceClient) StartUpload(ctx context.Context, in *StartUploadRequest, opts ...grpc.CallOption) (*StartUploadResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StartUploadResponse)
+	err := c.cc.Invoke(ctx, FileService_StartUpload_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileServiceClient) AppendChunk(ctx context.Context, in *AppendChunkRequest, opts ...grpc.CallOption) (*AppendChunkResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AppendChunkResponse)
+	err := c.cc.Invoke(ctx, FileService_AppendChunk_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileServiceClient) GetUploadStatus(ctx context.Context, in *GetUploadStatusRequest, opts ...grpc.CallOption) (*GetUploadStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetUploadStatusResponse)
+	err := c.cc.Invoke(ctx, FileService_GetUploadStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileServiceClient) CommitUpload(ctx context.Context, in *CommitUploadRequest, opts ...grpc.CallOption) (*CommitUploadResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CommitUploadResponse)
+	err := c.cc.Invoke(ctx, FileService_CommitUpload_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // FileServiceServer is the server API for FileService service.
 // All implementations must embed UnimplementedFileServiceServer
 // for forward compatibility.
 type FileServiceServer interface {
 	UploadFile(context.Context, *UploadFileRequest) (*UploadFileResponse, error)
+	UploadFileStream(grpc.ClientStreamingServer[UploadFileStreamRequest, UploadFileResponse]) error
+	CheckContent(context.Context, *CheckContentRequest) (*CheckContentResponse, error)
+	UploadByReference(context.Context, *UploadByReferenceRequest) (*UploadByReferenceResponse, error)
 	ViewFiles(context.Context, *ViewFilesRequest) (*ViewFilesResponse, error)
 	DownloadFile(context.Context, *DownloadFileRequest) (*DownloadFileResponse, error)
+	DownloadFileStream(*DownloadFileStreamRequest, grpc.ServerStreamingServer[DownloadFileStreamResponse]) error
+	DeleteFile(context.Context, *DeleteFileRequest) (*DeleteFileResponse, error)
+	GetFileMetadata(context.Context, *GetFileMetadataRequest) (*GetFileMetadataResponse, error)
+	GetThumbnail(context.Context, *GetThumbnailRequest) (*GetThumbnailResponse, error)
+	DownloadTransformed(context.Context, *DownloadTransformedRequest) (*DownloadTransformedResponse, error)
+	StartUpload(context.Context, *StartUploadRequest) (*StartUploadResponse, error)
+	AppendChunk(context.Context, *AppendChunkRequest) (*AppendChunkResponse, error)
+	GetUploadStatus(context.Context, *GetUploadStatusRequest) (*GetUploadStatusResponse, error)
+	CommitUpload(context.Context, *CommitUploadRequest) (*CommitUploadResponse, error)
 	mustEmbedUnimplementedFileServiceServer()
 }
 
@@ -91,12 +259,48 @@ type UnimplementedFileServiceServer struct{}
 func (UnimplementedFileServiceServer) UploadFile(context.Context, *UploadFileRequest) (*UploadFileResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UploadFile not implemented")
 }
+func (UnimplementedFileServiceServer) UploadFileStream(grpc.ClientStreamingServer[UploadFileStreamRequest, UploadFileResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method UploadFileStream not implemented")
+}
+func (UnimplementedFileServiceServer) CheckContent(context.Context, *CheckContentRequest) (*CheckContentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckContent not implemented")
+}
+func (UnimplementedFileServiceServer) UploadByReference(context.Context, *UploadByReferenceRequest) (*UploadByReferenceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UploadByReference not implemented")
+}
 func (UnimplementedFileServiceServer) ViewFiles(context.Context, *ViewFilesRequest) (*ViewFilesResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ViewFiles not implemented")
 }
 func (UnimplementedFileServiceServer) DownloadFile(context.Context, *DownloadFileRequest) (*DownloadFileResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DownloadFile not implemented")
 }
+func (UnimplementedFileServiceServer) DownloadFileStream(*DownloadFileStreamRequest, grpc.ServerStreamingServer[DownloadFileStreamResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method DownloadFileStream not implemented")
+}
+func (UnimplementedFileServiceServer) DeleteFile(context.Context, *DeleteFileRequest) (*DeleteFileResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteFile not implemented")
+}
+func (UnimplementedFileServiceServer) GetFileMetadata(context.Context, *GetFileMetadataRequest) (*GetFileMetadataResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFileMetadata not implemented")
+}
+func (UnimplementedFileServiceServer) GetThumbnail(context.Context, *GetThumbnailRequest) (*GetThumbnailResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetThumbnail not implemented")
+}
+func (UnimplementedFileServiceServer) DownloadTransformed(context.Context, *DownloadTransformedRequest) (*DownloadTransformedResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DownloadTransformed not implemented")
+}
+func (UnimplementedFileServiceServer) StartUpload(context.Context, *StartUploadRequest) (*StartUploadResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartUpload not implemented")
+}
+func (UnimplementedFileServiceServer) AppendChunk(context.Context, *AppendChunkRequest) (*AppendChunkResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AppendChunk not implemented")
+}
+func (UnimplementedFileServiceServer) GetUploadStatus(context.Context, *GetUploadStatusRequest) (*GetUploadStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUploadStatus not implemented")
+}
+func (UnimplementedFileServiceServer) CommitUpload(context.Context, *CommitUploadRequest) (*CommitUploadResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CommitUpload not implemented")
+}
 func (UnimplementedFileServiceServer) mustEmbedUnimplementedFileServiceServer() {}
 func (UnimplementedFileServiceServer) testEmbeddedByValue()                     {}
 
@@ -136,6 +340,49 @@ func _FileService_UploadFile_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _FileService_UploadFileStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(FileServiceServer).UploadFileStream(&grpc.GenericServerStream[UploadFileStreamRequest, UploadFileResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FileService_UploadFileStreamServer = grpc.ClientStreamingServer[UploadFileStreamRequest, UploadFileResponse]
+
+func _FileService_CheckContent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckContentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileServiceServer).CheckContent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileService_CheckContent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileServiceServer).CheckContent(ctx, req.(*CheckContentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileService_UploadByReference_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UploadByReferenceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileServiceServer).UploadByReference(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileService_UploadByReference_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileServiceServer).UploadByReference(ctx, req.(*UploadByReferenceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _FileService_ViewFiles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ViewFilesRequest)
 	if err := dec(in); err != nil {
@@ -172,6 +419,161 @@ func _FileService_DownloadFile_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _FileService_DownloadFileStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DownloadFileStreamRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FileServiceServer).DownloadFileStream(m, &grpc.GenericServerStream[DownloadFileStreamRequest, DownloadFileStreamResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FileService_DownloadFileStreamServer = grpc.ServerStreamingServer[DownloadFileStreamResponse]
+
+func _FileService_DeleteFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteFileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileServiceServer).DeleteFile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileService_DeleteFile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileServiceServer).DeleteFile(ctx, req.(*DeleteFileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileService_GetFileMetadata_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetFileMetadataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileServiceServer).GetFileMetadata(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileService_GetFileMetadata_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileServiceServer).GetFileMetadata(ctx, req.(*GetFileMetadataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileService_GetThumbnail_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetThumbnailRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileServiceServer).GetThumbnail(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileService_GetThumbnail_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileServiceServer).GetThumbnail(ctx, req.(*GetThumbnailRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileService_DownloadTransformed_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DownloadTransformedRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileServiceServer).DownloadTransformed(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileService_DownloadTransformed_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileServiceServer).DownloadTransformed(ctx, req.(*DownloadTransformedRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileService_StartUpload_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartUploadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileServiceServer).StartUpload(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileService_StartUpload_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileServiceServer).StartUpload(ctx, req.(*StartUploadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileService_AppendChunk_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AppendChunkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileServiceServer).AppendChunk(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileService_AppendChunk_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileServiceServer).AppendChunk(ctx, req.(*AppendChunkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileService_GetUploadStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUploadStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileServiceServer).GetUploadStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileService_GetUploadStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileServiceServer).GetUploadStatus(ctx, req.(*GetUploadStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileService_CommitUpload_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CommitUploadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileServiceServer).CommitUpload(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileService_CommitUpload_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileServiceServer).CommitUpload(ctx, req.(*CommitUploadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // FileService_ServiceDesc is the grpc.ServiceDesc for FileService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -183,6 +585,14 @@ var FileService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "UploadFile",
 			Handler:    _FileService_UploadFile_Handler,
 		},
+		{
+			MethodName: "CheckContent",
+			Handler:    _FileService_CheckContent_Handler,
+		},
+		{
+			MethodName: "UploadByReference",
+			Handler:    _FileService_UploadByReference_Handler,
+		},
 		{
 			MethodName: "ViewFiles",
 			Handler:    _FileService_ViewFiles_Handler,
@@ -191,7 +601,50 @@ var FileService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DownloadFile",
 			Handler:    _FileService_DownloadFile_Handler,
 		},
+		{
+			MethodName: "DeleteFile",
+			Handler:    _FileService_DeleteFile_Handler,
+		},
+		{
+			MethodName: "GetFileMetadata",
+			Handler:    _FileService_GetFileMetadata_Handler,
+		},
+		{
+			MethodName: "GetThumbnail",
+			Handler:    _FileService_GetThumbnail_Handler,
+		},
+		{
+			MethodName: "DownloadTransformed",
+			Handler:    _FileService_DownloadTransformed_Handler,
+		},
+		{
+			MethodName: "StartUpload",
+			Handler:    _FileService_StartUpload_Handler,
+		},
+		{
+			MethodName: "AppendChunk",
+			Handler:    _FileService_AppendChunk_Handler,
+		},
+		{
+			MethodName: "GetUploadStatus",
+			Handler:    _FileService_GetUploadStatus_Handler,
+		},
+		{
+			MethodName: "CommitUpload",
+			Handler:    _FileService_CommitUpload_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "UploadFileStream",
+			Handler:       _FileService_UploadFileStream_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "DownloadFileStream",
+			Handler:       _FileService_DownloadFileStream_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "api/file.proto",
 }
@@ -0,0 +1,211 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Config struct {
+	// Storage selects where file metadata is stored: "postgres", "sqlite"
+	// or "memory".
+	Storage         string
+	DatabaseURL     string
+	MigrationsPath  string
+	SQLitePath      string
+	FilesUploadPath string
+
+	// BlobStorage selects where file content is stored: "disk" or "s3".
+	BlobStorage string
+	S3          S3Config
+
+	// UploadAllowedTypes is the allowlist of uploaded content types, empty
+	// means the default list of image formats.
+	UploadAllowedTypes []string
+	// StripMetadata strips metadata from uploaded images unless an upload
+	// asks otherwise.
+	StripMetadata bool
+	// ReferenceSecret signs the challenges of uploads by reference, replicas
+	// of the service need the same one. Empty means a random secret.
+	ReferenceSecret string
+	// ThumbnailSizes are the longest sides of thumbnails of uploaded images,
+	// empty means the default sizes.
+	ThumbnailSizes []int
+	// ImageDecodes limits the images decoded at once for thumbnails and
+	// transformations, zero means the default limit.
+	ImageDecodes int
+
+	// GCInterval is the period of the garbage collector, zero disables it.
+	GCInterval    time.Duration
+	GCGracePeriod time.Duration
+	GCDryRun      bool
+
+	// RateLimitsPath is the YAML file with the limits of clients, empty means
+	// the built-in limits. The file is reloaded every RateLimitsReloadInterval,
+	// zero disables reloading.
+	RateLimitsPath           string
+	RateLimitsReloadInterval time.Duration
+	// RateLimitsShared counts concurrent requests in PostgreSQL for all
+	// replicas of the service.
+	RateLimitsShared bool
+}
+
+type S3Config struct {
+	Endpoint  string
+	Bucket    string
+	Region    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+	Prefix    string
+}
+
+// Load reads the configuration from the environment.
+func Load() (*Config, error) {
+	const op = "config.Load"
+
+	config := &Config{
+		Storage:            getString("STORAGE", "postgres"),
+		DatabaseURL:        os.Getenv("DATABASE_URL"),
+		MigrationsPath:     os.Getenv("MIGRATIONS_PATH"),
+		SQLitePath:         getString("SQLITE_PATH", "./file-service.db"),
+		FilesUploadPath:    getString("FILES_UPLOAD_PATH", "./uploads"),
+		BlobStorage:        getString("BLOB_STORAGE", "disk"),
+		RateLimitsPath:     os.Getenv("RATE_LIMITS_PATH"),
+		ReferenceSecret:    os.Getenv("REFERENCE_SECRET"),
+		UploadAllowedTypes: getList("UPLOAD_ALLOWED_TYPES"),
+		S3: S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			Region:    os.Getenv("S3_REGION"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			Prefix:    os.Getenv("S3_PREFIX"),
+		},
+	}
+
+	var err error
+	switch config.Storage {
+	case "postgres", "sqlite", "memory":
+	default:
+		return nil, fmt.Errorf("%s: unknown STORAGE %q", op, config.Storage)
+	}
+	switch config.BlobStorage {
+	case "disk":
+	case "s3":
+		if config.S3.Endpoint == "" || config.S3.Bucket == "" {
+			return nil, fmt.Errorf("%s: S3_ENDPOINT and S3_BUCKET are required for s3 blob storage", op)
+		}
+	default:
+		return nil, fmt.Errorf("%s: unknown BLOB_STORAGE %q", op, config.BlobStorage)
+	}
+	if config.S3.UseSSL, err = getBool("S3_USE_SSL", true); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if config.StripMetadata, err = getBool("STRIP_METADATA", false); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if config.ThumbnailSizes, err = getIntList("THUMBNAIL_SIZES"); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if config.ImageDecodes, err = getInt("IMAGE_DECODES", 0); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	// Metadata in memory is lost on restart, the garbage collector would
+	// then remove all content of the persistent blob storage.
+	gcInterval := time.Hour
+	if config.Storage == "memory" {
+		gcInterval = 0
+	}
+	if config.GCInterval, err = getDuration("GC_INTERVAL", gcInterval); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if config.GCInterval > 0 && config.Storage == "memory" {
+		return nil, fmt.Errorf("%s: GC_INTERVAL requires persistent STORAGE", op)
+	}
+	if config.GCGracePeriod, err = getDuration("GC_GRACE_PERIOD", 24*time.Hour); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if config.GCDryRun, err = getBool("GC_DRY_RUN", false); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if config.RateLimitsReloadInterval, err = getDuration("RATE_LIMITS_RELOAD_INTERVAL", 10*time.Second); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if config.RateLimitsShared, err = getBool("RATE_LIMITS_SHARED", false); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if config.RateLimitsShared && config.Storage != "postgres" {
+		return nil, fmt.Errorf("%s: RATE_LIMITS_SHARED requires postgres storage", op)
+	}
+
+	return config, nil
+}
+
+func getString(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func getDuration(key string, fallback time.Duration) (time.Duration, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return duration, nil
+}
+
+func getBool(key string, fallback bool) (bool, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return parsed, nil
+}
+
+func getInt(key string, fallback int) (int, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed <= 0 {
+		return 0, fmt.Errorf("invalid %s: %q is not a positive number", key, value)
+	}
+	return parsed, nil
+}
+
+// getList reads a comma separated list, skipping empty items.
+func getList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func getIntList(key string) ([]int, error) {
+	var list []int
+	for _, item := range getList(key) {
+		value, err := strconv.Atoi(item)
+		if err != nil || value <= 0 {
+			return nil, fmt.Errorf("invalid %s: %q is not a positive number", key, item)
+		}
+		list = append(list, value)
+	}
+	return list, nil
+}
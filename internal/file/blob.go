@@ -0,0 +1,77 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+var ErrBlobNotFound = fmt.Errorf("%w: content", ErrFileNotFound)
+
+type BlobInfo struct {
+	Hash    string // blob key, see DerivedKey
+	Size    int64
+	ModTime time.Time
+}
+
+// BlobStore stores file content addressed by its SHA-256 hash, and content
+// derived from it under keys returned by DerivedKey.
+type BlobStore interface {
+	// Create returns a writer for new content, whose hash is only known
+	// once all of it is written.
+	Create(ctx context.Context) (BlobWriter, error)
+	Put(ctx context.Context, hash string, content io.Reader) error
+	// Get returns a reader of the byte range [offset, offset + length) of
+	// the content, zero length means up to the end.
+	Get(ctx context.Context, hash string, offset, length int64) (io.ReadCloser, error)
+	Stat(ctx context.Context, hash string) (*BlobInfo, error)
+	// Delete removes the content, missing content is not an error.
+	Delete(ctx context.Context, hash string) error
+	// List calls fn for every stored content until fn returns an error.
+	List(ctx context.Context, fn func(BlobInfo) error) error
+}
+
+// BlobWriter spools new content until it is committed under its hash.
+type BlobWriter interface {
+	io.Writer
+	Commit(ctx context.Context, hash string) error
+	// Abort discards the content, it is a no-op after Commit.
+	Abort() error
+}
+
+// DerivedKey returns the blob key of content derived from the content of the
+// hash, e.g. its thumbnail. The variant is made of lowercase letters, digits
+// and dashes.
+func DerivedKey(hash string, variant string) string {
+	return hash + "_" + variant
+}
+
+// SourceHash returns the hash of the content the blob key is derived from,
+// which is the key itself for file content.
+func SourceHash(key string) string {
+	hash, _, _ := strings.Cut(key, "_")
+	return hash
+}
+
+// ValidateBlobKey checks that key is a content hash or a key derived from
+// one by DerivedKey.
+func ValidateBlobKey(key string) error {
+	hash, variant, derived := strings.Cut(key, "_")
+	if err := ValidateHash(hash); err != nil {
+		return err
+	}
+	if !derived {
+		return nil
+	}
+	if variant == "" {
+		return ErrHashInvalid
+	}
+	for _, c := range variant {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'z') && c != '-' {
+			return ErrHashInvalid
+		}
+	}
+	return nil
+}
@@ -5,6 +5,9 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"hash"
+	"hash/crc32"
+	"net/http"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,6 +20,12 @@ var (
 	ErrFileEmpty         = fmt.Errorf("%w: content is empty", ErrFile)
 	ErrFileNameEmpty     = fmt.Errorf("%w: name is empty", ErrFile)
 	ErrFileIdEmpty       = fmt.Errorf("%w: id is empty", ErrFile)
+	ErrFileTooLarge      = fmt.Errorf("%w: content is too large", ErrFile)
+	ErrInvalidRange      = fmt.Errorf("%w: invalid range", ErrFile)
+	ErrHashInvalid       = fmt.Errorf("%w: hash is not a hex encoded SHA-256", ErrFile)
+	ErrHashMismatch      = fmt.Errorf("%w: hash mismatch", ErrFile)
+	ErrCRC32CMismatch    = fmt.Errorf("%w: CRC32C mismatch", ErrFile)
+	ErrChallengeInvalid  = fmt.Errorf("%w: invalid or expired challenge", ErrFile)
 )
 
 type File struct {
@@ -35,34 +44,146 @@ func NewFile(data []byte, meta FileMeta) (*File, error) {
 }
 
 type FileMeta struct {
-	ID        uuid.UUID
-	Filename  string
-	Hash      string
+	ID       uuid.UUID
+	Filename string
+	Hash     string
+	Size     int64
+	// ContentType is the MIME type detected from the content.
+	ContentType string
+	// OriginalHash is the hash of the content as uploaded, set when metadata
+	// was stripped from the content and the original was kept.
+	OriginalHash string
+	// Image is nil unless the content is an image the service can read.
+	Image     *ImageMeta
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
 
-func NewFileMeta(fileId uuid.UUID, filename string, data []byte) (FileMeta, error) {
+// NewFileMeta creates metadata for content of the given size, SHA-256 hash
+// and content type, see Hasher.
+func NewFileMeta(fileId uuid.UUID, filename string, hash string, size int64, contentType string) (FileMeta, error) {
 	if filename == "" {
 		return FileMeta{}, ErrFileNameEmpty
 	}
-	if len(data) == 0 {
+	if size == 0 {
 		return FileMeta{}, ErrFileEmpty
 	}
 	return FileMeta{
-		ID:        fileId,
-		Filename:  filename,
-		Hash:      hashFile(data),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		ID:          fileId,
+		Filename:    filename,
+		Hash:        hash,
+		Size:        size,
+		ContentType: contentType,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
 	}, nil
 }
 
+// ContentHashes returns the hashes of all content referenced by the file.
+func (meta *FileMeta) ContentHashes() []string {
+	if meta.OriginalHash == "" {
+		return []string{meta.Hash}
+	}
+	return []string{meta.Hash, meta.OriginalHash}
+}
+
 func (file *File) Update() {
 	file.Meta.UpdatedAt = time.Now()
 }
 
-func hashFile(data []byte) string {
-	hash := sha256.Sum256(data)
-	return hex.EncodeToString(hash[:])
+// ValidateHash checks that hash is a lowercase hex encoded SHA-256 as
+// produced by Hasher.
+func ValidateHash(hash string) error {
+	if len(hash) != sha256.Size*2 {
+		return ErrHashInvalid
+	}
+	for _, c := range hash {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return ErrHashInvalid
+		}
+	}
+	return nil
+}
+
+// SNIFF_SIZE is the number of leading bytes the content type is detected from.
+const SNIFF_SIZE = 512
+
+// DetectContentType returns the MIME type of content starting with head.
+func DetectContentType(head []byte) string {
+	return http.DetectContentType(head)
+}
+
+// Checksums are checksums of file content, a client sends them with an
+// upload to have the content verified.
+type Checksums struct {
+	// SHA256 is the hex encoded SHA-256, empty when unknown.
+	SHA256 string
+	// CRC32C is the CRC-32 with the Castagnoli polynomial, nil when unknown.
+	CRC32C *uint32
+}
+
+// Validate checks the format of the checksums before any content is read.
+func (c Checksums) Validate() error {
+	if c.SHA256 == "" {
+		return nil
+	}
+	return ValidateHash(c.SHA256)
+}
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Hasher computes the hash, size and content type of file content written to
+// it incrementally, so the content never has to be held in memory at once.
+type Hasher struct {
+	hash   hash.Hash
+	crc32c hash.Hash32
+	size   int64
+	head   []byte
+}
+
+func NewHasher() *Hasher {
+	return &Hasher{hash: sha256.New(), crc32c: crc32.New(crc32cTable)}
+}
+
+func (h *Hasher) Write(p []byte) (int, error) {
+	if len(h.head) < SNIFF_SIZE {
+		h.head = append(h.head, p[:min(len(p), SNIFF_SIZE-len(h.head))]...)
+	}
+	h.crc32c.Write(p)
+	n, err := h.hash.Write(p)
+	h.size += int64(n)
+	return n, err
+}
+
+// Checksums returns the checksums of the content written so far.
+func (h *Hasher) Checksums() Checksums {
+	crc := h.crc32c.Sum32()
+	return Checksums{SHA256: h.Hash(), CRC32C: &crc}
+}
+
+// Verify compares the content written so far to the expected checksums,
+// unknown ones are not compared.
+func (h *Hasher) Verify(expected Checksums) error {
+	if hash := h.Hash(); expected.SHA256 != "" && expected.SHA256 != hash {
+		return fmt.Errorf("%w: expected %s, computed %s", ErrHashMismatch, expected.SHA256, hash)
+	}
+	if crc := h.crc32c.Sum32(); expected.CRC32C != nil && *expected.CRC32C != crc {
+		return fmt.Errorf("%w: expected %08x, computed %08x", ErrCRC32CMismatch, *expected.CRC32C, crc)
+	}
+	return nil
+}
+
+// Hash returns the hex encoded SHA-256 of the content written so far.
+func (h *Hasher) Hash() string {
+	return hex.EncodeToString(h.hash.Sum(nil))
+}
+
+// Size returns the number of bytes written so far.
+func (h *Hasher) Size() int64 {
+	return h.size
+}
+
+// Head returns up to SNIFF_SIZE leading bytes of the content.
+func (h *Hasher) Head() []byte {
+	return h.head
 }
@@ -0,0 +1,579 @@
+// Package filetest provides a conformance test suite for implementations of
+// the file package interfaces.
+package filetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	tx "github.com/avito-tech/go-transaction-manager/trm/v2/manager"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+
+	"file-service/internal/file"
+)
+
+// RepositoryFactory returns an empty repository and a transaction manager
+// for it.
+type RepositoryFactory func(t *testing.T) (file.FileMetaRepository, *tx.Manager)
+
+// SuiteOptions describe the behaviour of an implementation allowed to differ
+// from the reference ones.
+type SuiteOptions struct {
+	// SerialTransactions means that transactions exclude each other, e.g. by
+	// the write lock of the database, so locks of other hashes wait too.
+	SerialTransactions bool
+}
+
+// RunRepositorySuite checks that a file.FileMetaRepository implementation
+// behaves like the reference ones.
+func RunRepositorySuite(t *testing.T, newRepository RepositoryFactory, options SuiteOptions) {
+	t.Run("Save and find by id", func(t *testing.T) {
+		repository, _ := newRepository(t)
+		ctx := context.Background()
+
+		meta := newMeta("image.jpg", "hash", time.Now())
+		require.NoError(t, repository.Save(ctx, meta))
+
+		found, err := repository.FindById(ctx, meta.ID)
+		require.NoError(t, err)
+		requireEqualMeta(t, meta, found)
+	})
+
+	t.Run("Image metadata", func(t *testing.T) {
+		repository, _ := newRepository(t)
+		ctx := context.Background()
+
+		photo := newMeta("photo.jpg", "hash", time.Now())
+		photo.ContentType = "image/jpeg"
+		photo.Image = &file.ImageMeta{
+			Width:       4000,
+			Height:      3000,
+			ColorModel:  "ycbcr",
+			Format:      "jpeg",
+			TakenAt:     time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC),
+			CameraModel: "Camera",
+			Orientation: 6,
+			HasGPS:      true,
+		}
+		drawing := newMeta("drawing.png", "other", time.Now())
+		drawing.ContentType = "image/png"
+		drawing.Image = &file.ImageMeta{Width: 10, Height: 20, ColorModel: "nrgba", Format: "png"}
+		document := newMeta("document.pdf", "document", time.Now())
+		for _, meta := range []*file.FileMeta{photo, drawing, document} {
+			require.NoError(t, repository.Save(ctx, meta))
+		}
+
+		for _, meta := range []*file.FileMeta{photo, drawing, document} {
+			found, err := repository.FindById(ctx, meta.ID)
+			require.NoError(t, err)
+			requireEqualMeta(t, meta, found)
+		}
+
+		all, err := repository.FindAll(ctx, file.Filter{}, file.Sort{Field: file.SORT_BY_NAME}, file.NewPage(1, 10))
+		require.NoError(t, err)
+		require.Len(t, all, 3)
+		requireEqualMeta(t, photo, all[2])
+
+		incomplete, err := repository.FindIncomplete(ctx, uuid.Nil, 10)
+		require.NoError(t, err)
+		require.Empty(t, incomplete)
+	})
+
+	t.Run("Save upserts by id", func(t *testing.T) {
+		repository, _ := newRepository(t)
+		ctx := context.Background()
+
+		meta := newMeta("image.jpg", "hash", time.Now())
+		require.NoError(t, repository.Save(ctx, meta))
+
+		updated := *meta
+		updated.Filename = "renamed.jpg"
+		updated.UpdatedAt = meta.UpdatedAt.Add(time.Minute)
+		require.NoError(t, repository.Save(ctx, &updated))
+
+		found, err := repository.FindById(ctx, meta.ID)
+		require.NoError(t, err)
+		requireEqualMeta(t, &updated, found)
+
+		all, err := repository.FindAll(ctx, file.Filter{}, file.DefaultSort, file.NewPage(1, 10))
+		require.NoError(t, err)
+		require.Len(t, all, 1)
+	})
+
+	t.Run("Find unknown id", func(t *testing.T) {
+		repository, _ := newRepository(t)
+
+		_, err := repository.FindById(context.Background(), uuid.New())
+		require.ErrorIs(t, err, file.ErrFileNotFound)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repository, _ := newRepository(t)
+		ctx := context.Background()
+
+		meta := newMeta("image.jpg", "hash", time.Now())
+		require.NoError(t, repository.Save(ctx, meta))
+		require.NoError(t, repository.Delete(ctx, meta.ID))
+
+		_, err := repository.FindById(ctx, meta.ID)
+		require.ErrorIs(t, err, file.ErrFileNotFound)
+
+		err = repository.Delete(ctx, meta.ID)
+		require.ErrorIs(t, err, file.ErrFileNotFound)
+	})
+
+	t.Run("Find all orders by update then creation time", func(t *testing.T) {
+		repository, _ := newRepository(t)
+		ctx := context.Background()
+
+		now := time.Now()
+		oldest := newMeta("oldest", "a", now.Add(-3*time.Hour))
+		updated := newMeta("updated", "b", now.Add(-2*time.Hour))
+		updated.UpdatedAt = now
+		older := newMeta("older", "c", now.Add(-time.Hour))
+		newer := newMeta("newer", "d", now.Add(-30*time.Minute))
+		newer.UpdatedAt = older.UpdatedAt
+		for _, meta := range []*file.FileMeta{older, oldest, updated, newer} {
+			require.NoError(t, repository.Save(ctx, meta))
+		}
+
+		all, err := repository.FindAll(ctx, file.Filter{}, file.DefaultSort, file.NewPage(1, 10))
+		require.NoError(t, err)
+		require.Equal(t, []string{"updated", "newer", "older", "oldest"}, filenames(all))
+	})
+
+	t.Run("Find all pages", func(t *testing.T) {
+		repository, _ := newRepository(t)
+		ctx := context.Background()
+
+		now := time.Now()
+		var expected []string
+		for i := range 25 {
+			meta := newMeta(fmt.Sprintf("file-%02d", i), "hash", now.Add(-time.Duration(i)*time.Minute))
+			require.NoError(t, repository.Save(ctx, meta))
+			expected = append(expected, meta.Filename)
+		}
+
+		var paged []string
+		for number := 1; number <= 3; number++ {
+			page, err := repository.FindAll(ctx, file.Filter{}, file.DefaultSort, file.NewPage(number, 10))
+			require.NoError(t, err)
+			paged = append(paged, filenames(page)...)
+		}
+		require.Equal(t, expected, paged)
+
+		last, err := repository.FindAll(ctx, file.Filter{}, file.DefaultSort, file.NewPage(3, 10))
+		require.NoError(t, err)
+		require.Len(t, last, 5)
+
+		beyond, err := repository.FindAll(ctx, file.Filter{}, file.DefaultSort, file.NewPage(4, 10))
+		require.NoError(t, err)
+		require.NotNil(t, beyond)
+		require.Empty(t, beyond)
+
+		whole, err := repository.FindAll(ctx, file.Filter{}, file.DefaultSort, file.NewPage(1, 100))
+		require.NoError(t, err)
+		require.Len(t, whole, 25)
+
+		// Invalid pages fall back to the first page of the default size.
+		fallback, err := repository.FindAll(ctx, file.Filter{}, file.DefaultSort, file.NewPage(0, 0))
+		require.NoError(t, err)
+		require.Equal(t, expected[:10], filenames(fallback))
+	})
+
+	t.Run("Find all after cursor", func(t *testing.T) {
+		repository, _ := newRepository(t)
+		ctx := context.Background()
+
+		now := time.Now()
+		var metas []*file.FileMeta
+		for i := range 12 {
+			// Pairs of files share timestamps, so the id breaks ties.
+			meta := newMeta(fmt.Sprintf("file-%02d", i), "hash", now.Add(-time.Duration(i/2)*time.Minute))
+			require.NoError(t, repository.Save(ctx, meta))
+			metas = append(metas, meta)
+		}
+		sort.SliceStable(metas, func(i, j int) bool {
+			if !metas[i].UpdatedAt.Equal(metas[j].UpdatedAt) {
+				return metas[i].UpdatedAt.After(metas[j].UpdatedAt)
+			}
+			return metas[i].ID.String() > metas[j].ID.String()
+		})
+
+		first, err := repository.FindAll(ctx, file.Filter{}, file.DefaultSort, file.NewPage(1, 5))
+		require.NoError(t, err)
+		require.Equal(t, filenames(metas[:5]), filenames(first))
+
+		// Moving an already listed file to the top doesn't shift the next pages.
+		moved := *metas[0]
+		moved.UpdatedAt = now.Add(time.Hour).UTC().Truncate(time.Microsecond)
+		require.NoError(t, repository.Save(ctx, &moved))
+
+		page := file.NewPage(1, 5)
+		page.After = file.CursorOf(first[len(first)-1], file.DefaultSort)
+		second, err := repository.FindAll(ctx, file.Filter{}, file.DefaultSort, page)
+		require.NoError(t, err)
+		require.Equal(t, filenames(metas[5:10]), filenames(second))
+
+		page.After = file.CursorOf(second[len(second)-1], file.DefaultSort)
+		last, err := repository.FindAll(ctx, file.Filter{}, file.DefaultSort, page)
+		require.NoError(t, err)
+		require.Equal(t, filenames(metas[10:]), filenames(last))
+
+		page.After = file.CursorOf(last[len(last)-1], file.DefaultSort)
+		beyond, err := repository.FindAll(ctx, file.Filter{}, file.DefaultSort, page)
+		require.NoError(t, err)
+		require.NotNil(t, beyond)
+		require.Empty(t, beyond)
+	})
+
+	t.Run("Find all filtered", func(t *testing.T) {
+		repository, _ := newRepository(t)
+		ctx := context.Background()
+
+		now := time.Now().UTC().Truncate(time.Microsecond)
+		files := []struct {
+			name      string
+			size      int64
+			createdAt time.Time
+			updatedAt time.Time
+		}{
+			{"report.pdf", 100, now.Add(-3 * time.Hour), now.Add(-3 * time.Hour)},
+			{"report_2024.pdf", 200, now.Add(-2 * time.Hour), now},
+			{"photo.jpg", 300, now.Add(-time.Hour), now.Add(-time.Hour)},
+			{"Report.txt", 400, now, now},
+			{"100%_done.txt", 500, now, now},
+		}
+		for _, f := range files {
+			meta := newMeta(f.name, "hash", f.createdAt)
+			meta.Size = f.size
+			meta.UpdatedAt = f.updatedAt
+			require.NoError(t, repository.Save(ctx, meta))
+		}
+
+		size := func(size int64) *int64 { return &size }
+		tests := []struct {
+			name     string
+			filter   file.Filter
+			expected []string
+		}{
+			{"name prefix", file.Filter{NamePrefix: "report"}, []string{"report.pdf", "report_2024.pdf"}},
+			{"name prefix is case sensitive", file.Filter{NamePrefix: "Report"}, []string{"Report.txt"}},
+			{"name prefix with wildcards", file.Filter{NamePrefix: "report_"}, []string{"report_2024.pdf"}},
+			{"name prefix with percent", file.Filter{NamePrefix: "100%"}, []string{"100%_done.txt"}},
+			{"name contains", file.Filter{NameContains: ".pdf"}, []string{"report.pdf", "report_2024.pdf"}},
+			{"name contains with wildcards", file.Filter{NameContains: "%_"}, []string{"100%_done.txt"}},
+			{"created from", file.Filter{CreatedFrom: now.Add(-time.Hour)}, []string{"100%_done.txt", "Report.txt", "photo.jpg"}},
+			{"created to", file.Filter{CreatedTo: now.Add(-time.Hour)}, []string{"report.pdf", "report_2024.pdf"}},
+			{"updated range", file.Filter{UpdatedFrom: now.Add(-3 * time.Hour), UpdatedTo: now}, []string{"photo.jpg", "report.pdf"}},
+			{"min size", file.Filter{MinSize: size(400)}, []string{"100%_done.txt", "Report.txt"}},
+			{"size range", file.Filter{MinSize: size(200), MaxSize: size(300)}, []string{"photo.jpg", "report_2024.pdf"}},
+			{"combined", file.Filter{NamePrefix: "report", MaxSize: size(100)}, []string{"report.pdf"}},
+			{"nothing matches", file.Filter{NameContains: "missing"}, []string{}},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				byName := file.Sort{Field: file.SORT_BY_NAME, Desc: false}
+				found, err := repository.FindAll(ctx, test.filter, byName, file.NewPage(1, 10))
+				require.NoError(t, err)
+				require.Equal(t, test.expected, filenames(found))
+			})
+		}
+	})
+
+	t.Run("Find all sorted", func(t *testing.T) {
+		repository, _ := newRepository(t)
+		ctx := context.Background()
+
+		now := time.Now()
+		var metas []*file.FileMeta
+		for i := range 9 {
+			meta := newMeta(fmt.Sprintf("file-%d", (i*5)%9), "hash", now.Add(-time.Duration(i%4)*time.Minute))
+			meta.Size = int64(i % 3)
+			meta.UpdatedAt = meta.CreatedAt.Add(time.Duration(i%2) * time.Hour)
+			require.NoError(t, repository.Save(ctx, meta))
+			metas = append(metas, meta)
+		}
+
+		for _, field := range []file.SortField{file.SORT_BY_UPDATED_AT, file.SORT_BY_CREATED_AT, file.SORT_BY_NAME, file.SORT_BY_SIZE} {
+			for _, desc := range []bool{false, true} {
+				order := file.Sort{Field: field, Desc: desc}
+				t.Run(fmt.Sprintf("%s desc %t", field, desc), func(t *testing.T) {
+					expected := slices.Clone(metas)
+					slices.SortFunc(expected, order.Compare)
+
+					whole, err := repository.FindAll(ctx, file.Filter{}, order, file.NewPage(1, 10))
+					require.NoError(t, err)
+					require.Equal(t, ids(expected), ids(whole))
+
+					// Cursor pages follow the same order.
+					var paged []*file.FileMeta
+					page := file.NewPage(1, 4)
+					for {
+						found, err := repository.FindAll(ctx, file.Filter{}, order, page)
+						require.NoError(t, err)
+						if len(found) == 0 {
+							break
+						}
+						paged = append(paged, found...)
+						page.After = file.CursorOf(found[len(found)-1], order)
+					}
+					require.Equal(t, ids(expected), ids(paged))
+				})
+			}
+		}
+	})
+
+	t.Run("Find all of empty repository", func(t *testing.T) {
+		repository, _ := newRepository(t)
+
+		all, err := repository.FindAll(context.Background(), file.Filter{}, file.DefaultSort, file.NewPage(1, 10))
+		require.NoError(t, err)
+		require.NotNil(t, all)
+		require.Empty(t, all)
+	})
+
+	t.Run("Hashes", func(t *testing.T) {
+		repository, _ := newRepository(t)
+		ctx := context.Background()
+
+		for _, hash := range []string{"a", "a", "b"} {
+			require.NoError(t, repository.Save(ctx, newMeta("file", hash, time.Now())))
+		}
+
+		count, err := repository.CountByHash(ctx, "a")
+		require.NoError(t, err)
+		require.Equal(t, 2, count)
+
+		count, err = repository.CountByHash(ctx, "missing")
+		require.NoError(t, err)
+		require.Zero(t, count)
+
+		hashes, err := repository.FindAllHashes(ctx)
+		require.NoError(t, err)
+		sort.Strings(hashes)
+		require.Equal(t, []string{"a", "b"}, hashes)
+	})
+
+	t.Run("Original hashes", func(t *testing.T) {
+		repository, _ := newRepository(t)
+		ctx := context.Background()
+
+		stripped := newMeta("stripped", "a", time.Now())
+		stripped.OriginalHash = "b"
+		require.NoError(t, repository.Save(ctx, stripped))
+
+		count, err := repository.CountByContentHash(ctx, "b")
+		require.NoError(t, err)
+		require.Zero(t, count)
+
+		require.NoError(t, repository.Save(ctx, newMeta("original", "b", time.Now())))
+		require.NoError(t, repository.Save(ctx, newMeta("other", "c", time.Now())))
+
+		found, err := repository.FindById(ctx, stripped.ID)
+		require.NoError(t, err)
+		requireEqualMeta(t, stripped, found)
+
+		count, err = repository.CountByHash(ctx, "b")
+		require.NoError(t, err)
+		require.Equal(t, 2, count)
+		count, err = repository.CountByContentHash(ctx, "b")
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+
+		hashes, err := repository.FindAllHashes(ctx)
+		require.NoError(t, err)
+		sort.Strings(hashes)
+		require.Equal(t, []string{"a", "b", "c"}, hashes)
+	})
+
+	t.Run("Find incomplete", func(t *testing.T) {
+		repository, _ := newRepository(t)
+		ctx := context.Background()
+
+		require.NoError(t, repository.Save(ctx, newMeta("complete", "a", time.Now())))
+		var incomplete []string
+		for i := range 3 {
+			meta := newMeta(fmt.Sprintf("incomplete-%d", i), "b", time.Now())
+			meta.Size = 0
+			meta.ContentType = ""
+			require.NoError(t, repository.Save(ctx, meta))
+			incomplete = append(incomplete, meta.Filename)
+		}
+
+		found, err := repository.FindIncomplete(ctx, uuid.Nil, 10)
+		require.NoError(t, err)
+		require.ElementsMatch(t, incomplete, filenames(found))
+
+		first, err := repository.FindIncomplete(ctx, uuid.Nil, 2)
+		require.NoError(t, err)
+		require.Len(t, first, 2)
+		rest, err := repository.FindIncomplete(ctx, first[1].ID, 2)
+		require.NoError(t, err)
+		require.Len(t, rest, 1)
+		require.ElementsMatch(t, incomplete, filenames(append(first, rest...)))
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		repository, manager := newRepository(t)
+		ctx := context.Background()
+
+		kept := newMeta("kept", "hash", time.Now())
+		require.NoError(t, repository.Save(ctx, kept))
+
+		added := newMeta("added", "hash", time.Now())
+		errRollback := errors.New("rollback")
+		err := manager.Do(ctx, func(ctx context.Context) error {
+			require.NoError(t, repository.Save(ctx, added))
+			require.NoError(t, repository.Delete(ctx, kept.ID))
+			return errRollback
+		})
+		require.ErrorIs(t, err, errRollback)
+
+		_, err = repository.FindById(ctx, added.ID)
+		require.ErrorIs(t, err, file.ErrFileNotFound)
+		_, err = repository.FindById(ctx, kept.ID)
+		require.NoError(t, err)
+	})
+
+	t.Run("Lock hash", func(t *testing.T) {
+		repository, manager := newRepository(t)
+		ctx := context.Background()
+
+		locked := make(chan struct{})
+		release := make(chan struct{})
+		done := make(chan error)
+		go func() {
+			done <- manager.Do(ctx, func(ctx context.Context) error {
+				if err := repository.LockHash(ctx, "hash"); err != nil {
+					return err
+				}
+				close(locked)
+				<-release
+				return nil
+			})
+		}()
+		<-locked
+
+		order := make(chan string, 2)
+		var second errgroup.Group
+		second.Go(func() error {
+			return manager.Do(ctx, func(ctx context.Context) error {
+				if err := repository.LockHash(ctx, "hash"); err != nil {
+					return err
+				}
+				order <- "second"
+				return nil
+			})
+		})
+
+		if !options.SerialTransactions {
+			// Other hashes are not blocked.
+			err := manager.Do(ctx, func(ctx context.Context) error {
+				return repository.LockHash(ctx, "other")
+			})
+			require.NoError(t, err)
+		}
+
+		require.Never(t, func() bool { return len(order) > 0 }, 100*time.Millisecond, 10*time.Millisecond)
+		order <- "first"
+		close(release)
+		require.NoError(t, <-done)
+		require.NoError(t, second.Wait())
+
+		require.Equal(t, "first", <-order)
+		require.Equal(t, "second", <-order)
+	})
+
+	t.Run("Concurrent writers", func(t *testing.T) {
+		repository, manager := newRepository(t)
+		ctx := context.Background()
+
+		shared := newMeta("shared", "hash", time.Now())
+		require.NoError(t, repository.Save(ctx, shared))
+
+		var writers errgroup.Group
+		for i := range 20 {
+			writers.Go(func() error {
+				return manager.Do(ctx, func(ctx context.Context) error {
+					return repository.Save(ctx, newMeta(fmt.Sprintf("file-%d", i), "hash", time.Now()))
+				})
+			})
+			writers.Go(func() error {
+				update := *shared
+				update.Filename = fmt.Sprintf("shared-%d", i)
+				return repository.Save(ctx, &update)
+			})
+		}
+		require.NoError(t, writers.Wait())
+
+		count, err := repository.CountByHash(ctx, "hash")
+		require.NoError(t, err)
+		require.Equal(t, 21, count)
+
+		found, err := repository.FindById(ctx, shared.ID)
+		require.NoError(t, err)
+		require.Regexp(t, `^shared-\d+$`, found.Filename)
+	})
+}
+
+// newMeta returns metadata with timestamps at the precision every
+// implementation can store.
+func newMeta(filename string, hash string, createdAt time.Time) *file.FileMeta {
+	createdAt = createdAt.UTC().Truncate(time.Microsecond)
+	return &file.FileMeta{
+		ID:          uuid.New(),
+		Filename:    filename,
+		Hash:        hash,
+		Size:        1,
+		ContentType: "application/octet-stream",
+		CreatedAt:   createdAt,
+		UpdatedAt:   createdAt,
+	}
+}
+
+func requireEqualMeta(t *testing.T, expected, actual *file.FileMeta) {
+	t.Helper()
+
+	require.Equal(t, expected.ID, actual.ID)
+	require.Equal(t, expected.Filename, actual.Filename)
+	require.Equal(t, expected.Hash, actual.Hash)
+	require.Equal(t, expected.OriginalHash, actual.OriginalHash)
+	require.Equal(t, expected.Size, actual.Size)
+	require.Equal(t, expected.ContentType, actual.ContentType)
+	require.True(t, expected.CreatedAt.Equal(actual.CreatedAt), "created at %v, want %v", actual.CreatedAt, expected.CreatedAt)
+	require.True(t, expected.UpdatedAt.Equal(actual.UpdatedAt), "updated at %v, want %v", actual.UpdatedAt, expected.UpdatedAt)
+
+	if expected.Image == nil {
+		require.Nil(t, actual.Image)
+		return
+	}
+	require.NotNil(t, actual.Image)
+	expectedImage, actualImage := *expected.Image, *actual.Image
+	require.True(t, expectedImage.TakenAt.Equal(actualImage.TakenAt), "taken at %v, want %v", actualImage.TakenAt, expectedImage.TakenAt)
+	expectedImage.TakenAt, actualImage.TakenAt = time.Time{}, time.Time{}
+	require.Equal(t, expectedImage, actualImage)
+}
+
+func ids(metas []*file.FileMeta) []uuid.UUID {
+	result := make([]uuid.UUID, len(metas))
+	for i, meta := range metas {
+		result[i] = meta.ID
+	}
+	return result
+}
+
+func filenames(metas []*file.FileMeta) []string {
+	names := make([]string, len(metas))
+	for i, meta := range metas {
+		names[i] = meta.Filename
+	}
+	return names
+}
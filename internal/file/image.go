@@ -0,0 +1,34 @@
+package file
+
+import (
+	"fmt"
+	"time"
+)
+
+var (
+	ErrImageUnsupported     = fmt.Errorf("%w: not a supported image", ErrFile)
+	ErrStripUnsupported     = fmt.Errorf("%w: metadata can't be stripped from the content type", ErrFile)
+	ErrThumbnailSizeInvalid = fmt.Errorf("%w: thumbnail size is not available", ErrFile)
+)
+
+// ImageMeta describes the content of an image file.
+type ImageMeta struct {
+	// Width and Height are the stored dimensions in pixels, images with
+	// Orientation 5 to 8 are displayed rotated by 90 degrees.
+	Width  int
+	Height int
+	// ColorModel is the color model of decoded pixels, e.g. "ycbcr", "rgba"
+	// or "paletted".
+	ColorModel string
+	// Format is the image format, e.g. "jpeg" or "png".
+	Format string
+
+	// TakenAt is the capture time from EXIF, zero when unknown. Capture times
+	// without a time zone are taken as UTC.
+	TakenAt     time.Time
+	CameraModel string
+	// Orientation is the EXIF orientation from 1 to 8, zero when unknown.
+	Orientation int
+	// HasGPS reports whether EXIF holds the location of the capture.
+	HasGPS bool
+}
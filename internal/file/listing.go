@@ -0,0 +1,113 @@
+package file
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var ErrSortInvalid = fmt.Errorf("%w: invalid sort", ErrFile)
+
+// Filter narrows the file listing, zero fields don't filter.
+type Filter struct {
+	NamePrefix   string
+	NameContains string
+	// Time ranges include the start and exclude the end.
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	UpdatedFrom time.Time
+	UpdatedTo   time.Time
+	// Size range includes both ends.
+	MinSize *int64
+	MaxSize *int64
+}
+
+// Match reports whether the file meta passes the filter.
+func (f Filter) Match(meta *FileMeta) bool {
+	if !strings.HasPrefix(meta.Filename, f.NamePrefix) || !strings.Contains(meta.Filename, f.NameContains) {
+		return false
+	}
+	if !inRange(meta.CreatedAt, f.CreatedFrom, f.CreatedTo) || !inRange(meta.UpdatedAt, f.UpdatedFrom, f.UpdatedTo) {
+		return false
+	}
+	if f.MinSize != nil && meta.Size < *f.MinSize {
+		return false
+	}
+	if f.MaxSize != nil && meta.Size > *f.MaxSize {
+		return false
+	}
+	return true
+}
+
+func inRange(t, from, to time.Time) bool {
+	return (from.IsZero() || !t.Before(from)) && (to.IsZero() || t.Before(to))
+}
+
+type SortField string
+
+const (
+	SORT_BY_UPDATED_AT SortField = "updated_at"
+	SORT_BY_CREATED_AT SortField = "created_at"
+	SORT_BY_NAME       SortField = "name"
+	SORT_BY_SIZE       SortField = "size"
+)
+
+// Sort orders the file listing by the field, then by id in the same
+// direction, so the order is total.
+type Sort struct {
+	Field SortField
+	Desc  bool
+}
+
+// DefaultSort lists the most recently updated files first.
+var DefaultSort = Sort{Field: SORT_BY_UPDATED_AT, Desc: true}
+
+func NewSort(field SortField, desc bool) (Sort, error) {
+	switch field {
+	case SORT_BY_UPDATED_AT, SORT_BY_CREATED_AT, SORT_BY_NAME, SORT_BY_SIZE:
+	default:
+		return Sort{}, ErrSortInvalid
+	}
+	return Sort{Field: field, Desc: desc}, nil
+}
+
+// Compare returns a negative number when a comes before b in the listing,
+// a positive one when after and zero for the same file.
+func (s Sort) Compare(a, b *FileMeta) int {
+	result := s.compare(a, b)
+	if result == 0 {
+		result = strings.Compare(a.ID.String(), b.ID.String())
+	}
+	if s.Desc {
+		return -result
+	}
+	return result
+}
+
+func (s Sort) compare(a, b *FileMeta) int {
+	switch s.Field {
+	case SORT_BY_CREATED_AT:
+		return a.CreatedAt.Compare(b.CreatedAt)
+	case SORT_BY_NAME:
+		return strings.Compare(a.Filename, b.Filename)
+	case SORT_BY_SIZE:
+		return compareInt(a.Size, b.Size)
+	default:
+		// Files updated at the same time are ordered by creation time.
+		if result := a.UpdatedAt.Compare(b.UpdatedAt); result != 0 {
+			return result
+		}
+		return a.CreatedAt.Compare(b.CreatedAt)
+	}
+}
+
+func compareInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -1,8 +1,22 @@
 package file
 
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrPageTokenInvalid = fmt.Errorf("%w: invalid page token", ErrFile)
+
+// Page selects a part of the file listing. When After is set the page starts
+// right after that position and Number is ignored.
 type Page struct {
 	Number int
 	Size   int
+	After  *Cursor
 }
 
 func NewPage(number int, size int) Page {
@@ -17,3 +31,105 @@ func NewPage(number int, size int) Page {
 		Size:   size,
 	}
 }
+
+// NewCursorPage returns a page of the given size starting after the position
+// encoded in the token, which must come from a listing with the same sort.
+func NewCursorPage(token string, sort Sort, size int) (Page, error) {
+	cursor, err := ParseCursor(token)
+	if err != nil {
+		return Page{}, err
+	}
+	if cursor.Sort != sort {
+		return Page{}, ErrPageTokenInvalid
+	}
+
+	page := NewPage(1, size)
+	page.After = cursor
+	return page, nil
+}
+
+// Cursor is a position in the file listing, the key of the file the previous
+// page ended with.
+type Cursor struct {
+	Sort      Sort
+	UpdatedAt time.Time
+	CreatedAt time.Time
+	Filename  string
+	Size      int64
+	ID        uuid.UUID
+}
+
+type cursorToken struct {
+	Field     SortField `json:"f"`
+	Desc      bool      `json:"d,omitempty"`
+	UpdatedAt int64     `json:"u"`
+	CreatedAt int64     `json:"c"`
+	Filename  string    `json:"n,omitempty"`
+	Size      int64     `json:"s,omitempty"`
+	ID        uuid.UUID `json:"i"`
+}
+
+// CursorOf returns the position of the file meta in the listing.
+func CursorOf(meta *FileMeta, sort Sort) *Cursor {
+	return &Cursor{
+		Sort:      sort,
+		UpdatedAt: meta.UpdatedAt,
+		CreatedAt: meta.CreatedAt,
+		Filename:  meta.Filename,
+		Size:      meta.Size,
+		ID:        meta.ID,
+	}
+}
+
+// ParseCursor decodes a page token returned by Cursor.Token.
+func ParseCursor(token string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrPageTokenInvalid
+	}
+
+	var decoded cursorToken
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.ID == uuid.Nil {
+		return nil, ErrPageTokenInvalid
+	}
+	sort, err := NewSort(decoded.Field, decoded.Desc)
+	if err != nil {
+		return nil, ErrPageTokenInvalid
+	}
+
+	return &Cursor{
+		Sort:      sort,
+		UpdatedAt: time.Unix(0, decoded.UpdatedAt).UTC(),
+		CreatedAt: time.Unix(0, decoded.CreatedAt).UTC(),
+		Filename:  decoded.Filename,
+		Size:      decoded.Size,
+		ID:        decoded.ID,
+	}, nil
+}
+
+// Token encodes the cursor as an opaque page token.
+func (c *Cursor) Token() string {
+	data, _ := json.Marshal(cursorToken{
+		Field:     c.Sort.Field,
+		Desc:      c.Sort.Desc,
+		UpdatedAt: c.UpdatedAt.UnixNano(),
+		CreatedAt: c.CreatedAt.UnixNano(),
+		Filename:  c.Filename,
+		Size:      c.Size,
+		ID:        c.ID,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Precedes reports whether the cursor position comes before the file meta in
+// the listing.
+func (c *Cursor) Precedes(meta *FileMeta) bool {
+	key := FileMeta{
+		ID:        c.ID,
+		Filename:  c.Filename,
+		Size:      c.Size,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
+	return c.Sort.Compare(&key, meta) < 0
+}
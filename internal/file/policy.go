@@ -0,0 +1,17 @@
+package file
+
+import "fmt"
+
+var (
+	ErrContentTypeNotAllowed = fmt.Errorf("%w: content type is not allowed", ErrFile)
+	ErrExtensionMismatch     = fmt.Errorf("%w: extension doesn't match the content", ErrFile)
+)
+
+// UploadPolicy decides which files may be uploaded.
+type UploadPolicy interface {
+	// CheckName validates the filename before any content is received.
+	CheckName(filename string) error
+	// Check validates the filename against the leading bytes of the content
+	// and returns the detected content type, empty when it is unknown.
+	Check(filename string, head []byte) (string, error)
+}
@@ -8,6 +8,24 @@ import (
 
 type FileMetaRepository interface {
 	Save(ctx context.Context, meta *FileMeta) error
-	FindAll(ctx context.Context, page Page) ([]*FileMeta, error)
+	// FindAll returns a page of the files passing the filter in the sort order.
+	FindAll(ctx context.Context, filter Filter, sort Sort, page Page) ([]*FileMeta, error)
 	FindById(ctx context.Context, id uuid.UUID) (*FileMeta, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	// CountByHash returns the number of files referencing the content hash
+	// as their content or their original content.
+	CountByHash(ctx context.Context, hash string) (int, error)
+	// CountByContentHash returns the number of files referencing the hash as
+	// their content, not counting original content.
+	CountByContentHash(ctx context.Context, hash string) (int, error)
+	// FindIncomplete returns up to limit files stored before their size,
+	// content type and image metadata were recorded, with ids after the
+	// given one in id order. Saved files are complete.
+	FindIncomplete(ctx context.Context, after uuid.UUID, limit int) ([]*FileMeta, error)
+	// FindAllHashes returns the distinct content hashes referenced by files,
+	// including the hashes of original content.
+	FindAllHashes(ctx context.Context) ([]string, error)
+	// LockHash serializes transactions working with the content of the hash
+	// until the current transaction ends.
+	LockHash(ctx context.Context, hash string) error
 }
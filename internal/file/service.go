@@ -1,9 +1,49 @@
 package file
 
-import "context"
+import (
+	"context"
+	"io"
+)
 
 type FileService interface {
-	UploadFile(ctx context.Context, fileName string, fileData []byte) (string, error)
+	// UploadFile returns the file id and the checksums of the content as
+	// uploaded, which differs from the stored one with metadata stripped.
+	UploadFile(ctx context.Context, fileName string, fileData []byte, options UploadOptions) (string, Checksums, error)
+	UploadFileStream(ctx context.Context, fileName string, content io.Reader, options UploadOptions) (string, Checksums, error)
+	// CheckContent returns a challenge to prove possession of the content
+	// with the SHA-256 hash to UploadByReference. It doesn't tell whether the
+	// content is stored.
+	CheckContent(ctx context.Context, hash string) ([]byte, error)
+	// UploadByReference stores a file with the content stored under the hash
+	// without uploading it again, returning the file id. The proof is the
+	// HMAC-SHA256 of the content keyed with the challenge. ErrBlobNotFound
+	// means the content has to be uploaded.
+	UploadByReference(ctx context.Context, fileName string, hash string, challenge, proof []byte, options UploadOptions) (string, error)
 	DownloadFile(ctx context.Context, fileId string) (string, []byte, error)
-	ViewFilesMetadata(ctx context.Context, page Page) ([]*FileMeta, error)
+	// DownloadFileStream returns the filename, the total size of the file and
+	// a reader of the requested byte range. Zero length means up to the end.
+	DownloadFileStream(ctx context.Context, fileId string, offset, length int64) (string, int64, io.ReadCloser, error)
+	// ViewFilesMetadata returns a page of the listing and the token of the
+	// next page, which is empty once the listing is exhausted.
+	ViewFilesMetadata(ctx context.Context, filter Filter, sort Sort, page Page) ([]*FileMeta, string, error)
+	GetFileMetadata(ctx context.Context, fileId string) (*FileMeta, error)
+	// GetThumbnail returns the content type and the content of the thumbnail
+	// of an image with the longest side of size pixels.
+	GetThumbnail(ctx context.Context, fileId string, size int) (string, []byte, error)
+	// DownloadTransformed returns the content type and the content of an
+	// image cropped, scaled and encoded as described by the transformation.
+	DownloadTransformed(ctx context.Context, fileId string, transform Transform) (string, []byte, error)
+	// DeleteFile removes the file metadata and the content once no other
+	// file references it.
+	DeleteFile(ctx context.Context, fileId string) error
+
+	StartUpload(ctx context.Context, fileName string, options UploadOptions) (*UploadSession, error)
+	// AppendChunk writes the chunk at offset, which must be equal to the
+	// session offset. On ErrUploadOffsetMismatch the current session is
+	// returned along with the error.
+	AppendChunk(ctx context.Context, sessionId string, offset int64, chunk []byte) (*UploadSession, error)
+	GetUploadStatus(ctx context.Context, sessionId string) (*UploadSession, error)
+	// CommitUpload verifies the staged content against the expected SHA-256
+	// and stores it as a file, returning the file id.
+	CommitUpload(ctx context.Context, sessionId string, hash string) (string, error)
 }
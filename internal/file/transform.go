@@ -0,0 +1,125 @@
+package file
+
+import (
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+)
+
+var ErrTransformInvalid = fmt.Errorf("%w: invalid image transformation", ErrFile)
+
+const (
+	// MAX_TRANSFORM_SIDE limits the dimensions of transformed images in
+	// pixels.
+	MAX_TRANSFORM_SIDE = 4096
+	// TRANSFORM_STEP quantizes the crop and the box of transformations in
+	// pixels and TRANSFORM_QUALITY_STEP their quality, so requests differing
+	// slightly share the stored variant.
+	TRANSFORM_STEP         = 8
+	TRANSFORM_QUALITY_STEP = 5
+)
+
+// FitMode is the way an image is scaled to the box of a transformation.
+type FitMode string
+
+const (
+	// FIT_CONTAIN scales the image to fit inside the box keeping the aspect
+	// ratio.
+	FIT_CONTAIN FitMode = "contain"
+	// FIT_COVER scales the image to cover the box keeping the aspect ratio,
+	// the overflow is cropped evenly from both sides.
+	FIT_COVER FitMode = "cover"
+	// FIT_FILL stretches the image to the box.
+	FIT_FILL FitMode = "fill"
+)
+
+// OutputFormat is the encoding of a transformed image, empty means JPEG for
+// opaque images and PNG otherwise.
+type OutputFormat string
+
+const (
+	FORMAT_JPEG OutputFormat = "jpeg"
+	FORMAT_PNG  OutputFormat = "png"
+)
+
+// Transform describes an image derived from a stored one: the crop of the
+// stored image is scaled to the box of Width x Height pixels and encoded in
+// the format.
+type Transform struct {
+	// Crop is the region of the stored image in pixels, nil means the whole
+	// image.
+	Crop *image.Rectangle
+	// Zero Width or Height is derived from the aspect ratio, both zero keep
+	// the size of the crop.
+	Width  int
+	Height int
+	Fit    FitMode
+	Format OutputFormat
+	// Quality is the JPEG quality from 1 to 100, zero means the default.
+	Quality int
+}
+
+func NewTransform(crop *image.Rectangle, width, height int, fit FitMode, format OutputFormat, quality int) (Transform, error) {
+	if crop != nil && (crop.Min.X < 0 || crop.Min.Y < 0 || crop.Empty()) {
+		return Transform{}, fmt.Errorf("%w: empty crop", ErrTransformInvalid)
+	}
+	if width < 0 || height < 0 || width > MAX_TRANSFORM_SIDE || height > MAX_TRANSFORM_SIDE {
+		return Transform{}, fmt.Errorf("%w: width and height must be up to %d", ErrTransformInvalid, MAX_TRANSFORM_SIDE)
+	}
+	switch fit {
+	case "":
+		fit = FIT_CONTAIN
+	case FIT_CONTAIN, FIT_COVER, FIT_FILL:
+	default:
+		return Transform{}, fmt.Errorf("%w: fit mode %q", ErrTransformInvalid, fit)
+	}
+	switch format {
+	case "", FORMAT_JPEG:
+	case FORMAT_PNG:
+		// PNG is lossless.
+		quality = 0
+	default:
+		return Transform{}, fmt.Errorf("%w: format %q", ErrTransformInvalid, format)
+	}
+	if quality < 0 || quality > 100 {
+		return Transform{}, fmt.Errorf("%w: quality must be from 1 to 100", ErrTransformInvalid)
+	}
+
+	// The fit mode only matters for a box with both sides.
+	if width == 0 || height == 0 {
+		fit = FIT_CONTAIN
+	}
+	// The crop is extended and the box and the quality are rounded up, so
+	// nothing requested is lost.
+	if crop != nil {
+		crop = &image.Rectangle{
+			Min: image.Pt(crop.Min.X/TRANSFORM_STEP*TRANSFORM_STEP, crop.Min.Y/TRANSFORM_STEP*TRANSFORM_STEP),
+			Max: image.Pt(roundUp(crop.Max.X, TRANSFORM_STEP), roundUp(crop.Max.Y, TRANSFORM_STEP)),
+		}
+	}
+	width, height = roundUp(width, TRANSFORM_STEP), roundUp(height, TRANSFORM_STEP)
+	quality = roundUp(quality, TRANSFORM_QUALITY_STEP)
+	return Transform{Crop: crop, Width: width, Height: height, Fit: fit, Format: format, Quality: quality}, nil
+}
+
+func roundUp(value, step int) int {
+	return (value + step - 1) / step * step
+}
+
+// Variant returns the variant of the blob key of the transformed image, see
+// DerivedKey.
+func (t Transform) Variant() string {
+	parts := []string{"t"}
+	if t.Crop != nil {
+		parts = append(parts, fmt.Sprintf("c%d-%d-%d-%d", t.Crop.Min.X, t.Crop.Min.Y, t.Crop.Dx(), t.Crop.Dy()))
+	}
+	parts = append(parts, "w"+strconv.Itoa(t.Width), "h"+strconv.Itoa(t.Height), string(t.Fit))
+	if t.Format != "" {
+		parts = append(parts, string(t.Format))
+	}
+	if t.Quality != 0 {
+		parts = append(parts, "q"+strconv.Itoa(t.Quality))
+	}
+	return strings.Join(parts, "-")
+}
@@ -0,0 +1,53 @@
+package file
+
+import (
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTransform(t *testing.T) {
+	crop := image.Rect(16, 24, 112, 72)
+
+	transform, err := NewTransform(&crop, 200, 0, FIT_COVER, FORMAT_JPEG, 70)
+	require.NoError(t, err)
+	require.Equal(t, "t-c16-24-96-48-w200-h0-contain-jpeg-q70", transform.Variant())
+	require.NoError(t, ValidateBlobKey(DerivedKey(strings.Repeat("0", 64), transform.Variant())))
+
+	// PNG has no quality, so both requests share the variant.
+	png, err := NewTransform(nil, 200, 96, "", FORMAT_PNG, 70)
+	require.NoError(t, err)
+	require.Equal(t, "t-w200-h96-contain-png", png.Variant())
+
+	// The crop is extended, the box and the quality are rounded up.
+	unaligned := image.Rect(10, 20, 110, 70)
+	quantized, err := NewTransform(&unaligned, 201, 1, FIT_FILL, FORMAT_JPEG, 71)
+	require.NoError(t, err)
+	require.Equal(t, image.Rect(8, 16, 112, 72), *quantized.Crop)
+	require.Equal(t, "t-c8-16-104-56-w208-h8-fill-jpeg-q75", quantized.Variant())
+	require.Equal(t, image.Rect(10, 20, 110, 70), unaligned)
+
+	empty := image.Rect(10, 10, 10, 20)
+	invalid := []struct {
+		name          string
+		crop          *image.Rectangle
+		width, height int
+		fit           FitMode
+		format        OutputFormat
+		quality       int
+	}{
+		{"Empty crop", &empty, 0, 0, "", "", 0},
+		{"Too wide", nil, MAX_TRANSFORM_SIDE + 1, 0, "", "", 0},
+		{"Unknown fit", nil, 10, 10, "stretch", "", 0},
+		{"Unknown format", nil, 10, 10, "", "gif", 0},
+		{"Quality", nil, 10, 10, "", FORMAT_JPEG, 101},
+	}
+	for _, test := range invalid {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewTransform(test.crop, test.width, test.height, test.fit, test.format, test.quality)
+			require.ErrorIs(t, err, ErrTransformInvalid)
+		})
+	}
+}
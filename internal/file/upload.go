@@ -0,0 +1,40 @@
+package file
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUploadNotFound       = fmt.Errorf("%w: upload session not found", ErrFile)
+	ErrUploadOffsetMismatch = fmt.Errorf("%w: upload offset mismatch", ErrFile)
+)
+
+// UploadSession is a resumable upload whose content is staged chunk by chunk
+// until it is committed as a file.
+type UploadSession struct {
+	ID       uuid.UUID
+	Filename string
+	// Offset is the number of bytes already written to the staging area,
+	// the next chunk must start at it.
+	Offset    int64
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	// Options are resolved when the session starts, StripMetadata is set.
+	Options UploadOptions
+}
+
+// UploadOptions control how the content of an upload is stored.
+type UploadOptions struct {
+	// StripMetadata removes EXIF, GPS and other embedded metadata from
+	// images before they are stored, nil means the service default.
+	StripMetadata *bool
+	// KeepOriginal stores the content as uploaded too when metadata is
+	// stripped from it.
+	KeepOriginal bool
+	// Checksums are expected of the content as uploaded, content not
+	// matching them is rejected.
+	Checksums Checksums
+}
@@ -0,0 +1,178 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+var errExifInvalid = errors.New("invalid EXIF")
+
+// EXIF tags read by parseExif, see the EXIF 2.32 specification.
+const (
+	TAG_MODEL                = 0x0110
+	TAG_ORIENTATION          = 0x0112
+	TAG_EXIF_IFD             = 0x8769
+	TAG_GPS_IFD              = 0x8825
+	TAG_DATE_TIME_ORIGINAL   = 0x9003
+	TAG_DATE_TIME_DIGITIZED  = 0x9004
+	TAG_OFFSET_TIME_ORIGINAL = 0x9011
+	TAG_GPS_LATITUDE         = 0x0002
+	TAG_GPS_LONGITUDE        = 0x0004
+)
+
+const EXIF_TIME_LAYOUT = "2006:01:02 15:04:05"
+
+type exifTags struct {
+	takenAt     time.Time
+	cameraModel string
+	orientation int
+	hasGPS      bool
+}
+
+// parseExif reads the tags of EXIF data, a TIFF structure starting with the
+// byte order mark.
+func parseExif(data []byte) (exifTags, error) {
+	var tags exifTags
+	if len(data) < 8 {
+		return tags, errExifInvalid
+	}
+
+	reader := tiffReader{data: data}
+	switch {
+	case bytes.HasPrefix(data, []byte("II*\x00")):
+		reader.order = binary.LittleEndian
+	case bytes.HasPrefix(data, []byte("MM\x00*")):
+		reader.order = binary.BigEndian
+	default:
+		return tags, errExifInvalid
+	}
+
+	ifd0, err := reader.readIFD(reader.order.Uint32(data[4:]))
+	if err != nil {
+		return tags, err
+	}
+	tags.cameraModel = ifd0[TAG_MODEL].text()
+	if orientation, ok := ifd0[TAG_ORIENTATION].uint(reader.order); ok && orientation >= 1 && orientation <= 8 {
+		tags.orientation = int(orientation)
+	}
+
+	if offset, ok := ifd0[TAG_EXIF_IFD].uint(reader.order); ok {
+		exif, err := reader.readIFD(offset)
+		if err != nil {
+			return tags, err
+		}
+		offsetTime := exif[TAG_OFFSET_TIME_ORIGINAL].text()
+		tags.takenAt = exifTime(exif[TAG_DATE_TIME_ORIGINAL].text(), offsetTime)
+		if tags.takenAt.IsZero() {
+			tags.takenAt = exifTime(exif[TAG_DATE_TIME_DIGITIZED].text(), offsetTime)
+		}
+	}
+
+	if offset, ok := ifd0[TAG_GPS_IFD].uint(reader.order); ok {
+		gps, err := reader.readIFD(offset)
+		if err != nil {
+			return tags, err
+		}
+		_, latitude := gps[TAG_GPS_LATITUDE]
+		_, longitude := gps[TAG_GPS_LONGITUDE]
+		tags.hasGPS = latitude && longitude
+	}
+
+	return tags, nil
+}
+
+// exifTime parses an EXIF date and time with an optional offset like
+// "+02:00", a missing offset is taken as UTC.
+func exifTime(value, offset string) time.Time {
+	location := time.UTC
+	if zone, err := time.Parse("-07:00", offset); err == nil {
+		location = zone.Location()
+	}
+	parsed, err := time.ParseInLocation(EXIF_TIME_LAYOUT, value, location)
+	if err != nil {
+		return time.Time{}
+	}
+	return parsed
+}
+
+// typeSizes are the sizes of values of TIFF field types by type.
+var typeSizes = map[uint16]uint64{
+	1:  1, // BYTE
+	2:  1, // ASCII
+	3:  2, // SHORT
+	4:  4, // LONG
+	5:  8, // RATIONAL
+	6:  1, // SBYTE
+	7:  1, // UNDEFINED
+	8:  2, // SSHORT
+	9:  4, // SLONG
+	10: 8, // SRATIONAL
+}
+
+type tiffReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+type ifdEntry struct {
+	kind  uint16
+	value []byte
+}
+
+// readIFD reads the entries of the image file directory at offset, entries
+// of unknown types are skipped.
+func (r tiffReader) readIFD(offset uint32) (map[uint16]ifdEntry, error) {
+	start := uint64(offset)
+	if start+2 > uint64(len(r.data)) {
+		return nil, errExifInvalid
+	}
+	count := uint64(r.order.Uint16(r.data[start:]))
+	if start+2+count*12 > uint64(len(r.data)) {
+		return nil, errExifInvalid
+	}
+
+	entries := make(map[uint16]ifdEntry, count)
+	for i := range count {
+		entry := r.data[start+2+i*12 : start+14+i*12]
+		tag, kind := r.order.Uint16(entry), r.order.Uint16(entry[2:])
+		size, ok := typeSizes[kind]
+		if !ok {
+			continue
+		}
+
+		// Values up to 4 bytes are stored in the entry, others at an offset.
+		length := size * uint64(r.order.Uint32(entry[4:]))
+		value := entry[8:12]
+		if length > 4 {
+			valueOffset := uint64(r.order.Uint32(entry[8:]))
+			if valueOffset+length > uint64(len(r.data)) {
+				continue
+			}
+			value = r.data[valueOffset : valueOffset+length]
+		}
+		entries[tag] = ifdEntry{kind: kind, value: value[:length]}
+	}
+	return entries, nil
+}
+
+// text returns the value of an ASCII entry.
+func (e ifdEntry) text() string {
+	if e.kind != 2 {
+		return ""
+	}
+	value, _, _ := bytes.Cut(e.value, []byte{0})
+	return string(bytes.TrimSpace(value))
+}
+
+// uint returns the first value of a SHORT or LONG entry.
+func (e ifdEntry) uint(order binary.ByteOrder) (uint32, bool) {
+	switch {
+	case e.kind == 3 && len(e.value) >= 2:
+		return uint32(order.Uint16(e.value)), true
+	case e.kind == 4 && len(e.value) >= 4:
+		return order.Uint32(e.value), true
+	}
+	return 0, false
+}
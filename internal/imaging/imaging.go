@@ -0,0 +1,122 @@
+// Package imaging decodes uploaded images and renders images derived from
+// them.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	_ "golang.org/x/image/bmp"
+	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+
+	"file-service/internal/file"
+)
+
+const (
+	// MAX_PIXELS limits the size of decoded images, so a small file
+	// declaring huge dimensions can't exhaust memory.
+	MAX_PIXELS = 100_000_000
+
+	JPEG_QUALITY = 85
+)
+
+// SUPPORTED_TYPES are the content types Decode can read.
+var SUPPORTED_TYPES = []string{
+	"image/jpeg",
+	"image/png",
+	"image/gif",
+	"image/webp",
+	"image/bmp",
+	"image/tiff",
+}
+
+// Supported reports whether images of the content type can be decoded.
+func Supported(contentType string) bool {
+	for _, supported := range SUPPORTED_TYPES {
+		if supported == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+// Decode reads an image, checking its dimensions before decoding the pixels,
+// and turns it upright by the orientation Inspect reads from its EXIF.
+func Decode(src io.Reader) (image.Image, error) {
+	var head bytes.Buffer
+	config, format, err := image.DecodeConfig(io.TeeReader(src, &head))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", file.ErrImageUnsupported, err)
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > MAX_PIXELS {
+		return nil, fmt.Errorf("%w: %dx%d pixels", file.ErrImageUnsupported, config.Width, config.Height)
+	}
+
+	exifHead := &headWriter{}
+	content := io.TeeReader(io.MultiReader(&head, src), exifHead)
+	img, _, err := image.Decode(content)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", file.ErrImageUnsupported, err)
+	}
+
+	// EXIF can follow the image data, e.g. in WebP.
+	if _, err := io.CopyN(io.Discard, content, int64(INSPECT_SIZE-len(exifHead.data))); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	tags, err := parseExif(findExif(format, exifHead.data))
+	if err != nil {
+		return img, nil
+	}
+	return orient(img, tags.orientation), nil
+}
+
+// Thumbnail scales the image down to fit a size x size square keeping the
+// aspect ratio, smaller images keep their dimensions. Images with
+// transparency are encoded as PNG, other ones as JPEG.
+func Thumbnail(img image.Image, size int) ([]byte, error) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > size || height > size {
+		if width >= height {
+			width, height = size, max(1, height*size/width)
+		} else {
+			width, height = max(1, width*size/height), size
+		}
+	}
+
+	scaled := image.NewNRGBA(image.Rect(0, 0, width, height))
+	xdraw.CatmullRom.Scale(scaled, scaled.Bounds(), img, bounds, draw.Src, nil)
+
+	return Encode(scaled, opaque(img))
+}
+
+// Encode writes the image as JPEG when it is opaque and as PNG otherwise.
+func Encode(img image.Image, opaque bool) ([]byte, error) {
+	var buffer bytes.Buffer
+	var err error
+	if opaque {
+		err = jpeg.Encode(&buffer, img, &jpeg.Options{Quality: JPEG_QUALITY})
+	} else {
+		err = png.Encode(&buffer, img)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func opaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"file-service/internal/file"
+)
+
+func TestThumbnail(t *testing.T) {
+	tests := []struct {
+		name          string
+		img           image.Image
+		size          int
+		width, height int
+		contentType   string
+	}{
+		{"landscape", image.NewRGBA(image.Rect(0, 0, 400, 200)), 100, 100, 50, "image/png"},
+		{"portrait", image.NewGray(image.Rect(0, 0, 200, 400)), 100, 50, 100, "image/jpeg"},
+		{"not upscaled", image.NewGray(image.Rect(0, 0, 30, 20)), 100, 30, 20, "image/jpeg"},
+		{"thin", image.NewGray(image.Rect(0, 0, 1000, 1)), 100, 100, 1, "image/jpeg"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := Thumbnail(test.img, test.size)
+			require.NoError(t, err)
+
+			config, format, err := image.DecodeConfig(bytes.NewReader(data))
+			require.NoError(t, err)
+			require.Equal(t, test.width, config.Width)
+			require.Equal(t, test.height, config.Height)
+			require.Equal(t, test.contentType, "image/"+format)
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	img.Set(1, 1, color.NRGBA{R: 255, A: 255})
+	var buffer bytes.Buffer
+	require.NoError(t, png.Encode(&buffer, img))
+	encoded := buffer.Bytes()
+
+	t.Run("Valid", func(t *testing.T) {
+		decoded, err := Decode(bytes.NewReader(encoded))
+		require.NoError(t, err)
+		require.Equal(t, img.Bounds(), decoded.Bounds())
+		require.Equal(t, color.NRGBA{R: 255, A: 255}, color.NRGBAModel.Convert(decoded.At(1, 1)))
+	})
+
+	t.Run("Orientation", func(t *testing.T) {
+		exif := encodeExif([][]exifTag{{{id: TAG_ORIENTATION, kind: 3, value: []byte{0, 6}}}})
+		decoded, err := Decode(bytes.NewReader(jpegWithExif(t, 30, 20, exif)))
+		require.NoError(t, err)
+		require.Equal(t, image.Rect(0, 0, 20, 30), decoded.Bounds())
+	})
+
+	t.Run("Garbage", func(t *testing.T) {
+		_, err := Decode(bytes.NewReader([]byte("not an image")))
+		require.ErrorIs(t, err, file.ErrImageUnsupported)
+	})
+
+	t.Run("Too many pixels", func(t *testing.T) {
+		huge := bytes.Clone(encoded)
+		// IHDR is the first chunk: length, type, width, height, ..., crc.
+		binary.BigEndian.PutUint32(huge[16:], 100_000)
+		binary.BigEndian.PutUint32(huge[20:], 100_000)
+		binary.BigEndian.PutUint32(huge[29:], crc32.ChecksumIEEE(huge[12:29]))
+
+		_, err := Decode(bytes.NewReader(huge))
+		require.ErrorIs(t, err, file.ErrImageUnsupported)
+		require.ErrorContains(t, err, "100000x100000")
+	})
+}
+
+func TestOrient(t *testing.T) {
+	// The top left pixel is marked, the images are 3x2. Gray images are
+	// converted before they are oriented.
+	nrgba := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	nrgba.Set(0, 0, color.NRGBA{R: 255, A: 255})
+	ycbcr := image.NewYCbCr(image.Rect(0, 0, 3, 2), image.YCbCrSubsampleRatio444)
+	for i := range ycbcr.Cb {
+		ycbcr.Cb[i], ycbcr.Cr[i] = 128, 128
+	}
+	ycbcr.Y[0] = 255
+	gray := image.NewGray(image.Rect(0, 0, 3, 2))
+	gray.Set(0, 0, color.White)
+	images := []struct {
+		img    image.Image
+		marked color.NRGBA
+	}{
+		{nrgba, color.NRGBA{R: 255, A: 255}},
+		{ycbcr, color.NRGBA{R: 255, G: 255, B: 255, A: 255}},
+		{gray, color.NRGBA{R: 255, G: 255, B: 255, A: 255}},
+	}
+
+	tests := []struct {
+		orientation   int
+		width, height int
+		x, y          int
+	}{
+		{1, 3, 2, 0, 0},
+		{2, 3, 2, 2, 0},
+		{3, 3, 2, 2, 1},
+		{4, 3, 2, 0, 1},
+		{5, 2, 3, 0, 0},
+		{6, 2, 3, 1, 0},
+		{7, 2, 3, 1, 2},
+		{8, 2, 3, 0, 2},
+	}
+	for _, source := range images {
+		for _, test := range tests {
+			oriented := orient(source.img, test.orientation)
+			require.Equal(t, image.Rect(0, 0, test.width, test.height), oriented.Bounds(), "%T orientation %d", source.img, test.orientation)
+			require.Equal(t, source.marked, color.NRGBAModel.Convert(oriented.At(test.x, test.y)), "%T orientation %d", source.img, test.orientation)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+
+	"file-service/internal/file"
+)
+
+// INSPECT_SIZE is the number of leading bytes image metadata is read from.
+const INSPECT_SIZE = 1024 * 1024
+
+// Inspect reads the dimensions, color model, format and EXIF tags of an
+// image from the leading bytes of its content. EXIF that can't be read is
+// ignored.
+func Inspect(src io.Reader) (*file.ImageMeta, error) {
+	head, err := io.ReadAll(io.LimitReader(src, INSPECT_SIZE))
+	if err != nil {
+		return nil, err
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(head))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", file.ErrImageUnsupported, err)
+	}
+
+	meta := &file.ImageMeta{
+		Width:      config.Width,
+		Height:     config.Height,
+		ColorModel: colorModelName(config.ColorModel),
+		Format:     format,
+	}
+	if tags, err := parseExif(findExif(format, head)); err == nil {
+		meta.TakenAt = tags.takenAt
+		meta.CameraModel = tags.cameraModel
+		meta.Orientation = tags.orientation
+		meta.HasGPS = tags.hasGPS
+	}
+	return meta, nil
+}
+
+func colorModelName(model color.Model) string {
+	if _, ok := model.(color.Palette); ok {
+		return "paletted"
+	}
+	switch model {
+	case color.RGBAModel:
+		return "rgba"
+	case color.RGBA64Model:
+		return "rgba64"
+	case color.NRGBAModel:
+		return "nrgba"
+	case color.NRGBA64Model:
+		return "nrgba64"
+	case color.AlphaModel:
+		return "alpha"
+	case color.Alpha16Model:
+		return "alpha16"
+	case color.GrayModel:
+		return "gray"
+	case color.Gray16Model:
+		return "gray16"
+	case color.YCbCrModel:
+		return "ycbcr"
+	case color.NYCbCrAModel:
+		return "nycbcra"
+	case color.CMYKModel:
+		return "cmyk"
+	}
+	return ""
+}
+
+// findExif returns the EXIF data embedded in the image, nil if there is none.
+func findExif(format string, head []byte) []byte {
+	switch format {
+	case "jpeg":
+		return jpegExif(head)
+	case "png":
+		return pngExif(head)
+	case "webp":
+		return webpExif(head)
+	case "tiff":
+		// EXIF has the structure of TIFF, so the file itself is read.
+		return head
+	}
+	return nil
+}
+
+// jpegExif finds the APP1 segment with EXIF among the segments before the
+// image data.
+func jpegExif(head []byte) []byte {
+	for i := 2; i+4 <= len(head) && head[i] == 0xFF; {
+		marker := head[i+1]
+		switch {
+		case marker == 0xFF:
+			// Fill byte before a marker.
+			i++
+			continue
+		case marker == 0x01 || marker >= 0xD0 && marker <= 0xD8:
+			// Markers without a segment.
+			i += 2
+			continue
+		case marker == 0xD9 || marker == 0xDA:
+			return nil
+		}
+
+		length := int(binary.BigEndian.Uint16(head[i+2:]))
+		if length < 2 || i+2+length > len(head) {
+			return nil
+		}
+		segment := head[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return segment[6:]
+		}
+		i += 2 + length
+	}
+	return nil
+}
+
+// pngExif finds the eXIf chunk among the chunks before the image data.
+func pngExif(head []byte) []byte {
+	for i := 8; i+8 <= len(head); {
+		length := int(binary.BigEndian.Uint32(head[i:]))
+		kind := string(head[i+4 : i+8])
+		if length < 0 || length > len(head)-i-12 || kind == "IDAT" || kind == "IEND" {
+			return nil
+		}
+		if kind == "eXIf" {
+			return head[i+8 : i+8+length]
+		}
+		i += 12 + length
+	}
+	return nil
+}
+
+// webpExif finds the EXIF chunk of an extended format WebP file.
+func webpExif(head []byte) []byte {
+	for i := 12; i+8 <= len(head); {
+		length := int(binary.LittleEndian.Uint32(head[i+4:]))
+		kind := string(head[i : i+4])
+		if length < 0 || length > len(head)-i-8 {
+			return nil
+		}
+		if kind == "EXIF" {
+			// Some writers keep the prefix of the JPEG segment.
+			return bytes.TrimPrefix(head[i+8:i+8+length], []byte("Exif\x00\x00"))
+		}
+		i += 8 + length + length%2
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"file-service/internal/file"
+)
+
+func TestInspect(t *testing.T) {
+	exif := encodeExif([][]exifTag{
+		{
+			{id: TAG_MODEL, kind: 2, value: []byte("Camera\x00")},
+			{id: TAG_ORIENTATION, kind: 3, value: []byte{0, 6}},
+			{id: TAG_EXIF_IFD, kind: 4, ifd: 1},
+			{id: TAG_GPS_IFD, kind: 4, ifd: 2},
+		},
+		{
+			{id: TAG_DATE_TIME_ORIGINAL, kind: 2, value: []byte("2024:05:01 12:30:00\x00")},
+			{id: TAG_OFFSET_TIME_ORIGINAL, kind: 2, value: []byte("+02:00\x00")},
+		},
+		{
+			{id: TAG_GPS_LATITUDE, kind: 5, value: make([]byte, 24)},
+			{id: TAG_GPS_LONGITUDE, kind: 5, value: make([]byte, 24)},
+		},
+	})
+
+	t.Run("JPEG with EXIF", func(t *testing.T) {
+		meta, err := Inspect(bytes.NewReader(jpegWithExif(t, 40, 30, exif)))
+		require.NoError(t, err)
+		require.Equal(t, &file.ImageMeta{
+			Width:       40,
+			Height:      30,
+			ColorModel:  "gray",
+			Format:      "jpeg",
+			TakenAt:     meta.TakenAt,
+			CameraModel: "Camera",
+			Orientation: 6,
+			HasGPS:      true,
+		}, meta)
+		require.True(t, time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC).Equal(meta.TakenAt), meta.TakenAt)
+	})
+
+	t.Run("PNG without EXIF", func(t *testing.T) {
+		var buffer bytes.Buffer
+		require.NoError(t, png.Encode(&buffer, image.NewNRGBA(image.Rect(0, 0, 3, 2))))
+
+		meta, err := Inspect(&buffer)
+		require.NoError(t, err)
+		require.Equal(t, &file.ImageMeta{Width: 3, Height: 2, ColorModel: "nrgba", Format: "png"}, meta)
+	})
+
+	t.Run("Broken EXIF", func(t *testing.T) {
+		broken := bytes.Clone(exif)
+		// IFD0 offset past the end of the data.
+		binary.BigEndian.PutUint32(broken[4:], 1<<20)
+
+		meta, err := Inspect(bytes.NewReader(jpegWithExif(t, 4, 4, broken)))
+		require.NoError(t, err)
+		require.Equal(t, &file.ImageMeta{Width: 4, Height: 4, ColorModel: "gray", Format: "jpeg"}, meta)
+	})
+
+	t.Run("Not an image", func(t *testing.T) {
+		_, err := Inspect(bytes.NewReader([]byte("plain text")))
+		require.ErrorIs(t, err, file.ErrImageUnsupported)
+	})
+}
+
+func TestParseExif_Truncated(t *testing.T) {
+	exif := encodeExif([][]exifTag{{
+		{id: TAG_MODEL, kind: 2, value: []byte("A long camera model\x00")},
+		{id: TAG_ORIENTATION, kind: 3, value: []byte{0, 3}},
+	}})
+
+	for size := range len(exif) {
+		require.NotPanics(t, func() { parseExif(exif[:size]) }, size)
+	}
+
+	tags, err := parseExif(exif[:len(exif)-1])
+	require.NoError(t, err)
+	require.Empty(t, tags.cameraModel)
+	require.Equal(t, 3, tags.orientation)
+}
+
+func jpegWithExif(t *testing.T, width, height int, exif []byte) []byte {
+	t.Helper()
+
+	encoded := encodeJPEG(t, width, height)
+	segment := append([]byte("Exif\x00\x00"), exif...)
+	result := append([]byte{}, encoded[:2]...)
+	result = append(result, 0xFF, 0xE1)
+	result = binary.BigEndian.AppendUint16(result, uint16(len(segment)+2))
+	result = append(result, segment...)
+	return append(result, encoded[2:]...)
+}
+
+func encodeJPEG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buffer, image.NewGray(image.Rect(0, 0, width, height)), nil))
+	return buffer.Bytes()
+}
+
+type exifTag struct {
+	id, kind uint16
+	// value holds big-endian ASCII, SHORT or RATIONAL values.
+	value []byte
+	// ifd is the index of the IFD a LONG pointer refers to.
+	ifd int
+}
+
+// encodeExif lays out big-endian EXIF with the IFDs following the header and
+// the values which don't fit into entries following the IFDs.
+func encodeExif(ifds [][]exifTag) []byte {
+	offsets := []uint32{8}
+	for _, tags := range ifds {
+		offsets = append(offsets, offsets[len(offsets)-1]+uint32(2+12*len(tags)+4))
+	}
+
+	result := []byte("MM\x00*\x00\x00\x00\x08")
+	var data []byte
+	for _, tags := range ifds {
+		result = binary.BigEndian.AppendUint16(result, uint16(len(tags)))
+		for _, tag := range tags {
+			result = binary.BigEndian.AppendUint16(result, tag.id)
+			result = binary.BigEndian.AppendUint16(result, tag.kind)
+			switch {
+			case tag.kind == 4:
+				result = binary.BigEndian.AppendUint32(result, 1)
+				result = binary.BigEndian.AppendUint32(result, offsets[tag.ifd])
+			case len(tag.value) <= 4:
+				result = binary.BigEndian.AppendUint32(result, uint32(len(tag.value))/uint32(typeSizes[tag.kind]))
+				result = append(result, tag.value...)
+				result = append(result, make([]byte, 4-len(tag.value))...)
+			default:
+				result = binary.BigEndian.AppendUint32(result, uint32(len(tag.value))/uint32(typeSizes[tag.kind]))
+				result = binary.BigEndian.AppendUint32(result, offsets[len(ifds)]+uint32(len(data)))
+				data = append(data, tag.value...)
+			}
+		}
+		result = binary.BigEndian.AppendUint32(result, 0)
+	}
+	return append(result, data...)
+}
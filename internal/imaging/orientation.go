@@ -0,0 +1,84 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// headWriter keeps the first INSPECT_SIZE bytes written to it, the part of
+// the content Inspect reads the EXIF from.
+type headWriter struct {
+	data []byte
+}
+
+func (w *headWriter) Write(p []byte) (int, error) {
+	if room := INSPECT_SIZE - len(w.data); room > 0 {
+		w.data = append(w.data, p[:min(room, len(p))]...)
+	}
+	return len(p), nil
+}
+
+// orient turns the image upright by its EXIF orientation, the way viewers
+// show it. Orientations 5 to 8 swap the width and height.
+func orient(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	rect := image.Rect(0, 0, width, height)
+	if orientation >= 5 {
+		rect = image.Rect(0, 0, height, width)
+	}
+	dst := image.NewNRGBA(rect)
+	pixel := pixelReader(img)
+	for y := range rect.Dy() {
+		for x := range rect.Dx() {
+			var sx, sy int
+			switch orientation {
+			case 2: // Mirrored horizontally.
+				sx, sy = width-1-x, y
+			case 3: // Rotated by 180°.
+				sx, sy = width-1-x, height-1-y
+			case 4: // Mirrored vertically.
+				sx, sy = x, height-1-y
+			case 5: // Mirrored along the main diagonal.
+				sx, sy = y, x
+			case 6: // Needs a clockwise rotation by 90°.
+				sx, sy = y, height-1-x
+			case 7: // Mirrored along the anti-diagonal.
+				sx, sy = width-1-y, height-1-x
+			case 8: // Needs a counterclockwise rotation by 90°.
+				sx, sy = width-1-y, x
+			}
+			pixel(dst.Pix[dst.PixOffset(x, y):][:4], bounds.Min.X+sx, bounds.Min.Y+sy)
+		}
+	}
+	return dst
+}
+
+// pixelReader returns a function copying the non-alpha-premultiplied color of
+// the pixel of the image at x, y to p. The pixels of NRGBA images, like PNG
+// ones, and YCbCr images, like JPEG ones, are read in place, other images are
+// converted to NRGBA first.
+func pixelReader(img image.Image) func(p []byte, x, y int) {
+	switch img := img.(type) {
+	case *image.YCbCr:
+		return func(p []byte, x, y int) {
+			c := img.YCbCrAt(x, y)
+			p[0], p[1], p[2] = color.YCbCrToRGB(c.Y, c.Cb, c.Cr)
+			p[3] = 0xFF
+		}
+	case *image.NRGBA:
+		return func(p []byte, x, y int) {
+			copy(p, img.Pix[img.PixOffset(x, y):][:4])
+		}
+	}
+
+	bounds := img.Bounds()
+	converted := image.NewNRGBA(bounds)
+	draw.Draw(converted, bounds, img, bounds.Min, draw.Src)
+	return pixelReader(converted)
+}
@@ -0,0 +1,345 @@
+package imaging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"file-service/internal/file"
+)
+
+var errStripTruncated = errors.New("image is truncated")
+
+// STRIP_TYPES are the content types Strip can remove metadata from.
+var STRIP_TYPES = []string{
+	"image/jpeg",
+	"image/png",
+	"image/webp",
+}
+
+// StripSupported reports whether metadata can be removed from images of the
+// content type.
+func StripSupported(contentType string) bool {
+	for _, supported := range STRIP_TYPES {
+		if supported == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+// Strip copies the image from src to dst without EXIF, GPS, XMP, IPTC,
+// comments and other embedded metadata. The image data is copied as is, the
+// color profile and the EXIF orientation are kept, so the image looks the
+// same.
+func Strip(dst io.Writer, src io.ReadSeeker, contentType string) error {
+	var err error
+	switch contentType {
+	case "image/jpeg":
+		err = stripJPEG(dst, src)
+	case "image/png":
+		err = stripPNG(dst, src)
+	case "image/webp":
+		err = stripWebP(dst, src)
+	default:
+		return file.ErrImageUnsupported
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %w", file.ErrImageUnsupported, err)
+	}
+	return nil
+}
+
+// stripJPEG drops APPn segments other than JFIF, ICC profiles and Adobe color
+// transforms, comments and the data after the end of the image, like the
+// images of Multi-Picture Format files.
+func stripJPEG(dst io.Writer, src io.Reader) error {
+	r := bufio.NewReader(src)
+	w := bufio.NewWriter(dst)
+
+	soi := make([]byte, 2)
+	if _, err := io.ReadFull(r, soi); err != nil || soi[0] != 0xFF || soi[1] != 0xD8 {
+		return errors.New("missing JPEG start of image")
+	}
+	w.Write(soi)
+
+	scan, exifWritten := false, false
+	for {
+		if scan {
+			// Entropy coded data up to the next marker.
+			data, err := r.ReadSlice(0xFF)
+			if errors.Is(err, bufio.ErrBufferFull) {
+				w.Write(data)
+				continue
+			}
+			if errors.Is(err, io.EOF) {
+				// Decoders accept images missing the end of image marker.
+				w.Write(data)
+				return w.Flush()
+			}
+			if err != nil {
+				return errStripTruncated
+			}
+			w.Write(data[:len(data)-1])
+		} else if b, err := r.ReadByte(); err != nil {
+			return errStripTruncated
+		} else if b != 0xFF {
+			return errors.New("invalid JPEG marker")
+		}
+
+		marker, err := r.ReadByte()
+		for err == nil && marker == 0xFF {
+			marker, err = r.ReadByte()
+		}
+		if err != nil {
+			return errStripTruncated
+		}
+
+		switch {
+		case scan && (marker == 0x00 || marker >= 0xD0 && marker <= 0xD7):
+			// A stuffed 0xFF byte or a restart marker inside the scan.
+			w.Write([]byte{0xFF, marker})
+			continue
+		case marker == 0xD9:
+			w.Write([]byte{0xFF, marker})
+			return w.Flush()
+		case marker == 0x01 || marker >= 0xD0 && marker <= 0xD8:
+			w.Write([]byte{0xFF, marker})
+			continue
+		}
+
+		header := make([]byte, 2)
+		if _, err := io.ReadFull(r, header); err != nil {
+			return errStripTruncated
+		}
+		length := int(binary.BigEndian.Uint16(header))
+		if length < 2 {
+			return errors.New("invalid JPEG segment length")
+		}
+		payload := make([]byte, length-2)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return errStripTruncated
+		}
+		scan = marker == 0xDA
+
+		if keepSegment(marker, payload) {
+			w.Write([]byte{0xFF, marker})
+			w.Write(header)
+			w.Write(payload)
+			continue
+		}
+
+		// The orientation is kept, images would be displayed rotated otherwise.
+		if marker == 0xE1 && !exifWritten && bytes.HasPrefix(payload, []byte("Exif\x00\x00")) {
+			if tags, err := parseExif(payload[6:]); err == nil && tags.orientation > 1 {
+				segment := orientationExif(tags.orientation)
+				w.Write([]byte{0xFF, 0xE1})
+				w.Write(binary.BigEndian.AppendUint16(nil, uint16(len(segment)+2)))
+				w.Write(segment)
+				exifWritten = true
+			}
+		}
+	}
+}
+
+func keepSegment(marker byte, payload []byte) bool {
+	switch {
+	case marker == 0xE0:
+		return true
+	case marker == 0xE2:
+		return bytes.HasPrefix(payload, []byte("ICC_PROFILE\x00"))
+	case marker == 0xEE:
+		return bytes.HasPrefix(payload, []byte("Adobe"))
+	case marker >= 0xE1 && marker <= 0xEF, marker == 0xFE:
+		return false
+	}
+	return true
+}
+
+// orientationExif returns the content of an APP1 segment with EXIF holding
+// the orientation only.
+func orientationExif(orientation int) []byte {
+	segment := []byte("Exif\x00\x00MM\x00*\x00\x00\x00\x08\x00\x01")
+	segment = binary.BigEndian.AppendUint16(segment, TAG_ORIENTATION)
+	segment = binary.BigEndian.AppendUint16(segment, 3)
+	segment = binary.BigEndian.AppendUint32(segment, 1)
+	segment = binary.BigEndian.AppendUint16(segment, uint16(orientation))
+	return append(segment, 0, 0, 0, 0, 0, 0)
+}
+
+// pngMetadataChunks are the PNG chunks dropped by stripPNG.
+var pngMetadataChunks = map[string]bool{
+	"eXIf": true,
+	"tEXt": true,
+	"zTXt": true,
+	"iTXt": true,
+	"tIME": true,
+}
+
+func stripPNG(dst io.Writer, src io.Reader) error {
+	r := bufio.NewReader(src)
+	w := bufio.NewWriter(dst)
+
+	signature := make([]byte, 8)
+	if _, err := io.ReadFull(r, signature); err != nil || string(signature) != "\x89PNG\r\n\x1a\n" {
+		return errors.New("missing PNG signature")
+	}
+	w.Write(signature)
+
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return errStripTruncated
+		}
+		kind := string(header[4:])
+		// The chunk data is followed by its CRC.
+		length := int64(binary.BigEndian.Uint32(header)) + 4
+
+		var err error
+		switch {
+		case kind == "eXIf" && length <= INSPECT_SIZE:
+			// The orientation is kept, like in stripJPEG.
+			data := make([]byte, length)
+			if _, err = io.ReadFull(r, data); err == nil {
+				if tags, err := parseExif(data[:length-4]); err == nil && tags.orientation > 1 {
+					w.Write(pngChunk("eXIf", orientationExif(tags.orientation)[6:]))
+				}
+			}
+		case pngMetadataChunks[kind]:
+			_, err = io.CopyN(io.Discard, r, length)
+		default:
+			w.Write(header)
+			_, err = io.CopyN(w, r, length)
+		}
+		if err != nil {
+			return errStripTruncated
+		}
+
+		if kind == "IEND" {
+			return w.Flush()
+		}
+	}
+}
+
+func pngChunk(kind string, data []byte) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	chunk = append(chunk, kind...)
+	chunk = append(chunk, data...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+// Flags of the extended WebP header.
+const (
+	VP8X_EXIF_FLAG      = 0x08
+	VP8X_XMP_FLAG       = 0x04
+	VP8X_METADATA_FLAGS = VP8X_EXIF_FLAG | VP8X_XMP_FLAG
+)
+
+// stripWebP drops the XMP chunk and replaces the EXIF chunk with one holding
+// the orientation only. The RIFF header holds the size of the file, so the
+// chunks are read twice.
+func stripWebP(dst io.Writer, src io.ReadSeeker) error {
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(src, header); err != nil || string(header[:4]) != "RIFF" || string(header[8:]) != "WEBP" {
+		return errors.New("missing WebP header")
+	}
+	end := int64(binary.LittleEndian.Uint32(header[4:])) + 8
+
+	type chunk struct {
+		kind           string
+		offset, length int64
+		// data replaces the content of the chunk when set.
+		data []byte
+	}
+	var chunks []chunk
+	size := int64(4)
+	dropped := byte(VP8X_METADATA_FLAGS)
+	chunkHeader := make([]byte, 8)
+	for offset := int64(12); offset < end; {
+		if _, err := src.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(src, chunkHeader); err != nil {
+			return errStripTruncated
+		}
+		length := int64(binary.LittleEndian.Uint32(chunkHeader[4:]))
+		// Chunks are padded to an even size.
+		length += 8 + length%2
+		kind := string(chunkHeader[:4])
+		switch kind {
+		case "EXIF":
+			if exif := webpOrientationChunk(src, length-8); exif != nil {
+				chunks = append(chunks, chunk{kind: kind, length: int64(len(exif)), data: exif})
+				size += int64(len(exif))
+				dropped = VP8X_XMP_FLAG
+			}
+		case "XMP ":
+		default:
+			chunks = append(chunks, chunk{kind: kind, offset: offset, length: length})
+			size += length
+		}
+		offset += length
+	}
+
+	w := bufio.NewWriter(dst)
+	w.Write(header[:4])
+	w.Write(binary.LittleEndian.AppendUint32(nil, uint32(size)))
+	w.Write(header[8:])
+	for _, chunk := range chunks {
+		if chunk.data != nil {
+			w.Write(chunk.data)
+			continue
+		}
+		if _, err := src.Seek(chunk.offset, io.SeekStart); err != nil {
+			return err
+		}
+		data := io.LimitReader(src, chunk.length)
+		if chunk.kind == "VP8X" {
+			vp8x := make([]byte, chunk.length)
+			if _, err := io.ReadFull(data, vp8x); err != nil || len(vp8x) < 9 {
+				return errStripTruncated
+			}
+			vp8x[8] &^= dropped
+			data = bytes.NewReader(vp8x)
+		}
+		if n, err := io.Copy(w, data); err != nil {
+			return err
+		} else if n != chunk.length {
+			return errStripTruncated
+		}
+	}
+	return w.Flush()
+}
+
+// webpOrientationChunk reads the EXIF chunk data of the length at the current
+// offset of src and returns an EXIF chunk holding its orientation only, nil
+// when there is no orientation to keep.
+func webpOrientationChunk(src io.Reader, length int64) []byte {
+	if length > INSPECT_SIZE {
+		return nil
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(src, data); err != nil {
+		return nil
+	}
+	// Some writers keep the prefix of the JPEG segment.
+	tags, err := parseExif(bytes.TrimPrefix(data, []byte("Exif\x00\x00")))
+	if err != nil || tags.orientation <= 1 {
+		return nil
+	}
+
+	return webpChunk("EXIF", orientationExif(tags.orientation)[6:])
+}
+
+func webpChunk(kind string, data []byte) []byte {
+	chunk := append([]byte(kind), binary.LittleEndian.AppendUint32(nil, uint32(len(data)))...)
+	chunk = append(chunk, data...)
+	if len(data)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
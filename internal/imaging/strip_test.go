@@ -0,0 +1,161 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"file-service/internal/file"
+)
+
+func TestStrip_JPEG(t *testing.T) {
+	exif := encodeExif([][]exifTag{
+		{
+			{id: TAG_MODEL, kind: 2, value: []byte("Camera\x00")},
+			{id: TAG_ORIENTATION, kind: 3, value: []byte{0, 6}},
+			{id: TAG_GPS_IFD, kind: 4, ifd: 1},
+		},
+		{
+			{id: TAG_GPS_LATITUDE, kind: 5, value: make([]byte, 24)},
+			{id: TAG_GPS_LONGITUDE, kind: 5, value: make([]byte, 24)},
+		},
+	})
+	original := jpegWithExif(t, 16, 8, exif)
+	plain := encodeJPEG(t, 16, 8)
+
+	// A comment before the image data and a preview after its end.
+	withComment := append([]byte{}, original[:2]...)
+	withComment = append(withComment, 0xFF, 0xFE, 0x00, 0x09)
+	withComment = append(withComment, "comment"...)
+	withComment = append(withComment, original[2:]...)
+	withComment = append(withComment, plain...)
+
+	var stripped bytes.Buffer
+	require.NoError(t, Strip(&stripped, bytes.NewReader(withComment), "image/jpeg"))
+
+	segment := orientationExif(6)
+	expected := append([]byte{}, plain[:2]...)
+	expected = append(expected, 0xFF, 0xE1)
+	expected = binary.BigEndian.AppendUint16(expected, uint16(len(segment)+2))
+	expected = append(expected, segment...)
+	expected = append(expected, plain[2:]...)
+	require.Equal(t, expected, stripped.Bytes())
+
+	meta, err := Inspect(bytes.NewReader(stripped.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, &file.ImageMeta{Width: 16, Height: 8, ColorModel: "gray", Format: "jpeg", Orientation: 6}, meta)
+
+	var strippedPlain bytes.Buffer
+	require.NoError(t, Strip(&strippedPlain, bytes.NewReader(plain), "image/jpeg"))
+	require.Equal(t, plain, strippedPlain.Bytes())
+}
+
+func TestStrip_PNG(t *testing.T) {
+	var buffer bytes.Buffer
+	require.NoError(t, png.Encode(&buffer, image.NewNRGBA(image.Rect(0, 0, 4, 4))))
+	plain := buffer.Bytes()
+
+	// Metadata chunks after the header.
+	original := append([]byte{}, plain[:33]...)
+	original = append(original, pngChunk("tEXt", []byte("Author\x00Someone"))...)
+	original = append(original, pngChunk("eXIf", encodeExif([][]exifTag{{{id: TAG_MODEL, kind: 2, value: []byte("Camera\x00")}}}))...)
+	original = append(original, plain[33:]...)
+
+	var stripped bytes.Buffer
+	require.NoError(t, Strip(&stripped, bytes.NewReader(original), "image/png"))
+	require.Equal(t, plain, stripped.Bytes())
+
+	// The orientation is kept.
+	oriented := append([]byte{}, plain[:33]...)
+	oriented = append(oriented, pngChunk("eXIf", encodeExif([][]exifTag{{
+		{id: TAG_MODEL, kind: 2, value: []byte("Camera\x00")},
+		{id: TAG_ORIENTATION, kind: 3, value: []byte{0, 6}},
+	}}))...)
+	oriented = append(oriented, plain[33:]...)
+
+	stripped.Reset()
+	require.NoError(t, Strip(&stripped, bytes.NewReader(oriented), "image/png"))
+	expected := append([]byte{}, plain[:33]...)
+	expected = append(expected, pngChunk("eXIf", orientationExif(6)[6:])...)
+	expected = append(expected, plain[33:]...)
+	require.Equal(t, expected, stripped.Bytes())
+
+	meta, err := Inspect(bytes.NewReader(stripped.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, 6, meta.Orientation)
+}
+
+func TestStrip_WebP(t *testing.T) {
+	vp8x := make([]byte, 10)
+	vp8x[0] = 0x10 | VP8X_METADATA_FLAGS
+	original := riff(
+		webpChunk("VP8X", vp8x),
+		webpChunk("VP8L", []byte{1, 2, 3}),
+		webpChunk("EXIF", []byte("Exif\x00\x00MM")),
+		webpChunk("XMP ", []byte("<x:xmpmeta/>")),
+	)
+
+	var stripped bytes.Buffer
+	require.NoError(t, Strip(&stripped, bytes.NewReader(original), "image/webp"))
+
+	vp8x[0] = 0x10
+	require.Equal(t, riff(webpChunk("VP8X", vp8x), webpChunk("VP8L", []byte{1, 2, 3})), stripped.Bytes())
+
+	// The orientation is kept with the EXIF flag.
+	vp8x[0] = 0x10 | VP8X_METADATA_FLAGS
+	exif := encodeExif([][]exifTag{{
+		{id: TAG_MODEL, kind: 2, value: []byte("Camera\x00")},
+		{id: TAG_ORIENTATION, kind: 3, value: []byte{0, 8}},
+	}})
+	oriented := riff(
+		webpChunk("VP8X", vp8x),
+		webpChunk("VP8L", []byte{1, 2, 3}),
+		webpChunk("EXIF", append([]byte("Exif\x00\x00"), exif...)),
+		webpChunk("XMP ", []byte("<x:xmpmeta/>")),
+	)
+
+	stripped.Reset()
+	require.NoError(t, Strip(&stripped, bytes.NewReader(oriented), "image/webp"))
+	vp8x[0] = 0x10 | VP8X_EXIF_FLAG
+	expected := riff(
+		webpChunk("VP8X", vp8x),
+		webpChunk("VP8L", []byte{1, 2, 3}),
+		webpChunk("EXIF", orientationExif(8)[6:]),
+	)
+	require.Equal(t, expected, stripped.Bytes())
+}
+
+func TestStrip_Invalid(t *testing.T) {
+	plain := encodeJPEG(t, 16, 8)
+
+	tests := []struct {
+		name        string
+		content     []byte
+		contentType string
+	}{
+		{"Not a JPEG", []byte("plain text"), "image/jpeg"},
+		{"Truncated JPEG header", plain[:20], "image/jpeg"},
+		{"Truncated PNG", []byte("\x89PNG\r\n\x1a\n\x00\x00"), "image/png"},
+		{"Truncated WebP", riff(webpChunk("VP8L", []byte{1, 2, 3}))[:20], "image/webp"},
+		{"GIF", []byte("GIF89a"), "image/gif"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := Strip(&bytes.Buffer{}, bytes.NewReader(test.content), test.contentType)
+			require.ErrorIs(t, err, file.ErrImageUnsupported)
+		})
+	}
+}
+
+func riff(chunks ...[]byte) []byte {
+	content := []byte("WEBP")
+	for _, chunk := range chunks {
+		content = append(content, chunk...)
+	}
+	return append(append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(content)))...), content...)
+}
@@ -0,0 +1,95 @@
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+
+	xdraw "golang.org/x/image/draw"
+
+	"file-service/internal/file"
+)
+
+// Transform crops, scales and encodes the image as described by the
+// transformation. The crop is limited to the image bounds.
+func Transform(img image.Image, transform file.Transform) ([]byte, error) {
+	source := img.Bounds()
+	if transform.Crop != nil {
+		source = transform.Crop.Add(source.Min).Intersect(source)
+		if source.Empty() {
+			return nil, fmt.Errorf("%w: crop is outside of the %dx%d image", file.ErrTransformInvalid, img.Bounds().Dx(), img.Bounds().Dy())
+		}
+	}
+
+	source, width, height, err := fit(source, transform)
+	if err != nil {
+		return nil, err
+	}
+
+	scaled := image.NewNRGBA(image.Rect(0, 0, width, height))
+	xdraw.CatmullRom.Scale(scaled, scaled.Bounds(), img, source, draw.Src, nil)
+
+	format := transform.Format
+	if format == "" {
+		format = file.FORMAT_PNG
+		if opaque(img) {
+			format = file.FORMAT_JPEG
+		}
+	}
+
+	var buffer bytes.Buffer
+	if format == file.FORMAT_PNG {
+		err = png.Encode(&buffer, scaled)
+	} else {
+		quality := transform.Quality
+		if quality == 0 {
+			quality = JPEG_QUALITY
+		}
+		err = jpeg.Encode(&buffer, scaled, &jpeg.Options{Quality: quality})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// fit returns the region of the source drawn to the result and the
+// dimensions of the result.
+func fit(source image.Rectangle, transform file.Transform) (image.Rectangle, int, int, error) {
+	sourceWidth, sourceHeight := source.Dx(), source.Dy()
+	width, height := transform.Width, transform.Height
+
+	switch {
+	case width == 0 && height == 0:
+		width, height = sourceWidth, sourceHeight
+	case height == 0:
+		height = max(1, sourceHeight*width/sourceWidth)
+	case width == 0:
+		width = max(1, sourceWidth*height/sourceHeight)
+	case transform.Fit == file.FIT_CONTAIN:
+		if sourceWidth*height > sourceHeight*width {
+			height = max(1, sourceHeight*width/sourceWidth)
+		} else {
+			width = max(1, sourceWidth*height/sourceHeight)
+		}
+	case transform.Fit == file.FIT_COVER:
+		// The part of the source with the aspect ratio of the box.
+		if sourceWidth*height > sourceHeight*width {
+			cropWidth := max(1, sourceHeight*width/height)
+			source.Min.X += (sourceWidth - cropWidth) / 2
+			source.Max.X = source.Min.X + cropWidth
+		} else {
+			cropHeight := max(1, sourceWidth*height/width)
+			source.Min.Y += (sourceHeight - cropHeight) / 2
+			source.Max.Y = source.Min.Y + cropHeight
+		}
+	}
+
+	if width > file.MAX_TRANSFORM_SIDE || height > file.MAX_TRANSFORM_SIDE {
+		return source, 0, 0, fmt.Errorf("%w: %dx%d pixels", file.ErrTransformInvalid, width, height)
+	}
+	return source, width, height, nil
+}
@@ -0,0 +1,104 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"file-service/internal/file"
+)
+
+func TestTransform(t *testing.T) {
+	// Red left half and blue right half.
+	img := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	draw.Draw(img, image.Rect(0, 0, 200, 200), image.NewUniform(color.RGBA{R: 255, A: 255}), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(200, 0, 400, 200), image.NewUniform(color.RGBA{B: 255, A: 255}), image.Point{}, draw.Src)
+
+	rect := func(x0, y0, x1, y1 int) *image.Rectangle {
+		r := image.Rect(x0, y0, x1, y1)
+		return &r
+	}
+
+	tests := []struct {
+		name          string
+		crop          *image.Rectangle
+		width, height int
+		fit           file.FitMode
+		format        file.OutputFormat
+		resultWidth   int
+		resultHeight  int
+		resultFormat  string
+	}{
+		{"Same size", nil, 0, 0, "", "", 400, 200, "jpeg"},
+		{"Width only", nil, 96, 0, "", file.FORMAT_JPEG, 96, 48, "jpeg"},
+		{"Height only", nil, 0, 96, "", "", 192, 96, "jpeg"},
+		{"Contain", nil, 96, 96, file.FIT_CONTAIN, "", 96, 48, "jpeg"},
+		{"Cover", nil, 96, 96, file.FIT_COVER, "", 96, 96, "jpeg"},
+		{"Fill", nil, 96, 96, file.FIT_FILL, file.FORMAT_PNG, 96, 96, "png"},
+		{"Upscaled", nil, 800, 0, "", "", 800, 400, "jpeg"},
+		{"Crop", rect(0, 0, 96, 48), 0, 0, "", "", 96, 48, "jpeg"},
+		{"Crop over the edge", rect(304, 104, 504, 304), 0, 0, "", "", 96, 96, "jpeg"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			transform, err := file.NewTransform(test.crop, test.width, test.height, test.fit, test.format, 0)
+			require.NoError(t, err)
+
+			data, err := Transform(img, transform)
+			require.NoError(t, err)
+
+			config, format, err := image.DecodeConfig(bytes.NewReader(data))
+			require.NoError(t, err)
+			require.Equal(t, test.resultWidth, config.Width)
+			require.Equal(t, test.resultHeight, config.Height)
+			require.Equal(t, test.resultFormat, format)
+		})
+	}
+
+	t.Run("Cover crops evenly", func(t *testing.T) {
+		transform, err := file.NewTransform(nil, 96, 96, file.FIT_COVER, file.FORMAT_PNG, 0)
+		require.NoError(t, err)
+
+		data, err := Transform(img, transform)
+		require.NoError(t, err)
+		result, _, err := image.Decode(bytes.NewReader(data))
+		require.NoError(t, err)
+
+		// The center of the image, half red and half blue.
+		require.Equal(t, color.NRGBA{R: 255, A: 255}, color.NRGBAModel.Convert(result.At(10, 50)))
+		require.Equal(t, color.NRGBA{B: 255, A: 255}, color.NRGBAModel.Convert(result.At(86, 50)))
+	})
+
+	t.Run("Transparent", func(t *testing.T) {
+		transform, err := file.NewTransform(nil, 10, 0, "", "", 0)
+		require.NoError(t, err)
+
+		data, err := Transform(image.NewNRGBA(image.Rect(0, 0, 20, 20)), transform)
+		require.NoError(t, err)
+		_, format, err := image.DecodeConfig(bytes.NewReader(data))
+		require.NoError(t, err)
+		require.Equal(t, "png", format)
+	})
+
+	t.Run("Crop outside of the image", func(t *testing.T) {
+		transform, err := file.NewTransform(rect(500, 0, 600, 100), 0, 0, "", "", 0)
+		require.NoError(t, err)
+
+		_, err = Transform(img, transform)
+		require.ErrorIs(t, err, file.ErrTransformInvalid)
+	})
+
+	t.Run("Too large", func(t *testing.T) {
+		thin := image.NewGray(image.Rect(0, 0, 1, 100))
+		transform, err := file.NewTransform(nil, 100, 0, "", "", 0)
+		require.NoError(t, err)
+
+		_, err = Transform(thin, transform)
+		require.ErrorIs(t, err, file.ErrTransformInvalid)
+	})
+}
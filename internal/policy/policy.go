@@ -0,0 +1,136 @@
+// Package policy decides which files may be uploaded by their content type
+// sniffed from magic bytes.
+package policy
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/h2non/filetype"
+	"github.com/h2non/filetype/types"
+
+	"file-service/internal/file"
+)
+
+// ANY_TYPE allows any content under any name.
+const ANY_TYPE = "*"
+
+// DEFAULT_ALLOWED_TYPES are the common image formats.
+var DEFAULT_ALLOWED_TYPES = []string{
+	"image/jpeg",
+	"image/png",
+	"image/gif",
+	"image/webp",
+	"image/bmp",
+	"image/tiff",
+	"image/heif",
+}
+
+// extensions lists the filename extensions of content types that have more
+// than the one known to filetype.
+var extensions = map[string][]string{
+	"image/jpeg": {"jpg", "jpeg", "jpe", "jfif"},
+	"image/tiff": {"tif", "tiff"},
+	"image/heif": {"heif", "heic"},
+}
+
+// Policy allows uploads of content types matching the allowlist, with a
+// filename extension matching the content.
+type Policy struct {
+	allowed []string
+}
+
+// New creates a policy from an allowlist of MIME types, "type/*" wildcards
+// or ANY_TYPE, which also skips the extension check. An empty allowlist means DEFAULT_ALLOWED_TYPES.
+func New(allowed []string) (*Policy, error) {
+	const op = "policy.New"
+
+	if len(allowed) == 0 {
+		allowed = DEFAULT_ALLOWED_TYPES
+	}
+
+	policy := &Policy{}
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "*/*" {
+			pattern = ANY_TYPE
+		}
+		if pattern != ANY_TYPE {
+			kind, subtype, ok := strings.Cut(pattern, "/")
+			if !ok || kind == "" || subtype == "" || kind == "*" {
+				return nil, fmt.Errorf("%s: invalid content type %q", op, pattern)
+			}
+		}
+		policy.allowed = append(policy.allowed, pattern)
+	}
+
+	return policy, nil
+}
+
+func (p *Policy) CheckName(filename string) error {
+	if p.allowsAny() {
+		return nil
+	}
+
+	extension := extensionOf(filename)
+	for contentType, aliases := range extensions {
+		if slices.Contains(aliases, extension) && p.allows(contentType) {
+			return nil
+		}
+	}
+	if kind := filetype.GetType(extension); kind != filetype.Unknown && p.allows(kind.MIME.Value) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: extension %q", file.ErrContentTypeNotAllowed, extension)
+}
+
+func (p *Policy) Check(filename string, head []byte) (string, error) {
+	kind, err := filetype.Match(head)
+	if err != nil || kind == filetype.Unknown {
+		if p.allowsAny() {
+			return "", nil
+		}
+		return "", fmt.Errorf("%w: unknown content type", file.ErrContentTypeNotAllowed)
+	}
+
+	contentType := kind.MIME.Value
+	if p.allowsAny() {
+		return contentType, nil
+	}
+	if !p.allows(contentType) {
+		return "", fmt.Errorf("%w: %s", file.ErrContentTypeNotAllowed, contentType)
+	}
+	if !matchesExtension(kind, extensionOf(filename)) {
+		return "", fmt.Errorf("%w: %s content named %q", file.ErrExtensionMismatch, contentType, filename)
+	}
+
+	return contentType, nil
+}
+
+func (p *Policy) allowsAny() bool {
+	return slices.Contains(p.allowed, ANY_TYPE)
+}
+
+func (p *Policy) allows(contentType string) bool {
+	kind, _, _ := strings.Cut(contentType, "/")
+	for _, pattern := range p.allowed {
+		if pattern == ANY_TYPE || pattern == contentType || pattern == kind+"/*" {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesExtension(kind types.Type, extension string) bool {
+	if aliases, ok := extensions[kind.MIME.Value]; ok {
+		return slices.Contains(aliases, extension)
+	}
+	return extension == kind.Extension
+}
+
+func extensionOf(filename string) string {
+	return strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), "."))
+}
@@ -0,0 +1,83 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"file-service/internal/file"
+)
+
+var (
+	jpegHead = []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x10, 'J', 'F', 'I', 'F'}
+	pngHead  = []byte{0x89, 'P', 'N', 'G', 0x0D, 0x0A, 0x1A, 0x0A, 0x00}
+	pdfHead  = []byte("%PDF-1.7\n")
+)
+
+func TestPolicy_Check(t *testing.T) {
+	policy, err := New(nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		filename    string
+		head        []byte
+		contentType string
+		err         error
+	}{
+		{"jpeg", "photo.jpg", jpegHead, "image/jpeg", nil},
+		{"jpeg alias", "photo.JPEG", jpegHead, "image/jpeg", nil},
+		{"png", "image.png", pngHead, "image/png", nil},
+		{"extension mismatch", "photo.png", jpegHead, "", file.ErrExtensionMismatch},
+		{"no extension", "photo", jpegHead, "", file.ErrExtensionMismatch},
+		{"not allowed", "document.pdf", pdfHead, "", file.ErrContentTypeNotAllowed},
+		{"unknown", "notes.jpg", []byte("plain text"), "", file.ErrContentTypeNotAllowed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			contentType, err := policy.Check(test.filename, test.head)
+			require.ErrorIs(t, err, test.err)
+			require.Equal(t, test.contentType, contentType)
+		})
+	}
+}
+
+func TestPolicy_CheckName(t *testing.T) {
+	policy, err := New([]string{"image/png", "application/*"})
+	require.NoError(t, err)
+
+	require.NoError(t, policy.CheckName("image.PNG"))
+	require.NoError(t, policy.CheckName("document.pdf"))
+	require.ErrorIs(t, policy.CheckName("photo.jpg"), file.ErrContentTypeNotAllowed)
+	require.ErrorIs(t, policy.CheckName("photo"), file.ErrContentTypeNotAllowed)
+}
+
+func TestPolicy_Wildcards(t *testing.T) {
+	images, err := New([]string{"image/*"})
+	require.NoError(t, err)
+
+	contentType, err := images.Check("image.png", pngHead)
+	require.NoError(t, err)
+	require.Equal(t, "image/png", contentType)
+	_, err = images.Check("document.pdf", pdfHead)
+	require.ErrorIs(t, err, file.ErrContentTypeNotAllowed)
+
+	anything, err := New([]string{ANY_TYPE})
+	require.NoError(t, err)
+
+	contentType, err = anything.Check("notes.txt", []byte("plain text"))
+	require.NoError(t, err)
+	require.Empty(t, contentType)
+	contentType, err = anything.Check("photo.png", jpegHead)
+	require.NoError(t, err)
+	require.Equal(t, "image/jpeg", contentType)
+	require.NoError(t, anything.CheckName("notes.txt"))
+}
+
+func TestNew_Invalid(t *testing.T) {
+	for _, allowed := range []string{"image", "*/png", "image/", "/png"} {
+		_, err := New([]string{allowed})
+		require.Error(t, err, allowed)
+	}
+}
@@ -0,0 +1,294 @@
+package ratelimit
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DEFAULT_TIER is the only tier of DefaultConfig.
+	DEFAULT_TIER = "default"
+
+	// TRANSFER_GROUP is the group of uploads and downloads.
+	TRANSFER_GROUP = "transfer"
+	// LISTING_GROUP is the group of file listings.
+	LISTING_GROUP = "listing"
+)
+
+// Config describes the limits of clients, it is usually loaded from a YAML
+// file, see LoadConfig.
+type Config struct {
+	// DefaultTier is the tier of clients missing from Clients.
+	DefaultTier string `yaml:"default_tier"`
+	// Tiers are the limits by tier name.
+	Tiers map[string]Tier `yaml:"tiers"`
+	// Clients are the tier names by client identity, see ClientIdentifier.
+	Clients map[string]string `yaml:"clients"`
+	// Identity is the chain of strategies identifying clients, the first one
+	// finding an identity is used. Empty means the client-id metadata.
+	Identity []IdentityConfig `yaml:"identity"`
+}
+
+// IdentityConfig selects a ClientIdentifier.
+type IdentityConfig struct {
+	// Strategy is one of the IDENTITY_ constants.
+	Strategy string `yaml:"strategy"`
+	// Key is the metadata key of the metadata strategy, client-id by
+	// default.
+	Key string `yaml:"key"`
+	// IPv4Prefix and IPv6Prefix are the subnet sizes of the subnet strategy,
+	// 24 and 64 bits by default.
+	IPv4Prefix int `yaml:"ipv4_prefix"`
+	IPv6Prefix int `yaml:"ipv6_prefix"`
+	// TrustedProxies are the CIDRs of the proxies of the forwarded_for
+	// strategy.
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+// Tier is a named set of limits shared by clients.
+type Tier struct {
+	// Groups are the numbers of concurrent requests of a client by group
+	// name, the requests of all methods of a group are counted together. A
+	// method limited alone has a group of its own.
+	Groups map[string]int `yaml:"groups"`
+	// Methods are the limits by method name. Every method of the service must
+	// have one, so a new method can't be left unlimited by mistake.
+	Methods map[string]MethodLimit `yaml:"methods"`
+}
+
+// MethodLimit is the limit of a client for a method, zero values mean no
+// limit.
+type MethodLimit struct {
+	// Group is the group of concurrent requests the method counts against.
+	Group string `yaml:"group"`
+	// Rate is the token bucket of the method: Rate requests per second on
+	// average and up to Burst requests at once.
+	Rate  rate.Limit `yaml:"rate"`
+	Burst int        `yaml:"burst"`
+	// BytesPerSecond limits the throughput of the messages of a call in both
+	// directions, calls over the limit are slowed down rather than rejected.
+	// Unary calls are delayed after their request is received, which shapes
+	// their latency only, see RequestLimiter.UnaryInterceptor.
+	BytesPerSecond int `yaml:"bytes_per_second"`
+}
+
+// DefaultConfig returns the limits used without a configuration file, a
+// single tier for all clients.
+func DefaultConfig() *Config {
+	return &Config{
+		DefaultTier: DEFAULT_TIER,
+		Tiers: map[string]Tier{
+			DEFAULT_TIER: {
+				Groups: map[string]int{
+					TRANSFER_GROUP: MAX_CONCURRENT_TRANSFERS,
+					LISTING_GROUP:  MAX_CONCURRENT_LISTINGS,
+				},
+				Methods: map[string]MethodLimit{
+					"UploadFile":          {Group: TRANSFER_GROUP, Rate: 10, Burst: 20},
+					"UploadFileStream":    {Group: TRANSFER_GROUP, Rate: 10, Burst: 20},
+					"UploadByReference":   {Group: TRANSFER_GROUP, Rate: 10, Burst: 20},
+					"DownloadFile":        {Group: TRANSFER_GROUP, Rate: 20, Burst: 40},
+					"DownloadFileStream":  {Group: TRANSFER_GROUP, Rate: 20, Burst: 40},
+					"DownloadTransformed": {Group: TRANSFER_GROUP, Rate: 20, Burst: 40},
+					"GetThumbnail":        {Group: TRANSFER_GROUP, Rate: 50, Burst: 100},
+					"AppendChunk":         {Group: TRANSFER_GROUP},
+					"CommitUpload":        {Group: TRANSFER_GROUP},
+					"ViewFiles":           {Group: LISTING_GROUP, Rate: 20, Burst: 50},
+					"CheckContent":        {Rate: 50, Burst: 100},
+					"GetFileMetadata":     {Rate: 50, Burst: 100},
+					"DeleteFile":          {Rate: 20, Burst: 40},
+					"StartUpload":         {Rate: 10, Burst: 20},
+					"GetUploadStatus":     {},
+				},
+			},
+		},
+	}
+}
+
+// LoadConfig reads the configuration from a YAML file. Unknown fields are
+// errors, the limits themselves are validated by NewRequestLimiter.
+func LoadConfig(path string) (*Config, error) {
+	const op = "ratelimit.LoadConfig"
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	config, err := parseConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s: %w", op, path, err)
+	}
+	return config, nil
+}
+
+func parseConfig(data []byte) (*Config, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	var config Config
+	if err := decoder.Decode(&config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// limits is a validated configuration keyed by full method names.
+type limits struct {
+	identifier  ClientIdentifier
+	defaultTier *tier
+	clients     map[string]*tier // client identity -> tier
+	// limited are the methods limited in any tier, the others don't even
+	// require a client id.
+	limited map[string]bool
+}
+
+type tier struct {
+	groups  map[string]int
+	methods map[string]MethodLimit // full method name -> limit
+}
+
+func (l *limits) tier(client string) *tier {
+	if tier, ok := l.clients[client]; ok {
+		return tier
+	}
+	return l.defaultTier
+}
+
+// newLimits validates the configuration against the methods of the service.
+func newLimits(service *grpc.ServiceDesc, config *Config) (*limits, error) {
+	methods := make(map[string]bool, len(service.Methods)+len(service.Streams))
+	for _, method := range service.Methods {
+		methods[method.MethodName] = true
+	}
+	for _, stream := range service.Streams {
+		methods[stream.StreamName] = true
+	}
+
+	result := &limits{
+		identifier: IdentifierChain{MetadataIdentifier{Key: CLIENT_ID_KEY}},
+		clients:    make(map[string]*tier, len(config.Clients)),
+		limited:    make(map[string]bool),
+	}
+	if len(config.Identity) > 0 {
+		chain := make(IdentifierChain, 0, len(config.Identity))
+		for i, identity := range config.Identity {
+			identifier, err := newIdentifier(identity)
+			if err != nil {
+				return nil, fmt.Errorf("identity %d: %w", i+1, err)
+			}
+			chain = append(chain, identifier)
+		}
+		result.identifier = chain
+	}
+	tiers := make(map[string]*tier, len(config.Tiers))
+	for name, tierConfig := range config.Tiers {
+		tier, err := newTier(service, methods, name, tierConfig)
+		if err != nil {
+			return nil, err
+		}
+		tiers[name] = tier
+		for method, limit := range tier.methods {
+			if limit != (MethodLimit{}) {
+				result.limited[method] = true
+			}
+		}
+	}
+
+	if config.DefaultTier == "" {
+		return nil, fmt.Errorf("no default tier")
+	}
+	var ok bool
+	if result.defaultTier, ok = tiers[config.DefaultTier]; !ok {
+		return nil, fmt.Errorf("unknown default tier %s", config.DefaultTier)
+	}
+	for client, name := range config.Clients {
+		if client == "" {
+			return nil, fmt.Errorf("empty client of tier %s", name)
+		}
+		if result.clients[client], ok = tiers[name]; !ok {
+			return nil, fmt.Errorf("unknown tier %s of client %s", name, client)
+		}
+	}
+	return result, nil
+}
+
+func newTier(service *grpc.ServiceDesc, methods map[string]bool, name string, config Tier) (*tier, error) {
+	for group, requests := range config.Groups {
+		if requests <= 0 {
+			return nil, fmt.Errorf("tier %s: invalid limit %d of group %s", name, requests, group)
+		}
+	}
+	for method := range config.Methods {
+		if !methods[method] {
+			return nil, fmt.Errorf("tier %s: limit for unknown method %s of %s", name, method, service.ServiceName)
+		}
+	}
+
+	result := &tier{
+		groups:  config.Groups,
+		methods: make(map[string]MethodLimit, len(methods)),
+	}
+	for method := range methods {
+		limit, ok := config.Methods[method]
+		if !ok {
+			return nil, fmt.Errorf("tier %s: no limit for method %s of %s", name, method, service.ServiceName)
+		}
+		if _, ok := config.Groups[limit.Group]; limit.Group != "" && !ok {
+			return nil, fmt.Errorf("tier %s: unknown group %s of method %s", name, limit.Group, method)
+		}
+		if limit.Rate < 0 || limit.Burst < 0 || (limit.Rate > 0) != (limit.Burst > 0) {
+			return nil, fmt.Errorf("tier %s: invalid rate %v and burst %d of method %s", name, limit.Rate, limit.Burst, method)
+		}
+		if limit.BytesPerSecond < 0 {
+			return nil, fmt.Errorf("tier %s: invalid throughput %d of method %s", name, limit.BytesPerSecond, method)
+		}
+		result.methods["/"+service.ServiceName+"/"+method] = limit
+	}
+	return result, nil
+}
+
+// RunConfigReload applies the configuration file again whenever its content
+// changes. Invalid configurations are logged and the limits stay as they are.
+func (limiter *RequestLimiter) RunConfigReload(ctx context.Context, path string, interval time.Duration, logger *slog.Logger) {
+	// The file is applied on the first tick too, it may have changed since
+	// the limiter was created.
+	var loaded []byte
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			data, err := os.ReadFile(path)
+			if err != nil {
+				logger.Error("failed to read rate limits", "path", path, "error", err)
+				continue
+			}
+			if bytes.Equal(data, loaded) {
+				continue
+			}
+			// A broken file is reported once, not on every tick.
+			loaded = data
+
+			config, err := parseConfig(data)
+			if err == nil {
+				err = limiter.Reload(config)
+			}
+			if err != nil {
+				logger.Error("failed to reload rate limits", "path", path, "error", err)
+				continue
+			}
+			logger.Info("reloaded rate limits", "path", path)
+		}
+	}
+}
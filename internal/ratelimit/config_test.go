@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+
+	"file-service/internal/api"
+)
+
+func TestLoadConfig(t *testing.T) {
+	t.Run("Example", func(t *testing.T) {
+		config, err := LoadConfig("../../configs/rate_limits.yaml")
+		require.NoError(t, err)
+		require.Equal(t, "free", config.DefaultTier)
+		require.Equal(t, MethodLimit{Group: TRANSFER_GROUP, Rate: 1, Burst: 5, BytesPerSecond: 1 << 20}, config.Tiers["free"].Methods["UploadFile"])
+		require.Equal(t, []IdentityConfig{{Strategy: IDENTITY_METADATA, Key: CLIENT_ID_KEY}}, config.Identity)
+
+		limiter, err := NewRequestLimiter(&api.FileService_ServiceDesc, config)
+		require.NoError(t, err)
+		// The clients are identified the way the file service can.
+		for client, tier := range config.Clients {
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(CLIENT_ID_KEY, strings.TrimPrefix(client, CLIENT_ID_KEY+":")))
+			identity, ok := limiter.limits.Load().identifier.Identify(ctx)
+			require.True(t, ok)
+			require.Equal(t, client, identity)
+			require.Equal(t, config.Tiers[tier].Groups, limiter.limits.Load().tier(identity).groups)
+		}
+	})
+
+	t.Run("Unknown field", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "limits.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("default_tier: free\ntier: {}\n"), 0o644))
+
+		_, err := LoadConfig(path)
+		require.ErrorContains(t, err, "field tier not found")
+	})
+
+	t.Run("Missing file", func(t *testing.T) {
+		_, err := LoadConfig(filepath.Join(t.TempDir(), "limits.yaml"))
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestRequestLimiter_RunConfigReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "limits.yaml")
+	write := func(config string) {
+		require.NoError(t, os.WriteFile(path, []byte(config), 0o644))
+	}
+	transferLimit := func(limiter *RequestLimiter, clientId string) int {
+		return limiter.limits.Load().tier(clientId).groups[TRANSFER_GROUP]
+	}
+
+	example, err := os.ReadFile("../../configs/rate_limits.yaml")
+	require.NoError(t, err)
+	write(string(example))
+	config, err := LoadConfig(path)
+	require.NoError(t, err)
+	limiter, err := NewRequestLimiter(&api.FileService_ServiceDesc, config)
+	require.NoError(t, err)
+	require.Equal(t, 2, transferLimit(limiter, "client-id:new-partner"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		limiter.RunConfigReload(ctx, path, 10*time.Millisecond, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	write(string(example) + "  client-id:new-partner: partner\n")
+	require.Eventually(t, func() bool { return transferLimit(limiter, "client-id:new-partner") == 10 }, time.Second, 10*time.Millisecond)
+
+	// Invalid configurations are ignored.
+	write(string(example) + "  client-id:other-partner: unknown\n")
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, 10, transferLimit(limiter, "client-id:new-partner"))
+	require.Equal(t, 2, transferLimit(limiter, "client-id:other-partner"))
+}
@@ -0,0 +1,274 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// Identity strategies of IdentityConfig.
+const (
+	IDENTITY_METADATA      = "metadata"
+	IDENTITY_PEER_IP       = "peer_ip"
+	IDENTITY_SUBNET        = "subnet"
+	IDENTITY_FORWARDED_FOR = "forwarded_for"
+	IDENTITY_MTLS          = "mtls"
+	IDENTITY_PRINCIPAL     = "principal"
+
+	// CLIENT_ID_KEY is the metadata key of the default identity.
+	CLIENT_ID_KEY = "client-id"
+)
+
+// ClientIdentifier identifies the client of a call, the identity selects the
+// tier of the client and the counters and buckets of its requests.
+//
+// Identities start with their kind, e.g. "client-id:gallery-web" or
+// "ip:192.0.2.1", so a client can't pass for a client of another kind.
+type ClientIdentifier interface {
+	// Identify returns the identity of the client, false if the call has no
+	// identity of this kind.
+	Identify(ctx context.Context) (string, bool)
+	// String names the identity in errors of unidentified calls.
+	String() string
+}
+
+// MetadataIdentifier identifies clients by a metadata value chosen by the
+// client, it can be spoofed. The key can't be the kind of another identity,
+// e.g. "ip".
+type MetadataIdentifier struct {
+	Key string
+}
+
+func (identifier MetadataIdentifier) Identify(ctx context.Context) (string, bool) {
+	values := metadata.ValueFromIncomingContext(ctx, identifier.Key)
+	if len(values) == 0 || values[0] == "" {
+		return "", false
+	}
+	return identifier.Key + ":" + values[0], true
+}
+
+func (identifier MetadataIdentifier) String() string {
+	return identifier.Key
+}
+
+// PeerIPIdentifier identifies clients by the IP address of the connection.
+type PeerIPIdentifier struct{}
+
+func (PeerIPIdentifier) Identify(ctx context.Context) (string, bool) {
+	addr, ok := peerAddr(ctx)
+	if !ok {
+		return "", false
+	}
+	return "ip:" + addr.String(), true
+}
+
+func (PeerIPIdentifier) String() string {
+	return "client address"
+}
+
+// SubnetIdentifier identifies clients by the subnet of the IP address of the
+// connection, so all addresses of a network share the limits.
+type SubnetIdentifier struct {
+	IPv4Bits int
+	IPv6Bits int
+}
+
+func (identifier SubnetIdentifier) Identify(ctx context.Context) (string, bool) {
+	addr, ok := peerAddr(ctx)
+	if !ok {
+		return "", false
+	}
+	bits := identifier.IPv6Bits
+	if addr.Is4() {
+		bits = identifier.IPv4Bits
+	}
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return "", false
+	}
+	return "subnet:" + prefix.String(), true
+}
+
+func (identifier SubnetIdentifier) String() string {
+	return "client address"
+}
+
+// ForwardedForIdentifier identifies clients behind proxies by the address in
+// the X-Forwarded-For metadata. Only addresses added by the trusted proxies
+// are used, from the connection back to the first untrusted address, the
+// rest of the list is up to the client.
+type ForwardedForIdentifier struct {
+	TrustedProxies []netip.Prefix
+}
+
+func (identifier ForwardedForIdentifier) Identify(ctx context.Context) (string, bool) {
+	addr, ok := peerAddr(ctx)
+	if !ok {
+		return "", false
+	}
+
+	var hops []string
+	for _, value := range metadata.ValueFromIncomingContext(ctx, "x-forwarded-for") {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0 && identifier.trusted(addr); i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		addr = hop.Unmap()
+	}
+	return "ip:" + addr.String(), true
+}
+
+func (identifier ForwardedForIdentifier) trusted(addr netip.Addr) bool {
+	for _, proxy := range identifier.TrustedProxies {
+		if proxy.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (identifier ForwardedForIdentifier) String() string {
+	return "client address"
+}
+
+// MTLSIdentifier identifies clients by the subject of their verified TLS
+// certificate, for servers verifying client certificates.
+type MTLSIdentifier struct{}
+
+func (MTLSIdentifier) Identify(ctx context.Context) (string, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return "", false
+	}
+	return "mtls:" + info.State.VerifiedChains[0][0].Subject.String(), true
+}
+
+func (MTLSIdentifier) String() string {
+	return "client certificate"
+}
+
+type principalKey struct{}
+
+// ContextWithPrincipal returns a context of a call authenticated as the
+// principal, for interceptors authenticating calls before the limiter.
+func ContextWithPrincipal(ctx context.Context, principal string) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalIdentifier identifies clients by the principal authenticated
+// before the limiter, see ContextWithPrincipal.
+type PrincipalIdentifier struct{}
+
+func (PrincipalIdentifier) Identify(ctx context.Context) (string, bool) {
+	principal, _ := ctx.Value(principalKey{}).(string)
+	if principal == "" {
+		return "", false
+	}
+	return "principal:" + principal, true
+}
+
+func (PrincipalIdentifier) String() string {
+	return "authentication"
+}
+
+// IdentifierChain identifies clients by the first identifier that finds an
+// identity.
+type IdentifierChain []ClientIdentifier
+
+func (chain IdentifierChain) Identify(ctx context.Context) (string, bool) {
+	for _, identifier := range chain {
+		if identity, ok := identifier.Identify(ctx); ok {
+			return identity, true
+		}
+	}
+	return "", false
+}
+
+func (chain IdentifierChain) String() string {
+	var names []string
+	for _, identifier := range chain {
+		if name := identifier.String(); !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, " or ")
+}
+
+// peerAddr returns the IP address of the connection of the call.
+func peerAddr(ctx context.Context) (netip.Addr, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return netip.Addr{}, false
+	}
+	addrPort, err := netip.ParseAddrPort(p.Addr.String())
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addrPort.Addr().Unmap(), true
+}
+
+// identityKinds start the identities of the identifiers other than
+// MetadataIdentifier, its key can't be one of them.
+var identityKinds = []string{"ip", "subnet", "mtls", "principal"}
+
+// newIdentifier creates the identifier of the strategy.
+func newIdentifier(config IdentityConfig) (ClientIdentifier, error) {
+	switch config.Strategy {
+	case IDENTITY_METADATA:
+		key := strings.ToLower(config.Key)
+		if key == "" {
+			key = CLIENT_ID_KEY
+		}
+		// Otherwise a client could pass for a client of that kind.
+		if slices.Contains(identityKinds, key) {
+			return nil, fmt.Errorf("metadata key %q is a kind of identity", key)
+		}
+		return MetadataIdentifier{Key: key}, nil
+	case IDENTITY_PEER_IP:
+		return PeerIPIdentifier{}, nil
+	case IDENTITY_SUBNET:
+		identifier := SubnetIdentifier{IPv4Bits: config.IPv4Prefix, IPv6Bits: config.IPv6Prefix}
+		if identifier.IPv4Bits == 0 {
+			identifier.IPv4Bits = 24
+		}
+		if identifier.IPv6Bits == 0 {
+			identifier.IPv6Bits = 64
+		}
+		if identifier.IPv4Bits < 0 || identifier.IPv4Bits > 32 || identifier.IPv6Bits < 0 || identifier.IPv6Bits > 128 {
+			return nil, fmt.Errorf("invalid subnet prefixes /%d and /%d", identifier.IPv4Bits, identifier.IPv6Bits)
+		}
+		return identifier, nil
+	case IDENTITY_FORWARDED_FOR:
+		if len(config.TrustedProxies) == 0 {
+			return nil, fmt.Errorf("no trusted proxies")
+		}
+		identifier := ForwardedForIdentifier{}
+		for _, proxy := range config.TrustedProxies {
+			prefix, err := netip.ParsePrefix(proxy)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy: %w", err)
+			}
+			identifier.TrustedProxies = append(identifier.TrustedProxies, prefix.Masked())
+		}
+		return identifier, nil
+	case IDENTITY_MTLS:
+		return MTLSIdentifier{}, nil
+	case IDENTITY_PRINCIPAL:
+		return PrincipalIdentifier{}, nil
+	default:
+		return nil, fmt.Errorf("unknown strategy %q", config.Strategy)
+	}
+}
@@ -0,0 +1,149 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"file-service/internal/api"
+)
+
+func TestClientIdentifiers(t *testing.T) {
+	call := func(addr string, pairs ...string) context.Context {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(pairs...))
+		if addr == "" {
+			return ctx
+		}
+		return peer.NewContext(ctx, &peer.Peer{Addr: net.TCPAddrFromAddrPort(netip.MustParseAddrPort(addr))})
+	}
+	certificate := &x509.Certificate{Subject: pkix.Name{CommonName: "backfill", Organization: []string{"Example"}}}
+	withCertificate := func(ctx context.Context, chains [][]*x509.Certificate) context.Context {
+		p, _ := peer.FromContext(ctx)
+		p.AuthInfo = credentials.TLSInfo{State: tls.ConnectionState{VerifiedChains: chains}}
+		return ctx
+	}
+	proxies := ForwardedForIdentifier{TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}}
+
+	tests := []struct {
+		name       string
+		identifier ClientIdentifier
+		ctx        context.Context
+		identity   string
+	}{
+		{"Metadata", MetadataIdentifier{Key: "client-id"}, call("", "client-id", "gallery"), "client-id:gallery"},
+		{"Empty metadata", MetadataIdentifier{Key: "client-id"}, call("", "client-id", ""), ""},
+		{"Peer IP", PeerIPIdentifier{}, call("192.0.2.1:5000"), "ip:192.0.2.1"},
+		{"Mapped IPv4", PeerIPIdentifier{}, call("[::ffff:192.0.2.1]:5000"), "ip:192.0.2.1"},
+		{"No peer", PeerIPIdentifier{}, call(""), ""},
+		{"Subnet", SubnetIdentifier{IPv4Bits: 24, IPv6Bits: 64}, call("192.0.2.1:5000"), "subnet:192.0.2.0/24"},
+		{"IPv6 subnet", SubnetIdentifier{IPv4Bits: 24, IPv6Bits: 64}, call("[2001:db8:1:2:3::4]:5000"), "subnet:2001:db8:1:2::/64"},
+		{"Forwarded", proxies, call("10.0.0.1:5000", "x-forwarded-for", "192.0.2.1"), "ip:192.0.2.1"},
+		{"Forwarded twice", proxies, call("10.0.0.1:5000", "x-forwarded-for", "192.0.2.1, 10.0.0.2"), "ip:192.0.2.1"},
+		{"Spoofed forwarding", proxies, call("10.0.0.1:5000", "x-forwarded-for", "10.0.0.3, 192.0.2.1"), "ip:192.0.2.1"},
+		{"Untrusted proxy", proxies, call("192.0.2.1:5000", "x-forwarded-for", "198.51.100.1"), "ip:192.0.2.1"},
+		{"Not forwarded", proxies, call("10.0.0.1:5000"), "ip:10.0.0.1"},
+		{"Client certificate", MTLSIdentifier{}, withCertificate(call("192.0.2.1:5000"), [][]*x509.Certificate{{certificate}}), "mtls:CN=backfill,O=Example"},
+		{"Unverified certificate", MTLSIdentifier{}, withCertificate(call("192.0.2.1:5000"), nil), ""},
+		{"Principal", PrincipalIdentifier{}, ContextWithPrincipal(call(""), "alice"), "principal:alice"},
+		{"No principal", PrincipalIdentifier{}, call("", "client-id", "alice"), ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			identity, ok := test.identifier.Identify(test.ctx)
+			require.Equal(t, test.identity != "", ok)
+			require.Equal(t, test.identity, identity)
+		})
+	}
+
+	t.Run("Chain", func(t *testing.T) {
+		chain := IdentifierChain{PrincipalIdentifier{}, MetadataIdentifier{Key: "client-id"}, PeerIPIdentifier{}}
+		require.Equal(t, "authentication or client-id or client address", chain.String())
+
+		identity, _ := chain.Identify(ContextWithPrincipal(call("192.0.2.1:5000", "client-id", "gallery"), "alice"))
+		require.Equal(t, "principal:alice", identity)
+		identity, _ = chain.Identify(call("192.0.2.1:5000", "client-id", "gallery"))
+		require.Equal(t, "client-id:gallery", identity)
+		identity, _ = chain.Identify(call("192.0.2.1:5000"))
+		require.Equal(t, "ip:192.0.2.1", identity)
+		_, ok := chain.Identify(call(""))
+		require.False(t, ok)
+	})
+}
+
+func TestRequestLimiter_Identity(t *testing.T) {
+	config := withoutRates(DefaultConfig())
+	config.Identity = []IdentityConfig{
+		{Strategy: IDENTITY_PRINCIPAL},
+		{Strategy: IDENTITY_SUBNET, IPv4Prefix: 16},
+	}
+	limiter, err := NewRequestLimiter(&api.FileService_ServiceDesc, config)
+	require.NoError(t, err)
+
+	block := make(chan struct{})
+	defer close(block)
+	started := make(chan struct{})
+	call := func(ctx context.Context) error {
+		_, err := limiter.UnaryInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/file.FileService/UploadFile"}, func(ctx context.Context, req any) (any, error) {
+			started <- struct{}{}
+			<-block
+			return "success", nil
+		})
+		return err
+	}
+	from := func(addr string) context.Context {
+		// The client-id metadata is ignored.
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("client-id", addr))
+		return peer.NewContext(ctx, &peer.Peer{Addr: net.TCPAddrFromAddrPort(netip.MustParseAddrPort(addr))})
+	}
+
+	// Addresses of a subnet share the limit.
+	for i := range MAX_CONCURRENT_TRANSFERS {
+		go call(from(netip.AddrPortFrom(netip.AddrFrom4([4]byte{192, 0, 2, byte(i)}), 5000).String()))
+		<-started
+	}
+	require.Equal(t, codes.ResourceExhausted, status.Code(call(from("192.0.200.1:5000"))))
+
+	go call(from("198.51.100.1:5000"))
+	<-started
+	go call(ContextWithPrincipal(from("192.0.2.1:5000"), "alice"))
+	<-started
+
+	err = call(context.Background())
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	require.Equal(t, "authentication or client address required", status.Convert(err).Message())
+
+	t.Run("Invalid", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			identity IdentityConfig
+			err      string
+		}{
+			{"Unknown strategy", IdentityConfig{Strategy: "cookie"}, `identity 1: unknown strategy "cookie"`},
+			{"Subnet", IdentityConfig{Strategy: IDENTITY_SUBNET, IPv4Prefix: 33}, "invalid subnet prefixes"},
+			{"No proxies", IdentityConfig{Strategy: IDENTITY_FORWARDED_FOR}, "no trusted proxies"},
+			{"Proxy", IdentityConfig{Strategy: IDENTITY_FORWARDED_FOR, TrustedProxies: []string{"10.0.0.1"}}, "invalid trusted proxy"},
+			{"Metadata key of a kind", IdentityConfig{Strategy: IDENTITY_METADATA, Key: "IP"}, `metadata key "ip" is a kind of identity`},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				config := DefaultConfig()
+				config.Identity = []IdentityConfig{test.identity}
+				_, err := NewRequestLimiter(&api.FileService_ServiceDesc, config)
+				require.ErrorContains(t, err, test.err)
+			})
+		}
+	})
+}
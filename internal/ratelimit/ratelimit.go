@@ -2,85 +2,354 @@ package ratelimit
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"golang.org/x/time/rate"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 const (
-	MAX_CONCURRENT_UPLOADS   = 10
-	MAX_CONCURRENT_DOWNLOADS = 100
+	// MAX_CONCURRENT_TRANSFERS limits uploads and downloads of a client by
+	// default, see DefaultConfig.
+	MAX_CONCURRENT_TRANSFERS = 10
+	// MAX_CONCURRENT_LISTINGS limits file listings of a client by default.
+	MAX_CONCURRENT_LISTINGS = 100
+
+	// BUCKET_SWEEP_INTERVAL is the period of removing the token buckets of
+	// clients that stopped calling.
+	BUCKET_SWEEP_INTERVAL = time.Minute
 )
 
 type RequestLimiter struct {
-	clients map[string]map[string]int // client-id -> method -> active requests
-	limits  map[string]int
+	service *grpc.ServiceDesc
+	limits  atomic.Pointer[limits]
+	clients map[string]map[string]int // client -> group -> active requests
 	mutex   sync.Mutex
+
+	buckets    map[string]map[string]*rate.Limiter // client -> full method name -> bucket of requests
+	throughput map[string]map[string]*rate.Limiter // client -> full method name -> bucket of bytes
+	throttled  map[*rate.Limiter]int               // bucket of bytes -> calls in progress
+	lastSweep  time.Time
+	now        func() time.Time
+
+	slots        SlotStore
+	leased       map[string]bool // ids of the shared slots of requests in progress
+	slotsRetryAt time.Time
+	logger       *slog.Logger
+}
+
+// NewRequestLimiter limits the methods of the service as configured. Tiers
+// without a limit for a method of the service and limits of unknown methods
+// are errors, so a new method can't be left unlimited by mistake.
+func NewRequestLimiter(service *grpc.ServiceDesc, config *Config) (*RequestLimiter, error) {
+	const op = "ratelimit.NewRequestLimiter"
+
+	limits, err := newLimits(service, config)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	limiter := &RequestLimiter{
+		service:    service,
+		clients:    make(map[string]map[string]int),
+		buckets:    make(map[string]map[string]*rate.Limiter),
+		throughput: make(map[string]map[string]*rate.Limiter),
+		throttled:  make(map[*rate.Limiter]int),
+		now:        time.Now,
+	}
+	limiter.limits.Store(limits)
+	return limiter, nil
 }
 
-func NewRequestLimiter() *RequestLimiter {
-	return &RequestLimiter{
-		clients: make(map[string]map[string]int),
-		limits: map[string]int{
-			"/file.FileService/Upload":   MAX_CONCURRENT_UPLOADS,
-			"/file.FileService/Download": MAX_CONCURRENT_DOWNLOADS,
-		},
+// Reload replaces the configuration, validated like in NewRequestLimiter.
+// Requests in progress keep counting against the groups they started in,
+// token buckets keep their tokens under the new rates.
+func (limiter *RequestLimiter) Reload(config *Config) error {
+	const op = "ratelimit.RequestLimiter.Reload"
+
+	limits, err := newLimits(limiter.service, config)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
+
+	limiter.limits.Store(limits)
+	return nil
 }
 
+// UnaryInterceptor limits unary calls. Their request is received before the
+// throughput limit applies, so the call is delayed as if its messages were
+// transferred at the limit: it shapes the latency of the call rather than
+// the traffic, which slows down clients waiting for responses, e.g. of
+// AppendChunk.
 func (limiter *RequestLimiter) UnaryInterceptor(
 	ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
 ) (any, error) {
-	method := info.FullMethod
-	limit, exists := limiter.limits[method]
-	if !exists {
-		return handler(ctx, req)
-	}
-
-	// IP strategy
-	//
-	// p, ok := peer.FromContext(ctx)
-	// if !ok {
-	// 	return nil, status.Error(codes.Internal, "failed to get client IP")
-	// }
-	// clientIP := p.Addr.String()
-
-	// Client ID strategy
-	meta, ok := metadata.FromIncomingContext(ctx)
+	release, throughput, err := limiter.acquire(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if err := throttle(ctx, throughput, req); err != nil {
+		return nil, err
+	}
+	resp, err := handler(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := throttle(ctx, throughput, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// StreamInterceptor limits streaming calls like UnaryInterceptor, a call
+// counts as one request until the stream ends.
+func (limiter *RequestLimiter) StreamInterceptor(
+	srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+) error {
+	release, throughput, err := limiter.acquire(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if throughput != nil {
+		stream = &throttledStream{ServerStream: stream, throughput: throughput}
+	}
+	return handler(srv, stream)
+}
+
+// acquire counts a request of the client to the method, the returned
+// function ends it. The returned bucket limits the throughput of the call,
+// nil means no limit.
+func (limiter *RequestLimiter) acquire(ctx context.Context, method string) (func(), *rate.Limiter, error) {
+	limits := limiter.limits.Load()
+	if !limits.limited[method] {
+		return func() {}, nil, nil
+	}
+
+	client, ok := limits.identifier.Identify(ctx)
 	if !ok {
-		return nil, status.Error(codes.Internal, "failed to get metadata from context")
+		return nil, nil, status.Errorf(codes.Unauthenticated, "%s required", limits.identifier)
+	}
+
+	tier := limits.tier(client)
+	limit := tier.methods[method]
+	release, throughput, err := limiter.acquireLocal(client, method, limit, tier.groups[limit.Group])
+	if err != nil {
+		return nil, nil, err
 	}
 
-	values := meta.Get("client-id")
-	if len(values) == 0 {
-		return nil, status.Error(codes.Unauthenticated, "client-id required")
+	// Requests over the local limit are over the shared one too, so the
+	// store is only asked for the others.
+	if limit.Group != "" && limiter.slots != nil {
+		releaseSlot, err := limiter.acquireSlot(ctx, client, limit.Group, tier.groups[limit.Group])
+		if err != nil {
+			release()
+			return nil, nil, err
+		}
+		releaseLocal := release
+		release = func() {
+			releaseSlot()
+			releaseLocal()
+		}
 	}
-	clientId := values[0]
-	if clientId == "" {
-		return nil, status.Error(codes.Unauthenticated, "client-id required")
+
+	// The token is taken last, so requests refused for concurrency don't
+	// spend it.
+	if err := limiter.takeToken(client, method, limit); err != nil {
+		release()
+		return nil, nil, err
 	}
+	return release, throughput, nil
+}
 
+// acquireLocal counts the request in this replica, requests is the limit of
+// the group of the method.
+func (limiter *RequestLimiter) acquireLocal(client, method string, limit MethodLimit, requests int) (func(), *rate.Limiter, error) {
 	limiter.mutex.Lock()
-	activeRequests, ok := limiter.clients[clientId]
-	if !ok {
-		activeRequests = make(map[string]int)
-		limiter.clients[clientId] = activeRequests
+	defer limiter.mutex.Unlock()
+
+	now := limiter.now()
+	if now.Sub(limiter.lastSweep) >= BUCKET_SWEEP_INTERVAL {
+		limiter.sweep(now)
 	}
-	if activeRequests[method] >= limit {
-		limiter.mutex.Unlock()
-		return nil, status.Error(codes.ResourceExhausted, "too many concurrent requests")
+	var throughput *rate.Limiter
+	if limit.BytesPerSecond > 0 {
+		// The burst of a second lets messages of up to that size through at
+		// once, larger ones are split by throttle.
+		throughput = bucket(limiter.throughput, client, method, rate.Limit(limit.BytesPerSecond), limit.BytesPerSecond, now)
 	}
-	limiter.clients[clientId][method]++
-	limiter.mutex.Unlock()
 
-	resp, err := handler(ctx, req)
+	var activeRequests map[string]int
+	if limit.Group != "" {
+		var ok bool
+		activeRequests, ok = limiter.clients[client]
+		if !ok {
+			activeRequests = make(map[string]int)
+			limiter.clients[client] = activeRequests
+		}
+		if activeRequests[limit.Group] >= requests {
+			return nil, nil, status.Error(codes.ResourceExhausted, "too many concurrent requests")
+		}
+		activeRequests[limit.Group]++
+	}
+	// Buckets of calls in progress aren't swept, so the calls of a client
+	// keep sharing them.
+	if throughput != nil {
+		limiter.throttled[throughput]++
+	}
+
+	return func() {
+		limiter.mutex.Lock()
+		defer limiter.mutex.Unlock()
+
+		if throughput != nil {
+			limiter.throttled[throughput]--
+			if limiter.throttled[throughput] == 0 {
+				delete(limiter.throttled, throughput)
+			}
+		}
+		if limit.Group == "" {
+			return
+		}
+		activeRequests[limit.Group]--
+		if activeRequests[limit.Group] == 0 {
+			delete(activeRequests, limit.Group)
+		}
+		if len(activeRequests) == 0 {
+			delete(limiter.clients, client)
+		}
+	}, throughput, nil
+}
+
+// takeToken takes a token of the client from the bucket of requests to the
+// method.
+func (limiter *RequestLimiter) takeToken(client, method string, limit MethodLimit) error {
+	if limit.Rate <= 0 {
+		return nil
+	}
 
 	limiter.mutex.Lock()
-	limiter.clients[clientId][method]--
-	limiter.mutex.Unlock()
+	defer limiter.mutex.Unlock()
+
+	now := limiter.now()
+	return take(bucket(limiter.buckets, client, method, limit.Rate, limit.Burst, now), now)
+}
+
+// bucket returns the bucket of the client for the method, a reloaded limit
+// is applied to the tokens left in it.
+func bucket(buckets map[string]map[string]*rate.Limiter, client, method string, limit rate.Limit, burst int, now time.Time) *rate.Limiter {
+	clientBuckets, ok := buckets[client]
+	if !ok {
+		clientBuckets = make(map[string]*rate.Limiter)
+		buckets[client] = clientBuckets
+	}
+	bucket, ok := clientBuckets[method]
+	if !ok {
+		bucket = rate.NewLimiter(limit, burst)
+		clientBuckets[method] = bucket
+		return bucket
+	}
+	if bucket.Limit() != limit {
+		bucket.SetLimitAt(now, limit)
+	}
+	if bucket.Burst() != burst {
+		bucket.SetBurstAt(now, burst)
+	}
+	return bucket
+}
 
-	return resp, err
+// take takes a token from the bucket. Without one the error tells the client
+// when the next token is available.
+func take(bucket *rate.Limiter, now time.Time) error {
+	reservation := bucket.ReserveN(now, 1)
+	delay := reservation.DelayFrom(now)
+	if delay == 0 {
+		return nil
+	}
+	reservation.CancelAt(now)
+
+	status, err := status.New(codes.ResourceExhausted, "too many requests").
+		WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(delay)})
+	if err != nil {
+		return fmt.Errorf("unexpected error attaching error detail: %w", err)
+	}
+	return status.Err()
+}
+
+// sweep removes full buckets, which are the same as new ones, unless calls in
+// progress throttle with them.
+func (limiter *RequestLimiter) sweep(now time.Time) {
+	for _, buckets := range []map[string]map[string]*rate.Limiter{limiter.buckets, limiter.throughput} {
+		for client, clientBuckets := range buckets {
+			for method, bucket := range clientBuckets {
+				if bucket.TokensAt(now) >= float64(bucket.Burst()) && limiter.throttled[bucket] == 0 {
+					delete(clientBuckets, method)
+				}
+			}
+			if len(clientBuckets) == 0 {
+				delete(buckets, client)
+			}
+		}
+	}
+	limiter.lastSweep = now
+}
+
+// throttle waits until the throughput allows the size of the message.
+func throttle(ctx context.Context, throughput *rate.Limiter, message any) error {
+	if throughput == nil {
+		return nil
+	}
+	protoMessage, ok := message.(proto.Message)
+	if !ok {
+		return nil
+	}
+
+	for size := proto.Size(protoMessage); size > 0; {
+		n := min(size, throughput.Burst())
+		if err := throughput.WaitN(ctx, n); err != nil {
+			if ctx.Err() != nil {
+				return status.FromContextError(ctx.Err()).Err()
+			}
+			// A reload shrank the burst since it was read.
+			if n > throughput.Burst() {
+				continue
+			}
+			return status.Error(codes.DeadlineExceeded, "transfer can't finish before the deadline at the throughput limit")
+		}
+		size -= n
+	}
+	return nil
+}
+
+// throttledStream limits the throughput of the messages of a stream in both
+// directions.
+type throttledStream struct {
+	grpc.ServerStream
+	throughput *rate.Limiter
+}
+
+func (s *throttledStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return throttle(s.Context(), s.throughput, m)
+}
+
+func (s *throttledStream) SendMsg(m any) error {
+	if err := throttle(s.Context(), s.throughput, m); err != nil {
+		return err
+	}
+	return s.ServerStream.SendMsg(m)
 }
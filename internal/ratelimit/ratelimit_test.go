@@ -8,10 +8,15 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/sync/errgroup"
+	"golang.org/x/time/rate"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"file-service/internal/api"
 )
 
 func TestRequestLimiter_UnaryInterceptor(t *testing.T) {
@@ -30,11 +35,11 @@ func TestRequestLimiter_UnaryInterceptor(t *testing.T) {
 	}
 
 	t.Run("should allow requests within limits", func(t *testing.T) {
-		limiter := NewRequestLimiter()
+		limiter := newTestLimiter(t)
 
 		ctx := createContext("test-client")
 		info := &grpc.UnaryServerInfo{
-			FullMethod: "/file.FileService/Upload",
+			FullMethod: "/file.FileService/UploadFile",
 		}
 
 		errorGroup := errgroup.Group{}
@@ -59,11 +64,11 @@ func TestRequestLimiter_UnaryInterceptor(t *testing.T) {
 	})
 
 	t.Run("should reject requests exceeding limits", func(t *testing.T) {
-		limiter := NewRequestLimiter()
+		limiter := newTestLimiter(t)
 
 		ctx := createContext("test-client")
 		info := &grpc.UnaryServerInfo{
-			FullMethod: "/file.FileService/Upload",
+			FullMethod: "/file.FileService/UploadFile",
 		}
 
 		ctx, cancel := context.WithCancel(ctx)
@@ -90,11 +95,11 @@ func TestRequestLimiter_UnaryInterceptor(t *testing.T) {
 	})
 
 	t.Run("should reject requests without client-id", func(t *testing.T) {
-		limiter := NewRequestLimiter()
+		limiter := newTestLimiter(t)
 
 		ctx := createContext("")
 		info := &grpc.UnaryServerInfo{
-			FullMethod: "/file.FileService/Upload",
+			FullMethod: "/file.FileService/UploadFile",
 		}
 
 		resp, err := limiter.UnaryInterceptor(ctx, nil, info, createHandler(ctx, true))
@@ -106,3 +111,343 @@ func TestRequestLimiter_UnaryInterceptor(t *testing.T) {
 		assert.Equal(t, "client-id required", statusErr.Message())
 	})
 }
+
+func TestRequestLimiter_Groups(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("client-id", "test-client"))
+	block := make(chan struct{})
+	defer close(block)
+	blocked := func(ctx context.Context, req any) (any, error) {
+		<-block
+		return "success", nil
+	}
+	started := make(chan struct{})
+	call := func(limiter *RequestLimiter, method string) error {
+		_, err := limiter.UnaryInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req any) (any, error) {
+			started <- struct{}{}
+			return blocked(ctx, req)
+		})
+		return err
+	}
+
+	t.Run("Uploads and downloads share the limit", func(t *testing.T) {
+		limiter := newTestLimiter(t)
+		for i := range MAX_CONCURRENT_TRANSFERS {
+			method := "/file.FileService/UploadFile"
+			if i%2 == 1 {
+				method = "/file.FileService/DownloadFile"
+			}
+			go call(limiter, method)
+			<-started
+		}
+
+		err := call(limiter, "/file.FileService/DownloadFileStream")
+		require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+		// Listings are limited separately.
+		go call(limiter, "/file.FileService/ViewFiles")
+		<-started
+	})
+
+	t.Run("Listings", func(t *testing.T) {
+		limiter := newTestLimiter(t)
+		for range MAX_CONCURRENT_LISTINGS {
+			go call(limiter, "/file.FileService/ViewFiles")
+			<-started
+		}
+
+		err := call(limiter, "/file.FileService/ViewFiles")
+		require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	})
+
+	t.Run("Unlimited methods", func(t *testing.T) {
+		limiter := newTestLimiter(t)
+
+		// Not even the client id is required.
+		response, err := limiter.UnaryInterceptor(context.Background(), nil,
+			&grpc.UnaryServerInfo{FullMethod: "/file.FileService/GetUploadStatus"},
+			func(ctx context.Context, req any) (any, error) { return "success", nil },
+		)
+		require.NoError(t, err)
+		require.Equal(t, "success", response)
+	})
+}
+
+func TestRequestLimiter_StreamInterceptor(t *testing.T) {
+	limiter := newTestLimiter(t)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("client-id", "test-client"))
+	info := &grpc.StreamServerInfo{FullMethod: "/file.FileService/UploadFileStream", IsClientStream: true}
+
+	block := make(chan struct{})
+	started := make(chan struct{})
+	errorGroup := errgroup.Group{}
+	for range MAX_CONCURRENT_TRANSFERS {
+		errorGroup.Go(func() error {
+			return limiter.StreamInterceptor(nil, &testStream{ctx: ctx}, info, func(srv any, stream grpc.ServerStream) error {
+				started <- struct{}{}
+				<-block
+				return nil
+			})
+		})
+		<-started
+	}
+
+	err := limiter.StreamInterceptor(nil, &testStream{ctx: ctx}, info, func(srv any, stream grpc.ServerStream) error {
+		return nil
+	})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// Ended streams release the limit.
+	close(block)
+	require.NoError(t, errorGroup.Wait())
+	err = limiter.StreamInterceptor(nil, &testStream{ctx: ctx}, info, func(srv any, stream grpc.ServerStream) error {
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestNewRequestLimiter(t *testing.T) {
+	config := func(change func(config *Config, tier Tier)) *Config {
+		config := DefaultConfig()
+		change(config, config.Tiers[DEFAULT_TIER])
+		return config
+	}
+
+	tests := []struct {
+		name   string
+		config *Config
+		err    string
+	}{
+		{"Missing method", config(func(c *Config, t Tier) { delete(t.Methods, "ViewFiles") }), "tier default: no limit for method ViewFiles"},
+		{"Unknown method", config(func(c *Config, t Tier) { t.Methods["Upload"] = MethodLimit{} }), "tier default: limit for unknown method Upload"},
+		{"Unknown group", config(func(c *Config, t Tier) { t.Methods["ViewFiles"] = MethodLimit{Group: "lists"} }), "unknown group lists"},
+		{"Invalid group limit", config(func(c *Config, t Tier) { t.Groups[LISTING_GROUP] = 0 }), "invalid limit 0 of group listing"},
+		{"Rate without burst", config(func(c *Config, t Tier) { t.Methods["ViewFiles"] = MethodLimit{Rate: 1} }), "invalid rate"},
+		{"Burst without rate", config(func(c *Config, t Tier) { t.Methods["ViewFiles"] = MethodLimit{Burst: 1} }), "invalid rate"},
+		{"Invalid throughput", config(func(c *Config, t Tier) { t.Methods["ViewFiles"] = MethodLimit{BytesPerSecond: -1} }), "invalid throughput"},
+		{"No default tier", config(func(c *Config, t Tier) { c.DefaultTier = "" }), "no default tier"},
+		{"Unknown default tier", config(func(c *Config, t Tier) { c.DefaultTier = "free" }), "unknown default tier free"},
+		{"Unknown client tier", config(func(c *Config, t Tier) { c.Clients = map[string]string{"client": "free"} }), "unknown tier free of client client"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewRequestLimiter(&api.FileService_ServiceDesc, test.config)
+			require.ErrorContains(t, err, test.err)
+		})
+	}
+}
+
+func TestRequestLimiter_Rate(t *testing.T) {
+	config := withoutRates(DefaultConfig())
+	methods := config.Tiers[DEFAULT_TIER].Methods
+	methods["ViewFiles"] = MethodLimit{Group: LISTING_GROUP, Rate: 2, Burst: 3}
+	methods["DownloadFile"] = MethodLimit{Group: TRANSFER_GROUP, Rate: 2, Burst: 3}
+	limiter, err := NewRequestLimiter(&api.FileService_ServiceDesc, config)
+	require.NoError(t, err)
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("client-id", "test-client"))
+	call := func(ctx context.Context, method string) error {
+		_, err := limiter.UnaryInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req any) (any, error) {
+			return "success", nil
+		})
+		return err
+	}
+
+	for range 3 {
+		require.NoError(t, call(ctx, "/file.FileService/ViewFiles"))
+	}
+	err = call(ctx, "/file.FileService/ViewFiles")
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	details := status.Convert(err).Details()
+	require.Len(t, details, 1)
+	retryInfo, ok := details[0].(*errdetails.RetryInfo)
+	require.True(t, ok)
+	require.Equal(t, 500*time.Millisecond, retryInfo.RetryDelay.AsDuration())
+
+	// Rejected calls don't take tokens.
+	now = now.Add(499 * time.Millisecond)
+	require.Error(t, call(ctx, "/file.FileService/ViewFiles"))
+	now = now.Add(time.Millisecond)
+	require.NoError(t, call(ctx, "/file.FileService/ViewFiles"))
+
+	t.Run("Buckets per method and client", func(t *testing.T) {
+		require.NoError(t, call(ctx, "/file.FileService/DownloadFile"))
+
+		other := metadata.NewIncomingContext(context.Background(), metadata.Pairs("client-id", "other-client"))
+		require.NoError(t, call(other, "/file.FileService/ViewFiles"))
+	})
+
+	t.Run("Idle buckets are removed", func(t *testing.T) {
+		now = now.Add(BUCKET_SWEEP_INTERVAL)
+		require.NoError(t, call(ctx, "/file.FileService/DownloadFile"))
+
+		limiter.mutex.Lock()
+		defer limiter.mutex.Unlock()
+		require.Len(t, limiter.buckets, 1)
+		require.Len(t, limiter.buckets["client-id:test-client"], 1)
+	})
+}
+
+func TestRequestLimiter_Tiers(t *testing.T) {
+	config := withoutRates(DefaultConfig())
+	config.Tiers["partner"] = Tier{
+		Groups:  map[string]int{TRANSFER_GROUP: 2 * MAX_CONCURRENT_TRANSFERS, LISTING_GROUP: MAX_CONCURRENT_LISTINGS},
+		Methods: config.Tiers[DEFAULT_TIER].Methods,
+	}
+	config.Clients = map[string]string{"client-id:partner-client": "partner"}
+	limiter, err := NewRequestLimiter(&api.FileService_ServiceDesc, config)
+	require.NoError(t, err)
+
+	block := make(chan struct{})
+	defer close(block)
+	started := make(chan struct{})
+	call := func(clientId string) error {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("client-id", clientId))
+		_, err := limiter.UnaryInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/file.FileService/UploadFile"}, func(ctx context.Context, req any) (any, error) {
+			started <- struct{}{}
+			<-block
+			return "success", nil
+		})
+		return err
+	}
+	fill := func(clientId string, requests int) {
+		for range requests {
+			go call(clientId)
+			<-started
+		}
+	}
+
+	fill("test-client", MAX_CONCURRENT_TRANSFERS)
+	require.Equal(t, codes.ResourceExhausted, status.Code(call("test-client")))
+
+	fill("partner-client", 2*MAX_CONCURRENT_TRANSFERS)
+	require.Equal(t, codes.ResourceExhausted, status.Code(call("partner-client")))
+
+	t.Run("Reload", func(t *testing.T) {
+		config := withoutRates(DefaultConfig())
+		config.Tiers[DEFAULT_TIER].Groups[TRANSFER_GROUP] = MAX_CONCURRENT_TRANSFERS + 1
+		require.NoError(t, limiter.Reload(config))
+
+		// Requests in progress still count.
+		fill("test-client", 1)
+		require.Equal(t, codes.ResourceExhausted, status.Code(call("test-client")))
+		require.Equal(t, codes.ResourceExhausted, status.Code(call("partner-client")))
+
+		config.DefaultTier = "free"
+		require.ErrorContains(t, limiter.Reload(config), "unknown default tier free")
+	})
+}
+
+func TestRequestLimiter_Throughput(t *testing.T) {
+	config := withoutRates(DefaultConfig())
+	config.Tiers[DEFAULT_TIER].Methods["UploadFile"] = MethodLimit{Group: TRANSFER_GROUP, BytesPerSecond: 1000}
+	config.Tiers[DEFAULT_TIER].Methods["UploadFileStream"] = MethodLimit{Group: TRANSFER_GROUP, BytesPerSecond: 1000}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("client-id", "test-client"))
+	// 1500 bytes and the overhead of the message, a second call waits half
+	// a second after the burst.
+	message := wrapperspb.Bytes(make([]byte, 1500))
+
+	t.Run("Unary", func(t *testing.T) {
+		limiter, err := NewRequestLimiter(&api.FileService_ServiceDesc, config)
+		require.NoError(t, err)
+		info := &grpc.UnaryServerInfo{FullMethod: "/file.FileService/UploadFile"}
+		handler := func(ctx context.Context, req any) (any, error) { return "success", nil }
+
+		start := time.Now()
+		_, err = limiter.UnaryInterceptor(ctx, message, info, handler)
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, time.Since(start), 400*time.Millisecond)
+
+		// Calls that can't finish in time fail right away.
+		deadline, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+		defer cancel()
+		start = time.Now()
+		_, err = limiter.UnaryInterceptor(deadline, message, info, handler)
+		require.Equal(t, codes.DeadlineExceeded, status.Code(err))
+		require.Less(t, time.Since(start), 400*time.Millisecond)
+	})
+
+	t.Run("Stream", func(t *testing.T) {
+		limiter, err := NewRequestLimiter(&api.FileService_ServiceDesc, config)
+		require.NoError(t, err)
+		info := &grpc.StreamServerInfo{FullMethod: "/file.FileService/UploadFileStream", IsClientStream: true}
+
+		start := time.Now()
+		err = limiter.StreamInterceptor(nil, &testStream{ctx: ctx}, info, func(srv any, stream grpc.ServerStream) error {
+			return stream.SendMsg(message)
+		})
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, time.Since(start), 400*time.Millisecond)
+	})
+
+	t.Run("Streams in progress keep their bucket", func(t *testing.T) {
+		limiter, err := NewRequestLimiter(&api.FileService_ServiceDesc, config)
+		require.NoError(t, err)
+		now := time.Now()
+		limiter.now = func() time.Time { return now }
+		info := &grpc.StreamServerInfo{FullMethod: "/file.FileService/UploadFileStream", IsClientStream: true}
+
+		block := make(chan struct{})
+		buckets := make(chan *rate.Limiter)
+		errorGroup := errgroup.Group{}
+		errorGroup.Go(func() error {
+			return limiter.StreamInterceptor(nil, &testStream{ctx: ctx}, info, func(srv any, stream grpc.ServerStream) error {
+				buckets <- stream.(*throttledStream).throughput
+				<-block
+				return nil
+			})
+		})
+		first := <-buckets
+
+		// The bucket of the paused stream is full when the next one starts.
+		now = now.Add(BUCKET_SWEEP_INTERVAL)
+		var second *rate.Limiter
+		err = limiter.StreamInterceptor(nil, &testStream{ctx: ctx}, info, func(srv any, stream grpc.ServerStream) error {
+			second = stream.(*throttledStream).throughput
+			return nil
+		})
+		require.NoError(t, err)
+		require.Same(t, first, second)
+
+		close(block)
+		require.NoError(t, errorGroup.Wait())
+		limiter.mutex.Lock()
+		defer limiter.mutex.Unlock()
+		require.Empty(t, limiter.throttled)
+	})
+}
+
+// newTestLimiter limits concurrency only, so the tests aren't limited by
+// the rate of their calls.
+func newTestLimiter(t *testing.T) *RequestLimiter {
+	t.Helper()
+
+	limiter, err := NewRequestLimiter(&api.FileService_ServiceDesc, withoutRates(DefaultConfig()))
+	require.NoError(t, err)
+	return limiter
+}
+
+func withoutRates(config *Config) *Config {
+	for _, tier := range config.Tiers {
+		for method, limit := range tier.Methods {
+			limit.Rate, limit.Burst = 0, 0
+			tier.Methods[method] = limit
+		}
+	}
+	return config
+}
+
+type testStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *testStream) SendMsg(m any) error {
+	return nil
+}
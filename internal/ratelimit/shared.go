@@ -0,0 +1,129 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// SLOT_LEASE is the lease of a shared slot, renewed while the request is in
+	// progress. The slots of crashed replicas are freed when it expires.
+	SLOT_LEASE = 30 * time.Second
+	// SLOT_TIMEOUT limits the calls to the slot store.
+	SLOT_TIMEOUT = time.Second
+	// SLOT_RETRY_INTERVAL is the time requests are limited locally after the
+	// slot store failed.
+	SLOT_RETRY_INTERVAL = 10 * time.Second
+)
+
+// SlotStore counts the concurrent requests of clients for all replicas of the
+// service. Slots are leased, so the slots of crashed replicas are freed.
+type SlotStore interface {
+	// Acquire takes a slot of the client in the group unless limit slots are
+	// taken, false then.
+	Acquire(ctx context.Context, client, group string, limit int, lease time.Duration) (string, bool, error)
+	// Renew extends the leases of the slots and frees the expired slots of
+	// all replicas.
+	Renew(ctx context.Context, ids []string, lease time.Duration) error
+	Release(ctx context.Context, id string) error
+}
+
+// ShareSlots counts the concurrent requests in the store shared by the
+// replicas of the service too, so a client gets the limits of its tier once
+// rather than in every replica. While the store fails requests are limited
+// locally only. Requests admitted then hold no shared slot until they end,
+// so right after the store recovers a client can have up to the limit of its
+// tier in every replica. It must be called before serving, see
+// RunSlotRenewal.
+func (limiter *RequestLimiter) ShareSlots(store SlotStore, logger *slog.Logger) {
+	limiter.slots = store
+	limiter.leased = make(map[string]bool)
+	limiter.logger = logger
+}
+
+// acquireSlot takes a shared slot of the client in the group, the returned
+// function frees it.
+func (limiter *RequestLimiter) acquireSlot(ctx context.Context, client, group string, requests int) (func(), error) {
+	limiter.mutex.Lock()
+	local := limiter.now().Before(limiter.slotsRetryAt)
+	limiter.mutex.Unlock()
+	if local {
+		return func() {}, nil
+	}
+
+	storeCtx, cancel := context.WithTimeout(ctx, SLOT_TIMEOUT)
+	defer cancel()
+	id, ok, err := limiter.slots.Acquire(storeCtx, client, group, requests, SLOT_LEASE)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+		limiter.slotsFailed("failed to acquire shared rate limit slot", err)
+		return func() {}, nil
+	}
+	if !ok {
+		return nil, status.Error(codes.ResourceExhausted, "too many concurrent requests")
+	}
+
+	limiter.mutex.Lock()
+	limiter.leased[id] = true
+	limiter.mutex.Unlock()
+
+	return func() {
+		limiter.mutex.Lock()
+		delete(limiter.leased, id)
+		limiter.mutex.Unlock()
+
+		// The slot expires with its lease if it can't be released, which
+		// doesn't make the store unusable for new requests.
+		ctx, cancel := context.WithTimeout(context.Background(), SLOT_TIMEOUT)
+		defer cancel()
+		if err := limiter.slots.Release(ctx, id); err != nil {
+			limiter.logger.Warn("failed to release shared rate limit slot", "error", err, "slot", id)
+		}
+	}, nil
+}
+
+// slotsFailed limits requests locally for SLOT_RETRY_INTERVAL.
+func (limiter *RequestLimiter) slotsFailed(msg string, err error) {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	now := limiter.now()
+	if now.Before(limiter.slotsRetryAt) {
+		return
+	}
+	limiter.slotsRetryAt = now.Add(SLOT_RETRY_INTERVAL)
+	limiter.logger.Warn(msg+", limiting requests locally", "error", err, "retry_in", SLOT_RETRY_INTERVAL)
+}
+
+// RunSlotRenewal renews the leases of the shared slots of requests in
+// progress, the interval must be well below SLOT_LEASE.
+func (limiter *RequestLimiter) RunSlotRenewal(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			limiter.mutex.Lock()
+			ids := slices.Collect(maps.Keys(limiter.leased))
+			limiter.mutex.Unlock()
+
+			renewCtx, cancel := context.WithTimeout(ctx, SLOT_TIMEOUT)
+			err := limiter.slots.Renew(renewCtx, ids, SLOT_LEASE)
+			cancel()
+			if err != nil && ctx.Err() == nil {
+				limiter.slotsFailed("failed to renew shared rate limit slots", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"maps"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"file-service/internal/api"
+)
+
+func TestRequestLimiter_ShareSlots(t *testing.T) {
+	store := &testSlotStore{slots: make(map[string]string)}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	replica := func() *RequestLimiter {
+		limiter := newTestLimiter(t)
+		limiter.ShareSlots(store, logger)
+		return limiter
+	}
+	first, second := replica(), replica()
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("client-id", "test-client"))
+	block := make(chan struct{})
+	started := make(chan struct{})
+	errorGroup := errgroup.Group{}
+	call := func(limiter *RequestLimiter) error {
+		_, err := limiter.UnaryInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/file.FileService/UploadFile"}, func(ctx context.Context, req any) (any, error) {
+			started <- struct{}{}
+			<-block
+			return "success", nil
+		})
+		return err
+	}
+	fill := func(limiter *RequestLimiter, requests int) {
+		for range requests {
+			errorGroup.Go(func() error { return call(limiter) })
+			<-started
+		}
+	}
+
+	// The replicas share the limit of the client.
+	fill(first, MAX_CONCURRENT_TRANSFERS/2)
+	fill(second, MAX_CONCURRENT_TRANSFERS-MAX_CONCURRENT_TRANSFERS/2)
+	require.Equal(t, codes.ResourceExhausted, status.Code(call(first)))
+	require.Equal(t, codes.ResourceExhausted, status.Code(call(second)))
+	require.Len(t, store.taken(), MAX_CONCURRENT_TRANSFERS)
+
+	t.Run("Renewal", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			first.RunSlotRenewal(ctx, 10*time.Millisecond)
+		}()
+		require.Eventually(t, func() bool {
+			return len(store.renewedSlots()) == MAX_CONCURRENT_TRANSFERS/2
+		}, time.Second, 10*time.Millisecond)
+		cancel()
+		<-done
+	})
+
+	t.Run("Local limits while the store fails", func(t *testing.T) {
+		now := time.Now()
+		third := replica()
+		third.now = func() time.Time { return now }
+		store.fail(errors.New("connection refused"))
+
+		fill(third, MAX_CONCURRENT_TRANSFERS)
+		require.Equal(t, codes.ResourceExhausted, status.Code(call(third)))
+		require.Equal(t, 1, store.failedCalls())
+
+		// The store is tried again after a while.
+		now = now.Add(SLOT_RETRY_INTERVAL)
+		store.fail(nil)
+		close(block)
+		require.NoError(t, errorGroup.Wait())
+		require.Empty(t, store.taken())
+		require.Equal(t, 1, store.failedCalls())
+
+		block = make(chan struct{})
+		fill(third, 1)
+		require.Len(t, store.taken(), 1)
+		close(block)
+		require.NoError(t, errorGroup.Wait())
+	})
+
+	t.Run("Failed release", func(t *testing.T) {
+		block = make(chan struct{})
+		store.failRelease(errors.New("connection reset"))
+		fill(first, 1)
+		close(block)
+		require.NoError(t, errorGroup.Wait())
+
+		// The slot is left to expire, new requests still take shared slots.
+		store.failRelease(nil)
+		require.Len(t, store.taken(), 1)
+		block = make(chan struct{})
+		fill(first, 1)
+		require.Len(t, store.taken(), 2)
+		close(block)
+		require.NoError(t, errorGroup.Wait())
+		require.Len(t, store.taken(), 1)
+	})
+
+	t.Run("Refused requests keep their token", func(t *testing.T) {
+		config := withoutRates(DefaultConfig())
+		config.Tiers[DEFAULT_TIER].Methods["UploadFile"] = MethodLimit{Group: TRANSFER_GROUP, Rate: 1, Burst: 1}
+		rated, err := NewRequestLimiter(&api.FileService_ServiceDesc, config)
+		require.NoError(t, err)
+		rated.ShareSlots(store, logger)
+		now := time.Now()
+		rated.now = func() time.Time { return now }
+		quick := func() error {
+			_, err := rated.UnaryInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/file.FileService/UploadFile"}, func(ctx context.Context, req any) (any, error) {
+				return "success", nil
+			})
+			return err
+		}
+
+		// The slots are taken in another replica.
+		block = make(chan struct{})
+		fill(second, MAX_CONCURRENT_TRANSFERS-len(store.taken()))
+		err = quick()
+		require.Equal(t, codes.ResourceExhausted, status.Code(err))
+		require.Equal(t, "too many concurrent requests", status.Convert(err).Message())
+
+		close(block)
+		require.NoError(t, errorGroup.Wait())
+		require.NoError(t, quick())
+		require.Equal(t, codes.ResourceExhausted, status.Code(quick()))
+	})
+}
+
+type testSlotStore struct {
+	mutex   sync.Mutex
+	slots   map[string]string // id -> client and group
+	renewed []string
+	next    int
+	err     error
+	failed  int
+	// releaseErr fails Release only.
+	releaseErr error
+}
+
+func (s *testSlotStore) Acquire(ctx context.Context, client, group string, limit int, lease time.Duration) (string, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.err != nil {
+		s.failed++
+		return "", false, s.err
+	}
+	taken := 0
+	for _, key := range s.slots {
+		if key == client+"/"+group {
+			taken++
+		}
+	}
+	if taken >= limit {
+		return "", false, nil
+	}
+	s.next++
+	id := strconv.Itoa(s.next)
+	s.slots[id] = client + "/" + group
+	return id, true, nil
+}
+
+func (s *testSlotStore) Renew(ctx context.Context, ids []string, lease time.Duration) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.renewed = ids
+	return s.err
+}
+
+func (s *testSlotStore) Release(ctx context.Context, id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.releaseErr != nil {
+		return s.releaseErr
+	}
+	delete(s.slots, id)
+	return nil
+}
+
+func (s *testSlotStore) fail(err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.err = err
+}
+
+func (s *testSlotStore) failRelease(err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.releaseErr = err
+}
+
+func (s *testSlotStore) failedCalls() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.failed
+}
+
+func (s *testSlotStore) taken() map[string]string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return maps.Clone(s.slots)
+}
+
+func (s *testSlotStore) renewedSlots() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.renewed
+}
@@ -4,6 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"image"
+	"io"
+	"math"
+	"time"
 
 	"file-service/internal/api"
 	"file-service/internal/file"
@@ -14,6 +18,9 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// DOWNLOAD_CHUNK_SIZE is the size of the chunks sent by DownloadFileStream.
+const DOWNLOAD_CHUNK_SIZE = 64 * 1024
+
 type FileServer struct {
 	api.UnimplementedFileServiceServer
 
@@ -25,33 +32,237 @@ func NewFileServer(fileService file.FileService) *FileServer {
 }
 
 func (s *FileServer) UploadFile(ctx context.Context, request *api.UploadFileRequest) (*api.UploadFileResponse, error) {
-	var response *api.UploadFileResponse
-	id, err := s.fileService.UploadFile(ctx, request.Filename, request.Data)
+	id, checksums, err := s.fileService.UploadFile(ctx, request.Filename, request.Data, file.UploadOptions{
+		StripMetadata: request.StripMetadata,
+		KeepOriginal:  request.KeepOriginal,
+		Checksums:     file.Checksums{SHA256: request.Sha256, CRC32C: request.Crc32C},
+	})
+	if err != nil {
+		return nil, uploadFileError(err)
+	}
+
+	return uploadFileResponse(id, checksums), nil
+}
+
+func (s *FileServer) UploadFileStream(stream api.FileService_UploadFileStreamServer) error {
+	request, err := stream.Recv()
 	if err != nil {
-		if errors.Is(err, file.ErrFileEmpty) {
-			return nil, status.Errorf(codes.InvalidArgument, "File content can't be empty")
+		if errors.Is(err, io.EOF) {
+			return status.Errorf(codes.InvalidArgument, "File info is required")
 		}
-		if errors.Is(err, file.ErrFileNameEmpty) {
-			return nil, status.Errorf(codes.InvalidArgument, "File name can't be empty")
+		return err
+	}
+
+	info := request.GetInfo()
+	if info == nil {
+		return status.Errorf(codes.InvalidArgument, "First message must carry file info")
+	}
+
+	id, checksums, err := s.fileService.UploadFileStream(stream.Context(), info.Filename, &uploadStreamReader{stream: stream}, file.UploadOptions{
+		StripMetadata: info.StripMetadata,
+		KeepOriginal:  info.KeepOriginal,
+		Checksums:     file.Checksums{SHA256: info.Sha256, CRC32C: info.Crc32C},
+	})
+	if err != nil {
+		return uploadFileError(err)
+	}
+
+	return stream.SendAndClose(uploadFileResponse(id, checksums))
+}
+
+func (s *FileServer) CheckContent(ctx context.Context, request *api.CheckContentRequest) (*api.CheckContentResponse, error) {
+	challenge, err := s.fileService.CheckContent(ctx, request.Sha256)
+	if err != nil {
+		return nil, uploadFileError(err)
+	}
+
+	return &api.CheckContentResponse{
+		Challenge: challenge,
+	}, nil
+}
+
+func (s *FileServer) UploadByReference(ctx context.Context, request *api.UploadByReferenceRequest) (*api.UploadByReferenceResponse, error) {
+	id, err := s.fileService.UploadByReference(ctx, request.Filename, request.Sha256, request.Challenge, request.Proof, file.UploadOptions{
+		StripMetadata: request.StripMetadata,
+		KeepOriginal:  request.KeepOriginal,
+	})
+	if err != nil {
+		if errors.Is(err, file.ErrChallengeInvalid) {
+			return nil, invalidArgument("Invalid or expired challenge, get a new one with CheckContent", "challenge", err.Error())
 		}
-		status, err := status.New(codes.Internal, "Failed to upload file").
-			WithDetails(&errdetails.ErrorInfo{Reason: err.Error()})
-		if err != nil {
-			return nil, fmt.Errorf("unexpected error attaching error detail: %w", err)
+		if errors.Is(err, file.ErrBlobNotFound) {
+			return nil, status.Errorf(codes.NotFound, "Content with hash %s is not stored, upload the file", request.Sha256)
 		}
-		return nil, status.Err()
+		return nil, uploadFileError(err)
 	}
 
-	response = &api.UploadFileResponse{
+	return &api.UploadByReferenceResponse{
 		FileId: id,
+	}, nil
+}
+
+func uploadFileResponse(id string, checksums file.Checksums) *api.UploadFileResponse {
+	return &api.UploadFileResponse{
+		FileId: id,
+		Sha256: checksums.SHA256,
+		Crc32C: *checksums.CRC32C,
+	}
+}
+
+func uploadFileError(err error) error {
+	if errors.Is(err, file.ErrFileEmpty) {
+		return status.Errorf(codes.InvalidArgument, "File content can't be empty")
+	}
+	if errors.Is(err, file.ErrFileNameEmpty) {
+		return status.Errorf(codes.InvalidArgument, "File name can't be empty")
+	}
+	if errors.Is(err, file.ErrFileTooLarge) {
+		return status.Errorf(codes.InvalidArgument, "File is too large")
+	}
+	if errors.Is(err, file.ErrContentTypeNotAllowed) {
+		return invalidArgument("Content type is not allowed", "data", err.Error())
+	}
+	if errors.Is(err, file.ErrExtensionMismatch) {
+		return invalidArgument("File name extension doesn't match the content", "filename", err.Error())
+	}
+	if errors.Is(err, file.ErrImageUnsupported) {
+		return invalidArgument("Image can't be read to strip its metadata", "data", err.Error())
+	}
+	if errors.Is(err, file.ErrStripUnsupported) {
+		return invalidArgument("Metadata can't be stripped from the content type", "strip_metadata", err.Error())
+	}
+	if errors.Is(err, file.ErrHashInvalid) {
+		return invalidArgument("Invalid SHA-256 hash", "sha256", "must be a lowercase hex encoded SHA-256")
+	}
+	if errors.Is(err, file.ErrHashMismatch) {
+		return invalidArgument("SHA-256 hash mismatch", "sha256", err.Error())
+	}
+	if errors.Is(err, file.ErrCRC32CMismatch) {
+		return invalidArgument("CRC32C mismatch", "crc32c", err.Error())
+	}
+	if status.Code(err) != codes.Unknown {
+		// Already a gRPC status, e.g. the stream was cancelled by the client.
+		return err
+	}
+	status, err := status.New(codes.Internal, "Failed to upload file").
+		WithDetails(&errdetails.ErrorInfo{Reason: err.Error()})
+	if err != nil {
+		return fmt.Errorf("unexpected error attaching error detail: %w", err)
+	}
+	return status.Err()
+}
+
+func invalidArgument(message string, field string, description string) error {
+	status, err := status.New(codes.InvalidArgument, message).
+		WithDetails(&errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{
+				{Field: field, Description: description},
+			},
+		})
+	if err != nil {
+		return fmt.Errorf("unexpected error attaching error detail: %w", err)
+	}
+	return status.Err()
+}
+
+// uploadStreamReader exposes the chunks of an upload stream as io.Reader.
+type uploadStreamReader struct {
+	stream api.FileService_UploadFileStreamServer
+	chunk  []byte
+}
+
+func (r *uploadStreamReader) Read(p []byte) (int, error) {
+	for len(r.chunk) == 0 {
+		request, err := r.stream.Recv()
+		if err != nil {
+			return 0, err
+		}
+		if request.GetInfo() != nil {
+			return 0, status.Errorf(codes.InvalidArgument, "Only the first message can carry file info")
+		}
+		r.chunk = request.GetChunk()
 	}
 
-	return response, nil
+	n := copy(p, r.chunk)
+	r.chunk = r.chunk[n:]
+	return n, nil
 }
 
 func (s *FileServer) DownloadFile(ctx context.Context, request *api.DownloadFileRequest) (*api.DownloadFileResponse, error) {
 	filename, data, err := s.fileService.DownloadFile(ctx, request.FileId)
 	if err != nil {
+		return nil, downloadFileError(err, request.FileId)
+	}
+
+	return &api.DownloadFileResponse{
+		Filename: filename,
+		Data:     data,
+	}, nil
+}
+
+func (s *FileServer) DownloadFileStream(request *api.DownloadFileStreamRequest, stream api.FileService_DownloadFileStreamServer) error {
+	filename, size, content, err := s.fileService.DownloadFileStream(
+		stream.Context(), request.FileId, int64(request.Offset), int64(request.Length),
+	)
+	if err != nil {
+		return downloadFileError(err, request.FileId)
+	}
+	defer content.Close()
+
+	buffer := make([]byte, DOWNLOAD_CHUNK_SIZE)
+	offset := request.Offset
+	first := true
+	for {
+		n, err := io.ReadFull(content, buffer)
+		if n > 0 {
+			response := &api.DownloadFileStreamResponse{
+				Offset: offset,
+				Chunk:  buffer[:n],
+			}
+			if first {
+				response.Filename = filename
+				response.Size = uint64(size)
+				first = false
+			}
+			if err := stream.Send(response); err != nil {
+				return err
+			}
+			offset += uint64(n)
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil
+		}
+		if err != nil {
+			return downloadFileError(err, request.FileId)
+		}
+	}
+}
+
+func downloadFileError(err error, fileId string) error {
+	if errors.Is(err, file.ErrFileNotFound) {
+		return status.Errorf(codes.NotFound, "File with id %s not found", fileId)
+	}
+
+	if errors.Is(err, file.ErrFileIdEmpty) {
+		return status.Errorf(codes.InvalidArgument, "File id can't be empty")
+	}
+
+	if errors.Is(err, file.ErrInvalidRange) {
+		return status.Errorf(codes.OutOfRange, "Requested range is outside of the file with id %s", fileId)
+	}
+
+	status, err := status.New(
+		codes.Internal,
+		fmt.Sprintf("Failed to download file with id %s", fileId),
+	).WithDetails(&errdetails.ErrorInfo{Reason: err.Error()})
+	if err != nil {
+		return fmt.Errorf("unexpected error attaching error detail: %w", err)
+	}
+	return status.Err()
+}
+
+func (s *FileServer) DeleteFile(ctx context.Context, request *api.DeleteFileRequest) (*api.DeleteFileResponse, error) {
+	if err := s.fileService.DeleteFile(ctx, request.FileId); err != nil {
 		if errors.Is(err, file.ErrFileNotFound) {
 			return nil, status.Errorf(codes.NotFound, "File with id %s not found", request.FileId)
 		}
@@ -62,7 +273,7 @@ func (s *FileServer) DownloadFile(ctx context.Context, request *api.DownloadFile
 
 		status, err := status.New(
 			codes.Internal,
-			fmt.Sprintf("Failed to download file with id %s", request.FileId),
+			fmt.Sprintf("Failed to delete file with id %s", request.FileId),
 		).WithDetails(&errdetails.ErrorInfo{Reason: err.Error()})
 		if err != nil {
 			return nil, fmt.Errorf("unexpected error attaching error detail: %w", err)
@@ -70,28 +281,216 @@ func (s *FileServer) DownloadFile(ctx context.Context, request *api.DownloadFile
 		return nil, status.Err()
 	}
 
-	return &api.DownloadFileResponse{
-		Filename: filename,
-		Data:     data,
-	}, nil
+	return &api.DeleteFileResponse{}, nil
 }
 
 func (s *FileServer) ViewFiles(ctx context.Context, request *api.ViewFilesRequest) (*api.ViewFilesResponse, error) {
-	files, err := s.fileService.ViewFilesMetadata(ctx, file.NewPage(int(request.Offset), int(request.Limit)))
+	sort, err := fileSort(request.Sort)
+	if err != nil {
+		return nil, invalidArgument("Invalid sort", "sort.field", err.Error())
+	}
+
+	page := file.NewPage(int(request.GetOffset()), int(request.Limit))
+	if request.PageToken != "" {
+		page, err = file.NewCursorPage(request.PageToken, sort, int(request.Limit))
+		if err != nil {
+			return nil, invalidArgument("Invalid page token", "page_token", err.Error())
+		}
+	}
+
+	files, nextPageToken, err := s.fileService.ViewFilesMetadata(ctx, fileFilter(request.Filter), sort, page)
 	if err != nil {
 		return nil, err
 	}
 
-	responseFiles := make([]*api.ViewFilesResponse_FileInfo, len(files))
+	responseFiles := make([]*api.FileInfo, len(files))
 	for i, file := range files {
-		responseFiles[i] = &api.ViewFilesResponse_FileInfo{
-			Filename:  file.Filename,
-			CreatedAt: timestamppb.New(file.CreatedAt),
-			UpdatedAt: timestamppb.New(file.UpdatedAt),
-		}
+		responseFiles[i] = fileInfo(file)
 	}
 
 	return &api.ViewFilesResponse{
-		Files: responseFiles,
+		Files:         responseFiles,
+		NextPageToken: nextPageToken,
 	}, nil
 }
+
+func (s *FileServer) GetFileMetadata(ctx context.Context, request *api.GetFileMetadataRequest) (*api.GetFileMetadataResponse, error) {
+	meta, err := s.fileService.GetFileMetadata(ctx, request.FileId)
+	if err != nil {
+		if errors.Is(err, file.ErrFileNotFound) {
+			return nil, status.Errorf(codes.NotFound, "File with id %s not found", request.FileId)
+		}
+
+		if errors.Is(err, file.ErrFileIdEmpty) {
+			return nil, status.Errorf(codes.InvalidArgument, "File id can't be empty")
+		}
+
+		status, err := status.New(
+			codes.Internal,
+			fmt.Sprintf("Failed to get metadata of file with id %s", request.FileId),
+		).WithDetails(&errdetails.ErrorInfo{Reason: err.Error()})
+		if err != nil {
+			return nil, fmt.Errorf("unexpected error attaching error detail: %w", err)
+		}
+		return nil, status.Err()
+	}
+
+	return &api.GetFileMetadataResponse{
+		File: fileInfo(meta),
+	}, nil
+}
+
+func (s *FileServer) GetThumbnail(ctx context.Context, request *api.GetThumbnailRequest) (*api.GetThumbnailResponse, error) {
+	contentType, data, err := s.fileService.GetThumbnail(ctx, request.FileId, int(request.Size))
+	if err != nil {
+		if errors.Is(err, file.ErrThumbnailSizeInvalid) {
+			return nil, invalidArgument("Thumbnail size is not available", "size", err.Error())
+		}
+		if errors.Is(err, file.ErrImageUnsupported) {
+			return nil, status.Errorf(codes.FailedPrecondition, "File with id %s is not a supported image", request.FileId)
+		}
+		return nil, downloadFileError(err, request.FileId)
+	}
+
+	return &api.GetThumbnailResponse{
+		ContentType: contentType,
+		Data:        data,
+	}, nil
+}
+
+func (s *FileServer) DownloadTransformed(ctx context.Context, request *api.DownloadTransformedRequest) (*api.DownloadTransformedResponse, error) {
+	transform, err := fileTransform(request)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid transformation: %s", err)
+	}
+
+	contentType, data, err := s.fileService.DownloadTransformed(ctx, request.FileId, transform)
+	if err != nil {
+		if errors.Is(err, file.ErrTransformInvalid) {
+			return nil, status.Errorf(codes.InvalidArgument, "Invalid transformation: %s", err)
+		}
+		if errors.Is(err, file.ErrImageUnsupported) {
+			return nil, status.Errorf(codes.FailedPrecondition, "File with id %s is not a supported image", request.FileId)
+		}
+		return nil, downloadFileError(err, request.FileId)
+	}
+
+	return &api.DownloadTransformedResponse{
+		ContentType: contentType,
+		Data:        data,
+	}, nil
+}
+
+var fitModes = map[api.FitMode]file.FitMode{
+	api.FitMode_FIT_MODE_UNSPECIFIED: "",
+	api.FitMode_FIT_MODE_CONTAIN:     file.FIT_CONTAIN,
+	api.FitMode_FIT_MODE_COVER:       file.FIT_COVER,
+	api.FitMode_FIT_MODE_FILL:        file.FIT_FILL,
+}
+
+var outputFormats = map[api.OutputFormat]file.OutputFormat{
+	api.OutputFormat_OUTPUT_FORMAT_UNSPECIFIED: "",
+	api.OutputFormat_OUTPUT_FORMAT_JPEG:        file.FORMAT_JPEG,
+	api.OutputFormat_OUTPUT_FORMAT_PNG:         file.FORMAT_PNG,
+}
+
+func fileTransform(request *api.DownloadTransformedRequest) (file.Transform, error) {
+	fit, ok := fitModes[request.Fit]
+	if !ok {
+		return file.Transform{}, fmt.Errorf("%w: unknown fit mode %d", file.ErrTransformInvalid, request.Fit)
+	}
+	format, ok := outputFormats[request.Format]
+	if !ok {
+		return file.Transform{}, fmt.Errorf("%w: unknown format %d", file.ErrTransformInvalid, request.Format)
+	}
+
+	var crop *image.Rectangle
+	if request.Crop != nil {
+		// Larger coordinates are outside of any decodable image.
+		x, y := int(min(request.Crop.X, math.MaxInt32)), int(min(request.Crop.Y, math.MaxInt32))
+		width, height := int(min(request.Crop.Width, math.MaxInt32)), int(min(request.Crop.Height, math.MaxInt32))
+		rect := image.Rect(x, y, x+width, y+height)
+		crop = &rect
+	}
+
+	return file.NewTransform(crop, int(min(request.Width, math.MaxInt32)), int(min(request.Height, math.MaxInt32)), fit, format, int(min(request.Quality, math.MaxInt32)))
+}
+
+func fileInfo(meta *file.FileMeta) *api.FileInfo {
+	return &api.FileInfo{
+		FileId:         meta.ID.String(),
+		Filename:       meta.Filename,
+		Size:           uint64(meta.Size),
+		Sha256:         meta.Hash,
+		ContentType:    meta.ContentType,
+		Image:          imageInfo(meta.Image),
+		OriginalSha256: meta.OriginalHash,
+		CreatedAt:      timestamppb.New(meta.CreatedAt),
+		UpdatedAt:      timestamppb.New(meta.UpdatedAt),
+	}
+}
+
+func imageInfo(image *file.ImageMeta) *api.ImageInfo {
+	if image == nil {
+		return nil
+	}
+
+	info := &api.ImageInfo{
+		Width:       uint32(image.Width),
+		Height:      uint32(image.Height),
+		ColorModel:  image.ColorModel,
+		Format:      image.Format,
+		CameraModel: image.CameraModel,
+		Orientation: uint32(image.Orientation),
+		HasGps:      image.HasGPS,
+	}
+	if !image.TakenAt.IsZero() {
+		info.TakenAt = timestamppb.New(image.TakenAt)
+	}
+	return info
+}
+
+func fileFilter(filter *api.FileFilter) file.Filter {
+	if filter == nil {
+		return file.Filter{}
+	}
+
+	result := file.Filter{
+		NamePrefix:   filter.NamePrefix,
+		NameContains: filter.NameContains,
+		CreatedFrom:  timeOf(filter.CreatedFrom),
+		CreatedTo:    timeOf(filter.CreatedTo),
+		UpdatedFrom:  timeOf(filter.UpdatedFrom),
+		UpdatedTo:    timeOf(filter.UpdatedTo),
+	}
+	if filter.MinSize != nil {
+		size := int64(min(*filter.MinSize, math.MaxInt64))
+		result.MinSize = &size
+	}
+	if filter.MaxSize != nil {
+		size := int64(min(*filter.MaxSize, math.MaxInt64))
+		result.MaxSize = &size
+	}
+	return result
+}
+
+func timeOf(timestamp *timestamppb.Timestamp) time.Time {
+	if timestamp == nil {
+		return time.Time{}
+	}
+	return timestamp.AsTime()
+}
+
+var sortFields = map[api.SortField]file.SortField{
+	api.SortField_SORT_FIELD_UPDATED_AT: file.SORT_BY_UPDATED_AT,
+	api.SortField_SORT_FIELD_CREATED_AT: file.SORT_BY_CREATED_AT,
+	api.SortField_SORT_FIELD_NAME:       file.SORT_BY_NAME,
+	api.SortField_SORT_FIELD_SIZE:       file.SORT_BY_SIZE,
+}
+
+func fileSort(sort *api.FileSort) (file.Sort, error) {
+	if sort.GetField() == api.SortField_SORT_FIELD_UNSPECIFIED {
+		return file.DefaultSort, nil
+	}
+	return file.NewSort(sortFields[sort.Field], sort.Descending)
+}
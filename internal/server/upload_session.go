@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"file-service/internal/api"
+	"file-service/internal/file"
+)
+
+func (s *FileServer) StartUpload(ctx context.Context, request *api.StartUploadRequest) (*api.StartUploadResponse, error) {
+	session, err := s.fileService.StartUpload(ctx, request.Filename, file.UploadOptions{
+		StripMetadata: request.StripMetadata,
+		KeepOriginal:  request.KeepOriginal,
+	})
+	if err != nil {
+		return nil, uploadFileError(err)
+	}
+
+	return &api.StartUploadResponse{
+		SessionId: session.ID.String(),
+		ExpiresAt: timestamppb.New(session.ExpiresAt),
+	}, nil
+}
+
+func (s *FileServer) AppendChunk(ctx context.Context, request *api.AppendChunkRequest) (*api.AppendChunkResponse, error) {
+	session, err := s.fileService.AppendChunk(ctx, request.SessionId, int64(request.Offset), request.Chunk)
+	if err != nil {
+		if errors.Is(err, file.ErrUploadOffsetMismatch) {
+			return nil, status.Errorf(codes.FailedPrecondition,
+				"Chunk offset %d doesn't match upload offset %d", request.Offset, session.Offset)
+		}
+		return nil, uploadSessionError(err, request.SessionId)
+	}
+
+	return &api.AppendChunkResponse{
+		Offset:    uint64(session.Offset),
+		ExpiresAt: timestamppb.New(session.ExpiresAt),
+	}, nil
+}
+
+func (s *FileServer) GetUploadStatus(ctx context.Context, request *api.GetUploadStatusRequest) (*api.GetUploadStatusResponse, error) {
+	session, err := s.fileService.GetUploadStatus(ctx, request.SessionId)
+	if err != nil {
+		return nil, uploadSessionError(err, request.SessionId)
+	}
+
+	return &api.GetUploadStatusResponse{
+		Filename:  session.Filename,
+		Offset:    uint64(session.Offset),
+		ExpiresAt: timestamppb.New(session.ExpiresAt),
+	}, nil
+}
+
+func (s *FileServer) CommitUpload(ctx context.Context, request *api.CommitUploadRequest) (*api.CommitUploadResponse, error) {
+	id, err := s.fileService.CommitUpload(ctx, request.SessionId, request.Sha256)
+	if err != nil {
+		return nil, uploadSessionError(err, request.SessionId)
+	}
+
+	return &api.CommitUploadResponse{
+		FileId: id,
+	}, nil
+}
+
+func uploadSessionError(err error, sessionId string) error {
+	if errors.Is(err, file.ErrUploadNotFound) {
+		return status.Errorf(codes.NotFound, "Upload session %s not found", sessionId)
+	}
+	return uploadFileError(err)
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/google/uuid"
+
+	"file-service/internal/file"
+	"file-service/internal/imaging"
+)
+
+// BACKFILL_BATCH_SIZE is the number of files read per query by
+// BackfillMetadata.
+const BACKFILL_BATCH_SIZE = 100
+
+// BackfillMetadata records the size, content type and image metadata of files
+// stored before they were tracked, reading them from the blobs. It returns the
+// number of updated files.
+func (service *DiskFileService) BackfillMetadata(ctx context.Context) (int, error) {
+	updated := 0
+	after := uuid.Nil
+	for {
+		files, err := service.meta.FindIncomplete(ctx, after, BACKFILL_BATCH_SIZE)
+		if err != nil {
+			return updated, err
+		}
+		if len(files) == 0 {
+			return updated, nil
+		}
+
+		for _, meta := range files {
+			after = meta.ID
+			err := service.backfillFile(ctx, meta)
+			if errors.Is(err, file.ErrBlobNotFound) || errors.Is(err, file.ErrHashInvalid) {
+				// Left incomplete, the content may be restored.
+				service.logger.Warn("content of file is missing", "file_id", meta.ID, "hash", meta.Hash)
+				continue
+			}
+			if err != nil {
+				return updated, err
+			}
+			updated++
+		}
+	}
+}
+
+// RunMetadataBackfill runs BackfillMetadata once and logs the result.
+func (service *DiskFileService) RunMetadataBackfill(ctx context.Context) {
+	updated, err := service.BackfillMetadata(ctx)
+	if err != nil {
+		service.logger.Error("failed to backfill file metadata", "error", err)
+		return
+	}
+	if updated > 0 {
+		service.logger.Info("backfilled file metadata", "count", updated)
+	}
+}
+
+func (service *DiskFileService) backfillFile(ctx context.Context, meta *file.FileMeta) error {
+	// Files stored before images were inspected already have a content type.
+	size, contentType := meta.Size, meta.ContentType
+	if contentType == "" {
+		var err error
+		size, contentType, err = service.probeBlob(ctx, meta.Hash)
+		if err != nil {
+			return err
+		}
+	}
+
+	probed := &file.FileMeta{Hash: meta.Hash, ContentType: contentType}
+	if imaging.Supported(contentType) {
+		if err := service.inspectBlob(ctx, probed); err != nil {
+			return err
+		}
+	}
+
+	// The hash lock keeps a concurrent DeleteFile from being undone by Save.
+	return service.transaction.Do(ctx, func(ctx context.Context) error {
+		if err := service.meta.LockHash(ctx, meta.Hash); err != nil {
+			return err
+		}
+
+		current, err := service.meta.FindById(ctx, meta.ID)
+		if err != nil {
+			if errors.Is(err, file.ErrFileNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		current.Size = size
+		current.ContentType = contentType
+		current.Image = probed.Image
+		return service.meta.Save(ctx, current)
+	})
+}
+
+func (service *DiskFileService) probeBlob(ctx context.Context, hash string) (int64, string, error) {
+	info, err := service.blobs.Stat(ctx, hash)
+	if err != nil {
+		return 0, "", err
+	}
+
+	content, err := service.blobs.Get(ctx, hash, 0, file.SNIFF_SIZE)
+	if err != nil {
+		return 0, "", err
+	}
+	defer content.Close()
+
+	head, err := io.ReadAll(content)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return info.Size, file.DetectContentType(head), nil
+}
+
+func (service *DiskFileService) inspectBlob(ctx context.Context, meta *file.FileMeta) error {
+	content, err := service.blobs.Get(ctx, meta.Hash, 0, imaging.INSPECT_SIZE)
+	if errors.Is(err, file.ErrBlobNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	service.inspectImage(meta, content)
+	return nil
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"file-service/internal/file"
+)
+
+func TestDiskFileService_BackfillMetadata(t *testing.T) {
+	ctx := context.Background()
+	logs := &bytes.Buffer{}
+	service, blobs := newTestService(t, logs)
+
+	hasher := file.NewHasher()
+	hasher.Write([]byte("stored content"))
+	stored := hasher.Hash()
+	require.NoError(t, blobs.Put(ctx, stored, strings.NewReader("stored content")))
+	hasher = file.NewHasher()
+	hasher.Write([]byte("lost content"))
+	missing := hasher.Hash()
+
+	save := func(hash string) uuid.UUID {
+		meta := &file.FileMeta{ID: uuid.New(), Filename: hash + ".txt", Hash: hash, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+		require.NoError(t, service.meta.Save(ctx, meta))
+		return meta.ID
+	}
+	complete, incomplete := save(stored), save(missing)
+
+	updated, err := service.BackfillMetadata(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, updated)
+
+	meta, err := service.meta.FindById(ctx, complete)
+	require.NoError(t, err)
+	require.Equal(t, int64(len("stored content")), meta.Size)
+	require.Equal(t, "text/plain; charset=utf-8", meta.ContentType)
+
+	// Files with missing content are skipped, not recorded as empty.
+	meta, err = service.meta.FindById(ctx, incomplete)
+	require.NoError(t, err)
+	require.Empty(t, meta.ContentType)
+	require.Contains(t, logs.String(), "content of file is missing")
+}
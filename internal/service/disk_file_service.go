@@ -1,11 +1,17 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"time"
 
 	tx "github.com/avito-tech/go-transaction-manager/trm/v2/manager"
@@ -13,6 +19,7 @@ import (
 
 	"file-service/internal/api"
 	"file-service/internal/file"
+	"file-service/internal/imaging"
 )
 
 const (
@@ -28,102 +35,483 @@ type DiskFileService struct {
 	api.UnimplementedFileServiceServer
 
 	uploadPath  string
+	blobs       file.BlobStore
 	meta        file.FileMetaRepository
+	policy      file.UploadPolicy
+	thumbnails  *Thumbnailer
+	transforms  *Transformer
 	transaction *tx.Manager
 	logger      *slog.Logger
+
+	// stripMetadata is the default of file.UploadOptions.StripMetadata.
+	stripMetadata bool
+	// referenceSecret signs the challenges of CheckContent.
+	referenceSecret []byte
+
+	sessionLocks keyedMutex
 }
 
-func NewDiskFileService(uploadPath string, metaRepo file.FileMetaRepository, transaction *tx.Manager, logger *slog.Logger) (*DiskFileService, error) {
+// NewDiskFileService creates a service storing file content in blobs, the
+// upload path on the local disk is used to stage upload sessions. Uploads are
+// accepted according to the policy and have metadata stripped from images
+// by default when stripMetadata is set, thumbnails of uploaded images are
+// made by the thumbnailer and transformed images by the transformer.
+// Challenges of uploads by reference are signed with the reference secret,
+// empty means a random one only this service accepts.
+func NewDiskFileService(uploadPath string, blobs file.BlobStore, metaRepo file.FileMetaRepository, policy file.UploadPolicy, stripMetadata bool, referenceSecret []byte, thumbnails *Thumbnailer, transforms *Transformer, transaction *tx.Manager, logger *slog.Logger) (*DiskFileService, error) {
 	if uploadPath == "" {
 		uploadPath = DEFAULT_FILES_UPLOAD_PATH
 	}
+	if len(referenceSecret) == 0 {
+		referenceSecret = make([]byte, sha256.Size)
+		if _, err := rand.Read(referenceSecret); err != nil {
+			return nil, err
+		}
+	}
 
-	if err := os.MkdirAll(uploadPath, 0755); err != nil {
+	if err := os.MkdirAll(filepath.Join(uploadPath, SESSIONS_DIR), 0755); err != nil {
 		return nil, err
 	}
 
 	return &DiskFileService{
-		uploadPath:  uploadPath,
-		meta:        metaRepo,
-		transaction: transaction,
-		logger:      logger,
+		uploadPath:      uploadPath,
+		blobs:           blobs,
+		meta:            metaRepo,
+		policy:          policy,
+		stripMetadata:   stripMetadata,
+		referenceSecret: referenceSecret,
+		thumbnails:      thumbnails,
+		transforms:      transforms,
+		transaction:     transaction,
+		logger:          logger,
 	}, nil
 }
 
-func (service *DiskFileService) UploadFile(ctx context.Context, fileName string, fileData []byte) (string, error) {
-	meta, err := file.NewFileMeta(uuid.New(), fileName, fileData)
+func (service *DiskFileService) UploadFile(ctx context.Context, fileName string, fileData []byte, options file.UploadOptions) (string, file.Checksums, error) {
+	return service.UploadFileStream(ctx, fileName, bytes.NewReader(fileData), options)
+}
+
+func (service *DiskFileService) UploadFileStream(ctx context.Context, fileName string, content io.Reader, options file.UploadOptions) (string, file.Checksums, error) {
+	if fileName == "" {
+		return "", file.Checksums{}, file.ErrFileNameEmpty
+	}
+	if err := options.Checksums.Validate(); err != nil {
+		return "", file.Checksums{}, err
+	}
+
+	// The policy is applied before the rest of the content is received.
+	head := make([]byte, file.SNIFF_SIZE)
+	n, err := io.ReadFull(content, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", file.Checksums{}, err
+	}
+	head = head[:n]
+	if len(head) == 0 {
+		return "", file.Checksums{}, file.ErrFileEmpty
+	}
+	contentType, err := service.checkContent(fileName, head)
 	if err != nil {
-		return "", err
+		return "", file.Checksums{}, err
 	}
-	file, err := file.NewFile(fileData, meta)
+
+	content = io.MultiReader(bytes.NewReader(head), content)
+	hasher := file.NewHasher()
+	strip, err := stripsContent(service.stripsMetadata(options), options.StripMetadata != nil && *options.StripMetadata, contentType)
+	if err != nil {
+		return "", file.Checksums{}, err
+	}
+
+	var meta *file.FileMeta
+	if strip {
+		meta, err = service.spoolStripped(ctx, fileName, contentType, content, hasher, options)
+	} else {
+		meta, err = service.storeContent(ctx, fileName, contentType, content, hasher, options.Checksums)
+	}
+	if err != nil {
+		return "", file.Checksums{}, err
+	}
+	service.enqueueThumbnails(meta)
+
+	return meta.ID.String(), hasher.Checksums(), nil
+}
+
+// receive copies the uploaded content to dst and the hasher, verifying it
+// against the checksums expected by the client.
+func receive(dst io.Writer, content io.Reader, hasher *file.Hasher, expected file.Checksums) error {
+	if _, err := io.Copy(io.MultiWriter(dst, hasher), io.LimitReader(content, MAX_FILE_SIZE+1)); err != nil {
+		return err
+	}
+	if hasher.Size() > MAX_FILE_SIZE {
+		return file.ErrFileTooLarge
+	}
+	return hasher.Verify(expected)
+}
+
+// storeContent stores the content as uploaded.
+func (service *DiskFileService) storeContent(ctx context.Context, fileName, contentType string, content io.Reader, hasher *file.Hasher, expected file.Checksums) (*file.FileMeta, error) {
+	writer, err := service.blobs.Create(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer writer.Abort()
+
+	imageHead := &headBuffer{}
+	if imaging.Supported(contentType) {
+		imageHead.limit = imaging.INSPECT_SIZE
+	}
+	if err := receive(io.MultiWriter(writer, imageHead), content, hasher, expected); err != nil {
+		return nil, err
+	}
+
+	meta, err := file.NewFileMeta(uuid.New(), fileName, hasher.Hash(), hasher.Size(), contentType)
+	if err != nil {
+		return nil, err
+	}
+	service.inspectImage(&meta, bytes.NewReader(imageHead.data))
+
+	err = service.storeFile(ctx, &meta, func(ctx context.Context) error {
+		return writer.Commit(ctx, meta.Hash)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+// spoolStripped stages the content on the local disk, as stripping metadata
+// may need to read it twice, and stores it stripped.
+func (service *DiskFileService) spoolStripped(ctx context.Context, fileName, contentType string, content io.Reader, hasher *file.Hasher, options file.UploadOptions) (*file.FileMeta, error) {
+	// Staged like the content of an upload session without the session info,
+	// so the leftovers of a crash are removed by CleanupUploads.
+	path := service.sessionContentPath(uuid.New())
+	staged, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(path)
+	defer staged.Close()
+
+	if err := receive(staged, content, hasher, options.Checksums); err != nil {
+		return nil, err
+	}
+	if _, err := staged.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	return service.storeStripped(ctx, fileName, contentType, staged, hasher.Hash(), options.KeepOriginal)
+}
+
+// storeStripped stores the staged image without metadata. The staged content
+// is stored too as the original of the file when keepOriginal is set.
+func (service *DiskFileService) storeStripped(ctx context.Context, fileName, contentType string, staged io.ReadSeeker, originalHash string, keepOriginal bool) (*file.FileMeta, error) {
+	writer, err := service.blobs.Create(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer writer.Abort()
+
+	hasher := file.NewHasher()
+	imageHead := &headBuffer{limit: imaging.INSPECT_SIZE}
+	if err := imaging.Strip(io.MultiWriter(writer, hasher, imageHead), staged, contentType); err != nil {
+		return nil, err
+	}
+
+	meta, err := file.NewFileMeta(uuid.New(), fileName, hasher.Hash(), hasher.Size(), contentType)
+	if err != nil {
+		return nil, err
+	}
+	service.inspectImage(&meta, bytes.NewReader(imageHead.data))
+	// Content without metadata has nothing to strip, so it is its own original.
+	if keepOriginal && originalHash != meta.Hash {
+		meta.OriginalHash = originalHash
+	}
+
+	err = service.storeFile(ctx, &meta, func(ctx context.Context) error {
+		if err := writer.Commit(ctx, meta.Hash); err != nil {
+			return err
+		}
+		if meta.OriginalHash == "" {
+			return nil
+		}
+
+		if _, err := staged.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		return service.blobs.Put(ctx, meta.OriginalHash, staged)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+// stripsMetadata reports whether metadata is stripped from images uploaded
+// with the options.
+func (service *DiskFileService) stripsMetadata(options file.UploadOptions) bool {
+	if options.StripMetadata != nil {
+		return *options.StripMetadata
+	}
+	return service.stripMetadata
+}
+
+// stripsContent reports whether metadata is stripped from content of the type.
+// Strip doesn't support every image type the policy may allow, their content
+// is stored as is unless stripping was requested by the upload.
+func stripsContent(strip, requested bool, contentType string) (bool, error) {
+	if imaging.StripSupported(contentType) {
+		return strip, nil
+	}
+	if requested {
+		return false, fmt.Errorf("%w: %s", file.ErrStripUnsupported, contentType)
+	}
+	return false, nil
+}
+
+// checkContent applies the upload policy to the leading bytes of the content
+// and returns its content type.
+func (service *DiskFileService) checkContent(fileName string, head []byte) (string, error) {
+	contentType, err := service.policy.Check(fileName, head)
 	if err != nil {
 		return "", err
 	}
+	if contentType == "" {
+		contentType = file.DetectContentType(head)
+	}
+	return contentType, nil
+}
 
-	if err := service.transaction.Do(ctx,
+// storeFile saves the metadata and calls store to put the content into the
+// blob store in a single transaction.
+func (service *DiskFileService) storeFile(ctx context.Context, meta *file.FileMeta, store func(ctx context.Context) error) error {
+	return service.transaction.Do(ctx,
 		func(ctx context.Context) error {
-			if err := service.meta.Save(ctx, &file.Meta); err != nil {
+			if err := service.lockContent(ctx, meta); err != nil {
+				return err
+			}
+
+			if err := service.meta.Save(ctx, meta); err != nil {
 				return err
 			}
 
-			if err := service.writeToDisk(ctx, file); err != nil {
+			if err := store(ctx); err != nil {
 				return err
 			}
 
 			return nil
 		},
-	); err != nil {
-		return "", err
-	}
+	)
+}
 
-	return file.Meta.ID.String(), nil
+// lockContent locks the hashes of all content of the file in the same order
+// for every transaction, so two of them never wait for each other.
+func (service *DiskFileService) lockContent(ctx context.Context, meta *file.FileMeta) error {
+	for _, hash := range slices.Sorted(slices.Values(meta.ContentHashes())) {
+		if err := service.meta.LockHash(ctx, hash); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (service *DiskFileService) writeToDisk(ctx context.Context, file *file.File) error {
-	path := createFilePath(service.uploadPath, file.Meta.Hash)
-	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
-		return err
+func (service *DiskFileService) DownloadFile(ctx context.Context, fileId string) (string, []byte, error) {
+	fileUUID, err := uuid.Parse(fileId)
+	if err != nil {
+		return "", nil, file.ErrFileIdEmpty
 	}
 
-	if err := os.WriteFile(path, file.Content, 0644); err != nil {
-		return err
+	meta, err := service.meta.FindById(ctx, fileUUID)
+	if err != nil {
+		return "", nil, err
 	}
 
-	return nil
-}
+	content, err := service.blobs.Get(ctx, meta.Hash, 0, 0)
+	if err != nil {
+		return "", nil, err
+	}
+	defer content.Close()
 
-func createFilePath(base, hash string) string {
-	return filepath.Join(base, hash[:2], hash[2:4], hash)
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return meta.Filename, data, nil
 }
 
-func (service *DiskFileService) DownloadFile(ctx context.Context, fileId string) (string, []byte, error) {
+func (service *DiskFileService) DownloadFileStream(ctx context.Context, fileId string, offset, length int64) (string, int64, io.ReadCloser, error) {
 	fileUUID, err := uuid.Parse(fileId)
 	if err != nil {
-		return "", nil, file.ErrFileIdEmpty
+		return "", 0, nil, file.ErrFileIdEmpty
 	}
 
 	meta, err := service.meta.FindById(ctx, fileUUID)
+	if err != nil {
+		return "", 0, nil, err
+	}
+
+	info, err := service.blobs.Stat(ctx, meta.Hash)
+	if err != nil {
+		return "", 0, nil, err
+	}
+
+	size := info.Size
+	if offset < 0 || length < 0 || offset >= size {
+		return "", 0, nil, file.ErrInvalidRange
+	}
+	if length == 0 || length > size-offset {
+		length = size - offset
+	}
+
+	content, err := service.blobs.Get(ctx, meta.Hash, offset, length)
+	if err != nil {
+		return "", 0, nil, err
+	}
+
+	return meta.Filename, size, content, nil
+}
+
+func (service *DiskFileService) GetFileMetadata(ctx context.Context, fileId string) (*file.FileMeta, error) {
+	fileUUID, err := uuid.Parse(fileId)
+	if err != nil {
+		return nil, file.ErrFileIdEmpty
+	}
+
+	return service.meta.FindById(ctx, fileUUID)
+}
+
+// GetThumbnail returns the content type and the content of the thumbnail of
+// the image file with the longest side of size pixels.
+func (service *DiskFileService) GetThumbnail(ctx context.Context, fileId string, size int) (string, []byte, error) {
+	meta, err := service.GetFileMetadata(ctx, fileId)
 	if err != nil {
 		return "", nil, err
 	}
+	if !imaging.Supported(meta.ContentType) {
+		return "", nil, file.ErrImageUnsupported
+	}
 
-	filePath := createFilePath(service.uploadPath, meta.Hash)
-	data, err := os.ReadFile(filePath)
+	data, err := service.thumbnails.Get(ctx, meta.Hash, size)
 	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return "", nil, file.ErrFileNotFound
-		}
 		return "", nil, err
 	}
 
-	return meta.Filename, data, nil
+	return file.DetectContentType(data), data, nil
 }
 
-func (service *DiskFileService) ViewFilesMetadata(ctx context.Context, page file.Page) ([]*file.FileMeta, error) {
-	files, err := service.meta.FindAll(ctx, page)
+// DownloadTransformed returns the content type and the content of the image
+// file transformed as described.
+func (service *DiskFileService) DownloadTransformed(ctx context.Context, fileId string, transform file.Transform) (string, []byte, error) {
+	meta, err := service.GetFileMetadata(ctx, fileId)
 	if err != nil {
-		return nil, err
+		return "", nil, err
+	}
+	if !imaging.Supported(meta.ContentType) {
+		return "", nil, file.ErrImageUnsupported
+	}
+
+	data, err := service.transforms.Get(ctx, meta.Hash, transform)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return file.DetectContentType(data), data, nil
+}
+
+// inspectImage records the image metadata read from the leading bytes of the
+// content, content that can't be read as an image gets none.
+func (service *DiskFileService) inspectImage(meta *file.FileMeta, head io.Reader) {
+	if !imaging.Supported(meta.ContentType) {
+		return
+	}
+
+	image, err := imaging.Inspect(head)
+	if err != nil {
+		service.logger.Warn("failed to read image metadata", "hash", meta.Hash, "error", err)
+		return
+	}
+	meta.Image = image
+}
+
+// headBuffer keeps up to limit leading bytes written to it.
+type headBuffer struct {
+	data  []byte
+	limit int
+}
+
+func (b *headBuffer) Write(p []byte) (int, error) {
+	b.data = append(b.data, p[:min(len(p), b.limit-len(b.data))]...)
+	return len(p), nil
+}
+
+func (service *DiskFileService) enqueueThumbnails(meta *file.FileMeta) {
+	if imaging.Supported(meta.ContentType) {
+		service.thumbnails.Enqueue(meta.Hash)
+	}
+}
+
+func (service *DiskFileService) DeleteFile(ctx context.Context, fileId string) error {
+	fileUUID, err := uuid.Parse(fileId)
+	if err != nil {
+		return file.ErrFileIdEmpty
+	}
+
+	var unreferenced []string
+	err = service.transaction.Do(ctx,
+		func(ctx context.Context) error {
+			meta, err := service.meta.FindById(ctx, fileUUID)
+			if err != nil {
+				return err
+			}
+
+			// Keeps uploads of the same content from writing the blob while
+			// it is being removed.
+			if err := service.lockContent(ctx, meta); err != nil {
+				return err
+			}
+
+			if err := service.meta.Delete(ctx, meta.ID); err != nil {
+				return err
+			}
+
+			for _, hash := range meta.ContentHashes() {
+				references, err := service.meta.CountByHash(ctx, hash)
+				if err != nil {
+					return err
+				}
+				if references == 0 {
+					unreferenced = append(unreferenced, hash)
+				}
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	// Content is removed only after the metadata delete is committed, each
+	// key rechecked under its lock like by the garbage collector, which also
+	// removes whatever is left here and derived content of other variants.
+	for _, hash := range unreferenced {
+		for _, key := range append(service.thumbnails.Keys(hash), hash) {
+			if _, err := service.removeGarbage(ctx, key, false); err != nil {
+				service.logger.Error("failed to remove content of deleted file", "key", key, "error", err)
+			}
+		}
+	}
+	return nil
+}
+
+func (service *DiskFileService) ViewFilesMetadata(ctx context.Context, filter file.Filter, sort file.Sort, page file.Page) ([]*file.FileMeta, string, error) {
+	files, err := service.meta.FindAll(ctx, filter, sort, page)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// A short page is the last one, a full one may be followed by an empty page.
+	if len(files) < page.Size {
+		return files, "", nil
 	}
 
-	return files, nil
+	return files, file.CursorOf(files[len(files)-1], sort).Token(), nil
 }
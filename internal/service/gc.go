@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"file-service/internal/file"
+)
+
+type GarbageCollectorOptions struct {
+	// GracePeriod protects recently written content whose metadata may not
+	// be committed yet.
+	GracePeriod time.Duration
+	// DryRun reports the garbage without removing it.
+	DryRun bool
+}
+
+type GarbageReport struct {
+	Scanned      int
+	Removed      []string // keys of the removed content
+	RemovedBytes int64
+	RemovedTemp  int // abandoned spool files
+}
+
+// tempCleaner is implemented by blob stores spooling content to temporary
+// files that can be left behind by a crash.
+type tempCleaner interface {
+	RemoveStaleTemp(olderThan time.Duration, dryRun bool) (int, error)
+}
+
+// CollectGarbage removes content that is not referenced by any file metadata
+// and abandoned spool files, both older than the grace period.
+func (service *DiskFileService) CollectGarbage(ctx context.Context, options GarbageCollectorOptions) (*GarbageReport, error) {
+	report := &GarbageReport{}
+	cutoff := time.Now().Add(-options.GracePeriod)
+
+	hashes, err := service.meta.FindAllHashes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	referenced := make(map[string]struct{}, len(hashes))
+	for _, hash := range hashes {
+		referenced[hash] = struct{}{}
+	}
+
+	err = service.blobs.List(ctx, func(info file.BlobInfo) error {
+		report.Scanned++
+		// Derived content lives as long as its source.
+		if _, ok := referenced[file.SourceHash(info.Hash)]; ok || info.ModTime.After(cutoff) {
+			return nil
+		}
+
+		removed, err := service.removeGarbage(ctx, info.Hash, options.DryRun)
+		if err != nil {
+			return err
+		}
+		if removed {
+			report.Removed = append(report.Removed, info.Hash)
+			report.RemovedBytes += info.Size
+			if options.DryRun {
+				service.logger.Info("would remove unreferenced content", "hash", info.Hash, "size", info.Size)
+			} else {
+				service.logger.Info("removed unreferenced content", "hash", info.Hash, "size", info.Size)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	if cleaner, ok := service.blobs.(tempCleaner); ok {
+		report.RemovedTemp, err = cleaner.RemoveStaleTemp(options.GracePeriod, options.DryRun)
+		if err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+// removeGarbage rechecks that the content is still unreferenced while holding
+// the hash lock, since a file could have been saved after the mark phase.
+func (service *DiskFileService) removeGarbage(ctx context.Context, key string, dryRun bool) (bool, error) {
+	hash := file.SourceHash(key)
+	removed := false
+	err := service.transaction.Do(ctx,
+		func(ctx context.Context) error {
+			if err := service.meta.LockHash(ctx, hash); err != nil {
+				return err
+			}
+
+			references, err := service.meta.CountByHash(ctx, hash)
+			if err != nil {
+				return err
+			}
+			if references > 0 {
+				return nil
+			}
+
+			if !dryRun {
+				if err := service.blobs.Delete(ctx, key); err != nil {
+					return err
+				}
+			}
+			removed = true
+
+			return nil
+		},
+	)
+	return removed, err
+}
+
+// RunGarbageCollector calls CollectGarbage every interval until ctx is done.
+func (service *DiskFileService) RunGarbageCollector(ctx context.Context, interval time.Duration, options GarbageCollectorOptions) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := service.CollectGarbage(ctx, options)
+			if err != nil {
+				service.logger.Error("failed to collect garbage", "error", err)
+				continue
+			}
+			service.logger.Info("garbage collection finished",
+				"scanned", report.Scanned,
+				"removed", len(report.Removed),
+				"removed_bytes", report.RemovedBytes,
+				"removed_temp", report.RemovedTemp,
+				"dry_run", options.DryRun,
+			)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	tx "github.com/avito-tech/go-transaction-manager/trm/v2/manager"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/semaphore"
+
+	"file-service/internal/file"
+	"file-service/internal/policy"
+	"file-service/internal/storage/disk"
+	"file-service/internal/storage/memory"
+)
+
+func newTestService(t *testing.T, logs *bytes.Buffer) (*DiskFileService, *disk.BlobStorage) {
+	path := t.TempDir()
+	blobs, err := disk.NewBlobStorage(path)
+	require.NoError(t, err)
+	uploadPolicy, err := policy.New([]string{policy.ANY_TYPE})
+	require.NoError(t, err)
+	logger := slog.New(slog.NewTextHandler(logs, nil))
+	decodes := semaphore.NewWeighted(IMAGE_DECODES)
+	thumbnails, err := NewThumbnailer(blobs, []int{64}, decodes, logger)
+	require.NoError(t, err)
+	transforms := NewTransformer(blobs, TRANSFORM_CACHE_SIZE, decodes, logger)
+
+	service, err := NewDiskFileService(path, blobs, memory.New(), uploadPolicy, false, nil, thumbnails, transforms, tx.Must(memory.NewDefaultFactory()), logger)
+	require.NoError(t, err)
+	return service, blobs
+}
+
+func TestDiskFileService_CollectGarbage(t *testing.T) {
+	ctx := context.Background()
+	logs := &bytes.Buffer{}
+	service, blobs := newTestService(t, logs)
+	_, err := blobs.Create(ctx)
+	require.NoError(t, err)
+
+	_, checksums, err := service.UploadFile(ctx, "kept.txt", []byte("referenced content"), file.UploadOptions{})
+	require.NoError(t, err)
+	kept := checksums.SHA256
+	derived := file.DerivedKey(kept, "thumb64")
+	require.NoError(t, blobs.Put(ctx, derived, strings.NewReader("derived content")))
+
+	hasher := file.NewHasher()
+	hasher.Write([]byte("garbage"))
+	garbage := hasher.Hash()
+	require.NoError(t, blobs.Put(ctx, garbage, strings.NewReader("garbage")))
+
+	exists := func(key string) bool {
+		_, err := blobs.Stat(ctx, key)
+		return err == nil
+	}
+
+	t.Run("Grace period", func(t *testing.T) {
+		report, err := service.CollectGarbage(ctx, GarbageCollectorOptions{GracePeriod: time.Hour})
+		require.NoError(t, err)
+		require.Equal(t, 3, report.Scanned)
+		require.Empty(t, report.Removed)
+		require.Zero(t, report.RemovedTemp)
+		require.True(t, exists(garbage))
+	})
+
+	t.Run("Dry run", func(t *testing.T) {
+		logs.Reset()
+		report, err := service.CollectGarbage(ctx, GarbageCollectorOptions{DryRun: true})
+		require.NoError(t, err)
+		require.Equal(t, []string{garbage}, report.Removed)
+		require.Equal(t, int64(len("garbage")), report.RemovedBytes)
+		require.Equal(t, 1, report.RemovedTemp)
+		require.True(t, exists(garbage))
+		require.Contains(t, logs.String(), "would remove unreferenced content")
+	})
+
+	t.Run("References", func(t *testing.T) {
+		report, err := service.CollectGarbage(ctx, GarbageCollectorOptions{})
+		require.NoError(t, err)
+		require.Equal(t, []string{garbage}, report.Removed)
+		require.Equal(t, 1, report.RemovedTemp)
+		require.False(t, exists(garbage))
+		// Derived content lives as long as its source.
+		require.True(t, exists(kept))
+		require.True(t, exists(derived))
+	})
+}
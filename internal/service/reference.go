@@ -0,0 +1,184 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+
+	"file-service/internal/file"
+	"file-service/internal/imaging"
+)
+
+const (
+	// REFERENCE_CHALLENGE_TTL is how long a challenge returned by
+	// CheckContent is accepted.
+	REFERENCE_CHALLENGE_TTL = 10 * time.Minute
+	REFERENCE_NONCE_SIZE    = 16
+)
+
+// CheckContent returns a challenge to prove possession of the content of the
+// hash to UploadByReference. The challenge is the expiry time, a random nonce
+// and their signature, it is returned whether the content is stored or not,
+// so nobody can find out what is stored without having it.
+func (service *DiskFileService) CheckContent(ctx context.Context, hash string) ([]byte, error) {
+	if err := file.ValidateHash(hash); err != nil {
+		return nil, err
+	}
+
+	challenge := binary.BigEndian.AppendUint64(nil, uint64(time.Now().Add(REFERENCE_CHALLENGE_TTL).Unix()))
+	nonce := make([]byte, REFERENCE_NONCE_SIZE)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	challenge = append(challenge, nonce...)
+	return append(challenge, service.signChallenge(hash, challenge)...), nil
+}
+
+// UploadByReference stores a file with the content already stored under the
+// hash, returning the file id. The proof is the HMAC-SHA256 of the content
+// keyed with a challenge of CheckContent for the hash. On ErrBlobNotFound the
+// content has to be uploaded, it is returned for a wrong proof too.
+func (service *DiskFileService) UploadByReference(ctx context.Context, fileName string, hash string, challenge, proof []byte, options file.UploadOptions) (string, error) {
+	if fileName == "" {
+		return "", file.ErrFileNameEmpty
+	}
+	if err := file.ValidateHash(hash); err != nil {
+		return "", err
+	}
+	if err := service.checkChallenge(hash, challenge); err != nil {
+		return "", err
+	}
+
+	// Original content kept next to stripped content holds the metadata
+	// stripped from it, so it is only available to the files it was kept by.
+	references, err := service.meta.CountByHash(ctx, hash)
+	if err != nil {
+		return "", err
+	}
+	contents, err := service.meta.CountByContentHash(ctx, hash)
+	if err != nil {
+		return "", err
+	}
+	if references > 0 && contents == 0 {
+		return "", fmt.Errorf("%w: original content", file.ErrBlobNotFound)
+	}
+
+	if err := service.proveContent(ctx, hash, challenge, proof); err != nil {
+		return "", err
+	}
+
+	content, err := service.blobs.Get(ctx, hash, 0, 0)
+	if err != nil {
+		return "", err
+	}
+	defer content.Close()
+
+	// The content is checked like an upload of it.
+	head, err := io.ReadAll(io.LimitReader(content, imaging.INSPECT_SIZE))
+	if err != nil {
+		return "", err
+	}
+	if len(head) == 0 {
+		return "", file.ErrFileEmpty
+	}
+	contentType, err := service.checkContent(fileName, head[:min(len(head), file.SNIFF_SIZE)])
+	if err != nil {
+		return "", err
+	}
+
+	strip, err := stripsContent(service.stripsMetadata(options), options.StripMetadata != nil && *options.StripMetadata, contentType)
+	if err != nil {
+		return "", err
+	}
+
+	var meta *file.FileMeta
+	if strip {
+		options.Checksums = file.Checksums{SHA256: hash}
+		meta, err = service.spoolStripped(ctx, fileName, contentType, io.MultiReader(bytes.NewReader(head), content), file.NewHasher(), options)
+	} else {
+		meta, err = service.storeReference(ctx, fileName, contentType, hash, head)
+	}
+	if err != nil {
+		return "", err
+	}
+	service.enqueueThumbnails(meta)
+
+	return meta.ID.String(), nil
+}
+
+// storeReference saves the metadata of a file with stored content, the head
+// of the content is inspected for image metadata.
+func (service *DiskFileService) storeReference(ctx context.Context, fileName, contentType, hash string, head []byte) (*file.FileMeta, error) {
+	info, err := service.blobs.Stat(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	meta, err := file.NewFileMeta(uuid.New(), fileName, hash, info.Size, contentType)
+	if err != nil {
+		return nil, err
+	}
+	service.inspectImage(&meta, bytes.NewReader(head))
+
+	err = service.storeFile(ctx, &meta, func(ctx context.Context) error {
+		// The content could have been removed as garbage since it was read,
+		// the hash lock keeps it from being removed from now on.
+		_, err := service.blobs.Stat(ctx, hash)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+func (service *DiskFileService) signChallenge(hash string, challenge []byte) []byte {
+	mac := hmac.New(sha256.New, service.referenceSecret)
+	mac.Write([]byte(hash))
+	mac.Write(challenge)
+	return mac.Sum(nil)
+}
+
+// checkChallenge checks that the challenge was returned by CheckContent for
+// the hash and hasn't expired.
+func (service *DiskFileService) checkChallenge(hash string, challenge []byte) error {
+	if len(challenge) != 8+REFERENCE_NONCE_SIZE+sha256.Size {
+		return file.ErrChallengeInvalid
+	}
+	signed, signature := challenge[:8+REFERENCE_NONCE_SIZE], challenge[8+REFERENCE_NONCE_SIZE:]
+	if !hmac.Equal(signature, service.signChallenge(hash, signed)) {
+		return file.ErrChallengeInvalid
+	}
+	if time.Now().Unix() > int64(binary.BigEndian.Uint64(signed)) {
+		return fmt.Errorf("%w: expired", file.ErrChallengeInvalid)
+	}
+	return nil
+}
+
+// proveContent checks that the proof is the HMAC-SHA256 of the stored content
+// keyed with the challenge.
+func (service *DiskFileService) proveContent(ctx context.Context, hash string, challenge, proof []byte) error {
+	content, err := service.blobs.Get(ctx, hash, 0, 0)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	mac := hmac.New(sha256.New, challenge)
+	if _, err := io.Copy(mac, content); err != nil {
+		return err
+	}
+	if !hmac.Equal(proof, mac.Sum(nil)) {
+		// Not told apart from missing content.
+		return fmt.Errorf("%w: proof doesn't match", file.ErrBlobNotFound)
+	}
+	return nil
+}
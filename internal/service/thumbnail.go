@@ -0,0 +1,218 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"golang.org/x/sync/semaphore"
+	"golang.org/x/sync/singleflight"
+
+	"file-service/internal/file"
+	"file-service/internal/imaging"
+)
+
+const (
+	// THUMBNAIL_QUEUE_SIZE is the number of uploads waiting for thumbnails,
+	// uploads over it get their thumbnails on demand.
+	THUMBNAIL_QUEUE_SIZE = 100
+	THUMBNAIL_WORKERS    = 2
+	// IMAGE_DECODES limits the images decoded at once for thumbnails and
+	// transformations by default. A decoded image takes up to 4 bytes per
+	// pixel and as much again while it is oriented, up to 800 MB at
+	// imaging.MAX_PIXELS.
+	IMAGE_DECODES = 2
+)
+
+// DEFAULT_THUMBNAIL_SIZES are the longest sides of thumbnails in pixels.
+var DEFAULT_THUMBNAIL_SIZES = []int{64, 256, 1024}
+
+// Thumbnailer stores thumbnails of images as blobs derived from the image
+// content, so files with the same content share them.
+type Thumbnailer struct {
+	blobs  file.BlobStore
+	sizes  []int
+	logger *slog.Logger
+
+	queue   chan string
+	group   singleflight.Group
+	workers sync.WaitGroup
+	decodes *semaphore.Weighted
+}
+
+// NewThumbnailer creates a thumbnailer holding a slot of decodes while an
+// image is decoded, the slots are shared with the Transformer.
+func NewThumbnailer(blobs file.BlobStore, sizes []int, decodes *semaphore.Weighted, logger *slog.Logger) (*Thumbnailer, error) {
+	const op = "service.NewThumbnailer"
+
+	for _, size := range sizes {
+		if size <= 0 {
+			return nil, fmt.Errorf("%s: invalid thumbnail size %d", op, size)
+		}
+	}
+
+	return &Thumbnailer{
+		blobs:   blobs,
+		sizes:   sizes,
+		logger:  logger,
+		queue:   make(chan string, THUMBNAIL_QUEUE_SIZE),
+		decodes: decodes,
+	}, nil
+}
+
+// Run generates the thumbnails of enqueued content with the number of
+// workers until ctx is done, see Wait.
+func (t *Thumbnailer) Run(ctx context.Context, workers int) {
+	for range workers {
+		t.workers.Add(1)
+		go func() {
+			defer t.workers.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case hash := <-t.queue:
+					if err := t.generateAll(ctx, hash); err != nil {
+						t.logger.Warn("failed to generate thumbnails", "hash", hash, "error", err)
+					}
+				}
+			}
+		}()
+	}
+}
+
+// Wait blocks until the workers started by Run finish the thumbnails they
+// are generating and return.
+func (t *Thumbnailer) Wait() {
+	t.workers.Wait()
+}
+
+// Enqueue schedules generation of the thumbnails of the content. It never
+// blocks, when the queue is full the thumbnails are generated on demand.
+func (t *Thumbnailer) Enqueue(hash string) {
+	select {
+	case t.queue <- hash:
+	default:
+		t.logger.Warn("thumbnail queue is full", "hash", hash)
+	}
+}
+
+// Get returns the thumbnail of the content, generating it if it is missing.
+func (t *Thumbnailer) Get(ctx context.Context, hash string, size int) ([]byte, error) {
+	if !slices.Contains(t.sizes, size) {
+		return nil, file.ErrThumbnailSizeInvalid
+	}
+
+	key := thumbnailKey(hash, size)
+	data, err := readBlob(ctx, t.blobs, key)
+	if !errors.Is(err, file.ErrBlobNotFound) {
+		return data, err
+	}
+
+	// Acquired before joining the generation, so whoever generates it holds
+	// a decode slot and never waits for one.
+	if err := t.decodes.Acquire(ctx, 1); err != nil {
+		return nil, err
+	}
+	defer t.decodes.Release(1)
+
+	result, err, _ := t.group.Do(key, func() (any, error) {
+		// Shared by concurrent callers, so not cancelled with one of them.
+		ctx := context.WithoutCancel(ctx)
+
+		img, err := decodeBlob(ctx, t.blobs, hash)
+		if err != nil {
+			return nil, err
+		}
+		return t.generate(ctx, img, hash, size)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]byte), nil
+}
+
+// Keys returns the blob keys of all thumbnails of the content.
+func (t *Thumbnailer) Keys(hash string) []string {
+	keys := make([]string, len(t.sizes))
+	for i, size := range t.sizes {
+		keys[i] = thumbnailKey(hash, size)
+	}
+	return keys
+}
+
+// generateAll decodes the image once for all missing thumbnails.
+func (t *Thumbnailer) generateAll(ctx context.Context, hash string) error {
+	var img image.Image
+	for _, size := range t.sizes {
+		key := thumbnailKey(hash, size)
+		if _, err := t.blobs.Stat(ctx, key); !errors.Is(err, file.ErrBlobNotFound) {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if img == nil {
+			// Held while the decoded image is used.
+			if err := t.decodes.Acquire(ctx, 1); err != nil {
+				return err
+			}
+			defer t.decodes.Release(1)
+
+			var err error
+			if img, err = decodeBlob(ctx, t.blobs, hash); err != nil {
+				return err
+			}
+		}
+
+		_, err, _ := t.group.Do(key, func() (any, error) {
+			return t.generate(ctx, img, hash, size)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Thumbnailer) generate(ctx context.Context, img image.Image, hash string, size int) ([]byte, error) {
+	data, err := imaging.Thumbnail(img, size)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.blobs.Put(ctx, thumbnailKey(hash, size), bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func thumbnailKey(hash string, size int) string {
+	return file.DerivedKey(hash, fmt.Sprintf("thumb%d", size))
+}
+
+func decodeBlob(ctx context.Context, blobs file.BlobStore, hash string) (image.Image, error) {
+	content, err := blobs.Get(ctx, hash, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	return imaging.Decode(content)
+}
+
+func readBlob(ctx context.Context, blobs file.BlobStore, key string) ([]byte, error) {
+	content, err := blobs.Get(ctx, key, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	return io.ReadAll(content)
+}
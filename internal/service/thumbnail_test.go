@@ -0,0 +1,59 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"file-service/internal/file"
+)
+
+func TestThumbnailer_Get(t *testing.T) {
+	ctx := context.Background()
+	service, blobs := newTestService(t, &bytes.Buffer{})
+	thumbnails := service.thumbnails
+
+	hash := putOrientedJPEG(t, blobs)
+
+	t.Run("Orientation", func(t *testing.T) {
+		data, err := thumbnails.Get(ctx, hash, 64)
+		require.NoError(t, err)
+		config, _, err := image.DecodeConfig(bytes.NewReader(data))
+		require.NoError(t, err)
+		require.Equal(t, 32, config.Width)
+		require.Equal(t, 64, config.Height)
+	})
+
+	t.Run("Decodes are limited", func(t *testing.T) {
+		require.NoError(t, blobs.Delete(ctx, thumbnailKey(hash, 64)))
+		require.NoError(t, thumbnails.decodes.Acquire(ctx, IMAGE_DECODES))
+		defer thumbnails.decodes.Release(IMAGE_DECODES)
+
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		_, err := thumbnails.Get(ctx, hash, 64)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+// putOrientedJPEG stores a 128x64 JPEG shown as 64x128 by its EXIF
+// orientation and returns its hash.
+func putOrientedJPEG(t *testing.T, blobs file.BlobStore) string {
+	var encoded bytes.Buffer
+	require.NoError(t, jpeg.Encode(&encoded, image.NewGray(image.Rect(0, 0, 128, 64)), nil))
+	exif := []byte("Exif\x00\x00MM\x00*\x00\x00\x00\x08\x00\x01\x01\x12\x00\x03\x00\x00\x00\x01\x00\x06\x00\x00\x00\x00\x00\x00")
+	content := append([]byte{0xFF, 0xD8, 0xFF, 0xE1}, binary.BigEndian.AppendUint16(nil, uint16(len(exif)+2))...)
+	content = append(append(content, exif...), encoded.Bytes()[2:]...)
+
+	hasher := file.NewHasher()
+	hasher.Write(content)
+	hash := hasher.Hash()
+	require.NoError(t, blobs.Put(context.Background(), hash, bytes.NewReader(content)))
+	return hash
+}
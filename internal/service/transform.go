@@ -0,0 +1,197 @@
+package service
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/semaphore"
+	"golang.org/x/sync/singleflight"
+
+	"file-service/internal/file"
+	"file-service/internal/imaging"
+)
+
+// TRANSFORM_CACHE_SIZE limits the total size of stored transformed images in
+// bytes, the least recently used ones are removed over it.
+const TRANSFORM_CACHE_SIZE = 1 << 30
+
+// Transformer stores transformed images as blobs derived from the image
+// content, so repeated requests for the same variant are served from the
+// blob store. Variants over the cache size are removed least recently used
+// first, variants of deleted files are removed by the garbage collector.
+//
+// The use of variants is tracked by each Transformer, replicas sharing the
+// blob store keep their own order and size.
+type Transformer struct {
+	blobs     file.BlobStore
+	cacheSize int64
+	logger    *slog.Logger
+
+	group   singleflight.Group
+	decodes *semaphore.Weighted
+
+	mu sync.Mutex
+	// variants holds the cachedVariant of stored variants from the most to
+	// the least recently used, it is loaded from the blob store by the first
+	// Get.
+	variants *list.List
+	elements map[string]*list.Element
+	size     int64
+}
+
+type cachedVariant struct {
+	key  string
+	size int64
+}
+
+// NewTransformer creates a transformer holding a slot of decodes while an
+// image is decoded, see NewThumbnailer.
+func NewTransformer(blobs file.BlobStore, cacheSize int64, decodes *semaphore.Weighted, logger *slog.Logger) *Transformer {
+	return &Transformer{
+		blobs:     blobs,
+		cacheSize: cacheSize,
+		logger:    logger,
+		decodes:   decodes,
+	}
+}
+
+// Get returns the transformed image of the content, generating it if it is
+// missing.
+func (t *Transformer) Get(ctx context.Context, hash string, transform file.Transform) ([]byte, error) {
+	if err := t.load(ctx); err != nil {
+		return nil, err
+	}
+
+	key := file.DerivedKey(hash, transform.Variant())
+	data, err := readBlob(ctx, t.blobs, key)
+	if err == nil {
+		t.use(ctx, key, int64(len(data)))
+		return data, nil
+	}
+	if !errors.Is(err, file.ErrBlobNotFound) {
+		return nil, err
+	}
+
+	// Acquired before joining the generation, so whoever generates it holds
+	// a decode slot and never waits for one.
+	if err := t.decodes.Acquire(ctx, 1); err != nil {
+		return nil, err
+	}
+	defer t.decodes.Release(1)
+
+	result, err, _ := t.group.Do(key, func() (any, error) {
+		// Shared by concurrent callers, so not cancelled with one of them.
+		ctx := context.WithoutCancel(ctx)
+
+		img, err := decodeBlob(ctx, t.blobs, hash)
+		if err != nil {
+			return nil, err
+		}
+		data, err := imaging.Transform(img, transform)
+		if err != nil {
+			return nil, err
+		}
+		if err := t.blobs.Put(ctx, key, bytes.NewReader(data)); err != nil {
+			return nil, err
+		}
+		t.use(ctx, key, int64(len(data)))
+		return data, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]byte), nil
+}
+
+// load indexes the variants stored before the first Get, the least recently
+// written ones are taken as the least recently used.
+func (t *Transformer) load(ctx context.Context) error {
+	t.mu.Lock()
+	if t.variants != nil {
+		t.mu.Unlock()
+		return nil
+	}
+
+	var stored []file.BlobInfo
+	err := t.blobs.List(ctx, func(info file.BlobInfo) error {
+		if isTransformKey(info.Hash) {
+			stored = append(stored, info)
+		}
+		return nil
+	})
+	if err != nil {
+		t.mu.Unlock()
+		return err
+	}
+	slices.SortFunc(stored, func(a, b file.BlobInfo) int {
+		return a.ModTime.Compare(b.ModTime)
+	})
+
+	t.variants = list.New()
+	t.elements = make(map[string]*list.Element, len(stored))
+	for _, info := range stored {
+		t.elements[info.Hash] = t.variants.PushFront(&cachedVariant{key: info.Hash, size: info.Size})
+		t.size += info.Size
+	}
+	evicted := t.evict()
+	t.mu.Unlock()
+
+	t.remove(ctx, evicted)
+	return nil
+}
+
+// use marks the stored variant as the most recently used and removes the
+// least recently used ones over the cache size.
+func (t *Transformer) use(ctx context.Context, key string, size int64) {
+	t.mu.Lock()
+	if element, ok := t.elements[key]; ok {
+		variant := element.Value.(*cachedVariant)
+		t.size += size - variant.size
+		variant.size = size
+		t.variants.MoveToFront(element)
+	} else {
+		t.elements[key] = t.variants.PushFront(&cachedVariant{key: key, size: size})
+		t.size += size
+	}
+	evicted := t.evict()
+	t.mu.Unlock()
+
+	t.remove(ctx, evicted)
+}
+
+// evict drops the least recently used variants over the cache size from the
+// index and returns their keys, the most recently used one is always kept.
+// It must be called with mu held.
+func (t *Transformer) evict() []string {
+	var evicted []string
+	for t.size > t.cacheSize && t.variants.Len() > 1 {
+		variant := t.variants.Remove(t.variants.Back()).(*cachedVariant)
+		delete(t.elements, variant.key)
+		t.size -= variant.size
+		evicted = append(evicted, variant.key)
+	}
+	return evicted
+}
+
+func (t *Transformer) remove(ctx context.Context, keys []string) {
+	// Not cancelled with the request the variants were evicted by.
+	ctx = context.WithoutCancel(ctx)
+	for _, key := range keys {
+		if err := t.blobs.Delete(ctx, key); err != nil {
+			t.logger.Warn("failed to remove transformed image", "key", key, "error", err)
+		}
+	}
+}
+
+// isTransformKey reports whether the blob key is of a transformed image, see
+// file.Transform.Variant.
+func isTransformKey(key string) bool {
+	_, variant, derived := strings.Cut(key, "_")
+	return derived && strings.HasPrefix(variant, "t-")
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/semaphore"
+
+	"file-service/internal/file"
+)
+
+func TestTransformer_Get(t *testing.T) {
+	ctx := context.Background()
+	service, blobs := newTestService(t, &bytes.Buffer{})
+	hash := putOrientedJPEG(t, blobs)
+
+	t.Run("Orientation", func(t *testing.T) {
+		transforms := service.transforms
+		// The crop and the box are of the image as shown.
+		crop := image.Rect(0, 64, 64, 128)
+		transform, err := file.NewTransform(&crop, 0, 32, "", file.FORMAT_PNG, 0)
+		require.NoError(t, err)
+
+		data, err := transforms.Get(ctx, hash, transform)
+		require.NoError(t, err)
+		config, _, err := image.DecodeConfig(bytes.NewReader(data))
+		require.NoError(t, err)
+		require.Equal(t, 32, config.Width)
+		require.Equal(t, 32, config.Height)
+	})
+
+	t.Run("Decodes are shared with thumbnails", func(t *testing.T) {
+		transform, err := file.NewTransform(nil, 16, 0, "", "", 0)
+		require.NoError(t, err)
+		require.NoError(t, service.thumbnails.decodes.Acquire(ctx, IMAGE_DECODES))
+		defer service.thumbnails.decodes.Release(IMAGE_DECODES)
+
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		_, err = service.transforms.Get(ctx, hash, transform)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("Least recently used are removed", func(t *testing.T) {
+		var transforms []file.Transform
+		var keys []string
+		for _, width := range []int{24, 32, 40} {
+			transform, err := file.NewTransform(nil, width, 0, "", file.FORMAT_PNG, 0)
+			require.NoError(t, err)
+			transforms = append(transforms, transform)
+			keys = append(keys, file.DerivedKey(hash, transform.Variant()))
+		}
+		data, err := NewTransformer(blobs, TRANSFORM_CACHE_SIZE, semaphore.NewWeighted(IMAGE_DECODES), slog.Default()).Get(ctx, hash, transforms[2])
+		require.NoError(t, err)
+		require.NoError(t, blobs.Delete(ctx, keys[2]))
+
+		// Stored before, so loaded by the first Get.
+		require.NoError(t, blobs.Put(ctx, keys[0], bytes.NewReader(make([]byte, 10))))
+		require.NoError(t, blobs.Put(ctx, keys[1], bytes.NewReader(make([]byte, 10))))
+		cache := NewTransformer(blobs, int64(len(data))+10, semaphore.NewWeighted(IMAGE_DECODES), slog.Default())
+
+		_, err = cache.Get(ctx, hash, transforms[0])
+		require.NoError(t, err)
+		_, err = cache.Get(ctx, hash, transforms[2])
+		require.NoError(t, err)
+
+		_, err = blobs.Stat(ctx, keys[0])
+		require.NoError(t, err)
+		_, err = blobs.Stat(ctx, keys[1])
+		require.ErrorIs(t, err, file.ErrBlobNotFound)
+		_, err = blobs.Stat(ctx, keys[2])
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,403 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"file-service/internal/file"
+)
+
+const (
+	UPLOAD_SESSION_TTL      = 24 * time.Hour
+	UPLOAD_CLEANUP_INTERVAL = time.Hour
+
+	// SESSIONS_DIR is a directory inside the upload path where the content of
+	// upload sessions is staged until it is committed.
+	SESSIONS_DIR = "sessions"
+)
+
+// uploadSessionInfo is stored next to the staged content of a session. The
+// offset of the session is the size of the staged content and the session
+// expires UPLOAD_SESSION_TTL after the content was last written.
+type uploadSessionInfo struct {
+	Filename  string    `json:"filename"`
+	CreatedAt time.Time `json:"created_at"`
+	// StripMetadata is resolved from the upload options when the session
+	// starts, StripRequested is set when the options set it.
+	StripMetadata  bool `json:"strip_metadata,omitempty"`
+	StripRequested bool `json:"strip_requested,omitempty"`
+	KeepOriginal   bool `json:"keep_original,omitempty"`
+}
+
+func (service *DiskFileService) StartUpload(ctx context.Context, fileName string, options file.UploadOptions) (*file.UploadSession, error) {
+	if fileName == "" {
+		return nil, file.ErrFileNameEmpty
+	}
+	if err := service.policy.CheckName(fileName); err != nil {
+		return nil, err
+	}
+
+	id := uuid.New()
+	info, err := json.Marshal(uploadSessionInfo{
+		Filename:       fileName,
+		CreatedAt:      time.Now(),
+		StripMetadata:  service.stripsMetadata(options),
+		StripRequested: options.StripMetadata != nil && *options.StripMetadata,
+		KeepOriginal:   options.KeepOriginal,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.WriteFile(service.sessionContentPath(id), nil, 0644); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(service.sessionInfoPath(id), info, 0644); err != nil {
+		service.removeSession(id)
+		return nil, err
+	}
+
+	return service.findSession(id)
+}
+
+func (service *DiskFileService) AppendChunk(ctx context.Context, sessionId string, offset int64, chunk []byte) (*file.UploadSession, error) {
+	id, err := uuid.Parse(sessionId)
+	if err != nil {
+		return nil, file.ErrUploadNotFound
+	}
+
+	unlock := service.sessionLocks.Lock(id)
+	defer unlock()
+
+	session, err := service.findSession(id)
+	if err != nil {
+		return nil, err
+	}
+	if offset != session.Offset {
+		return session, file.ErrUploadOffsetMismatch
+	}
+	if session.Offset+int64(len(chunk)) > MAX_FILE_SIZE {
+		return session, file.ErrFileTooLarge
+	}
+
+	content, err := os.OpenFile(service.sessionContentPath(id), os.O_WRONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	if _, err := content.WriteAt(chunk, offset); err != nil {
+		// Drop a partially written chunk so the offset stays consistent.
+		content.Truncate(offset)
+		return nil, err
+	}
+	if err := content.Sync(); err != nil {
+		return nil, err
+	}
+
+	session.Offset += int64(len(chunk))
+	session.ExpiresAt = time.Now().Add(UPLOAD_SESSION_TTL)
+
+	return session, nil
+}
+
+func (service *DiskFileService) GetUploadStatus(ctx context.Context, sessionId string) (*file.UploadSession, error) {
+	id, err := uuid.Parse(sessionId)
+	if err != nil {
+		return nil, file.ErrUploadNotFound
+	}
+
+	unlock := service.sessionLocks.Lock(id)
+	defer unlock()
+
+	return service.findSession(id)
+}
+
+func (service *DiskFileService) CommitUpload(ctx context.Context, sessionId string, hash string) (string, error) {
+	id, err := uuid.Parse(sessionId)
+	if err != nil {
+		return "", file.ErrUploadNotFound
+	}
+	if err := file.ValidateHash(hash); err != nil {
+		return "", err
+	}
+
+	unlock := service.sessionLocks.Lock(id)
+	defer unlock()
+
+	session, info, err := service.loadSession(id)
+	if err != nil {
+		return "", err
+	}
+
+	contentPath := service.sessionContentPath(id)
+	hasher, err := hashFile(contentPath)
+	if err != nil {
+		return "", err
+	}
+	if err := hasher.Verify(file.Checksums{SHA256: hash}); err != nil {
+		return "", err
+	}
+	if hasher.Size() == 0 {
+		return "", file.ErrFileEmpty
+	}
+	contentType, err := service.checkContent(session.Filename, hasher.Head())
+	if err != nil {
+		return "", err
+	}
+
+	strip, err := stripsContent(info.StripMetadata, info.StripRequested, contentType)
+	if err != nil {
+		return "", err
+	}
+
+	var meta *file.FileMeta
+	if strip {
+		meta, err = service.commitStripped(ctx, session, contentPath, contentType, hash)
+	} else {
+		meta, err = service.commitStaged(ctx, session, contentPath, contentType, hasher)
+	}
+	if err != nil {
+		return "", err
+	}
+	service.enqueueThumbnails(meta)
+
+	service.removeSession(id)
+
+	return meta.ID.String(), nil
+}
+
+// blobMover is implemented by blob stores that can take over a staged file
+// without copying its content.
+type blobMover interface {
+	Move(ctx context.Context, hash string, path string) error
+}
+
+func (service *DiskFileService) commitStaged(ctx context.Context, session *file.UploadSession, contentPath, contentType string, hasher *file.Hasher) (*file.FileMeta, error) {
+	meta, err := file.NewFileMeta(uuid.New(), session.Filename, hasher.Hash(), hasher.Size(), contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.Open(contentPath)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	service.inspectImage(&meta, content)
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	err = service.storeFile(ctx, &meta, func(ctx context.Context) error {
+		if mover, ok := service.blobs.(blobMover); ok {
+			return mover.Move(ctx, meta.Hash, contentPath)
+		}
+		return service.blobs.Put(ctx, meta.Hash, content)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+func (service *DiskFileService) commitStripped(ctx context.Context, session *file.UploadSession, contentPath, contentType, hash string) (*file.FileMeta, error) {
+	content, err := os.Open(contentPath)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	return service.storeStripped(ctx, session.Filename, contentType, content, hash, session.Options.KeepOriginal)
+}
+
+// CleanupUploads removes the staged content of expired upload sessions and
+// returns the number of removed sessions.
+func (service *DiskFileService) CleanupUploads(ctx context.Context) (int, error) {
+	entries, err := os.ReadDir(filepath.Join(service.uploadPath, SESSIONS_DIR))
+	if err != nil {
+		return 0, err
+	}
+
+	lastActivity := make(map[string]time.Time)
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		if info.ModTime().After(lastActivity[name]) {
+			lastActivity[name] = info.ModTime()
+		}
+	}
+
+	removed := 0
+	for name, activity := range lastActivity {
+		if ctx.Err() != nil {
+			return removed, ctx.Err()
+		}
+		if time.Since(activity) < UPLOAD_SESSION_TTL {
+			continue
+		}
+		id, err := uuid.Parse(name)
+		if err != nil {
+			continue
+		}
+
+		// The session could have been resumed since the directory was read.
+		unlock := service.sessionLocks.Lock(id)
+		if _, err := service.findSession(id); errors.Is(err, file.ErrUploadNotFound) {
+			service.removeSession(id)
+			removed++
+		}
+		unlock()
+	}
+
+	return removed, nil
+}
+
+// RunUploadCleanup calls CleanupUploads every interval until ctx is done.
+func (service *DiskFileService) RunUploadCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			removed, err := service.CleanupUploads(ctx)
+			if err != nil {
+				service.logger.Error("failed to clean up upload sessions", "error", err)
+				continue
+			}
+			if removed > 0 {
+				service.logger.Info("removed expired upload sessions", "count", removed)
+			}
+		}
+	}
+}
+
+// findSession must be called with the session lock held.
+func (service *DiskFileService) findSession(id uuid.UUID) (*file.UploadSession, error) {
+	session, _, err := service.loadSession(id)
+	return session, err
+}
+
+// loadSession returns the session with its stored info, it must be called with
+// the session lock held.
+func (service *DiskFileService) loadSession(id uuid.UUID) (*file.UploadSession, uploadSessionInfo, error) {
+	var info uploadSessionInfo
+	data, err := os.ReadFile(service.sessionInfoPath(id))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, info, file.ErrUploadNotFound
+		}
+		return nil, info, err
+	}
+
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, info, err
+	}
+
+	content, err := os.Stat(service.sessionContentPath(id))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, info, file.ErrUploadNotFound
+		}
+		return nil, info, err
+	}
+
+	session := &file.UploadSession{
+		ID:        id,
+		Filename:  info.Filename,
+		Offset:    content.Size(),
+		CreatedAt: info.CreatedAt,
+		ExpiresAt: content.ModTime().Add(UPLOAD_SESSION_TTL),
+		Options: file.UploadOptions{
+			StripMetadata: &info.StripMetadata,
+			KeepOriginal:  info.KeepOriginal,
+		},
+	}
+	if time.Now().After(session.ExpiresAt) {
+		service.removeSession(id)
+		return nil, info, file.ErrUploadNotFound
+	}
+
+	return session, info, nil
+}
+
+func (service *DiskFileService) removeSession(id uuid.UUID) {
+	os.Remove(service.sessionContentPath(id))
+	os.Remove(service.sessionInfoPath(id))
+}
+
+func (service *DiskFileService) sessionContentPath(id uuid.UUID) string {
+	return filepath.Join(service.uploadPath, SESSIONS_DIR, id.String()+".part")
+}
+
+func (service *DiskFileService) sessionInfoPath(id uuid.UUID) string {
+	return filepath.Join(service.uploadPath, SESSIONS_DIR, id.String()+".json")
+}
+
+func hashFile(path string) (*file.Hasher, error) {
+	content, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	hasher := file.NewHasher()
+	if _, err := io.Copy(hasher, content); err != nil {
+		return nil, err
+	}
+	return hasher, nil
+}
+
+// keyedMutex serializes access per key and keeps no state for unused keys.
+type keyedMutex struct {
+	mutex sync.Mutex
+	locks map[uuid.UUID]*keyedLock
+}
+
+type keyedLock struct {
+	sync.Mutex
+	holders int
+}
+
+func (m *keyedMutex) Lock(key uuid.UUID) (unlock func()) {
+	m.mutex.Lock()
+	if m.locks == nil {
+		m.locks = make(map[uuid.UUID]*keyedLock)
+	}
+	lock, ok := m.locks[key]
+	if !ok {
+		lock = &keyedLock{}
+		m.locks[key] = lock
+	}
+	lock.holders++
+	m.mutex.Unlock()
+
+	lock.Lock()
+
+	return func() {
+		lock.Unlock()
+
+		m.mutex.Lock()
+		lock.holders--
+		if lock.holders == 0 {
+			delete(m.locks, key)
+		}
+		m.mutex.Unlock()
+	}
+}
@@ -0,0 +1,296 @@
+package disk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"file-service/internal/file"
+)
+
+// TEMP_DIR is a directory inside the storage path where new content is
+// spooled before it is moved to its content-addressed location.
+const TEMP_DIR = "tmp"
+
+// BlobStorage implements the file.BlobStore interface on the local disk,
+// storing content at hash[:2]/hash[2:4]/hash inside the storage path.
+type BlobStorage struct {
+	path string
+}
+
+func NewBlobStorage(path string) (*BlobStorage, error) {
+	const op = "storage.disk.NewBlobStorage"
+
+	if err := os.MkdirAll(filepath.Join(path, TEMP_DIR), 0755); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &BlobStorage{path: path}, nil
+}
+
+func (s *BlobStorage) Create(ctx context.Context) (file.BlobWriter, error) {
+	temp, err := os.CreateTemp(filepath.Join(s.path, TEMP_DIR), "upload-*")
+	if err != nil {
+		return nil, err
+	}
+	return &blobWriter{storage: s, temp: temp}, nil
+}
+
+func (s *BlobStorage) Put(ctx context.Context, hash string, content io.Reader) error {
+	if err := file.ValidateBlobKey(hash); err != nil {
+		return err
+	}
+
+	writer, err := s.Create(ctx)
+	if err != nil {
+		return err
+	}
+	defer writer.Abort()
+
+	if _, err := io.Copy(writer, content); err != nil {
+		return err
+	}
+	return writer.Commit(ctx, hash)
+}
+
+// Move stores the file at path under the hash by renaming it, so it is not
+// copied. A file on another file system is copied and removed.
+func (s *BlobStorage) Move(ctx context.Context, hash string, path string) error {
+	if err := file.ValidateBlobKey(hash); err != nil {
+		return err
+	}
+
+	target := s.blobPath(hash)
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(path, target); err != nil {
+		if !errors.Is(err, syscall.EXDEV) {
+			return err
+		}
+		return s.moveByCopy(ctx, hash, path)
+	}
+
+	// See blobWriter.Commit.
+	now := time.Now()
+	return os.Chtimes(target, now, now)
+}
+
+func (s *BlobStorage) moveByCopy(ctx context.Context, hash string, path string) error {
+	content, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	if err := s.Put(ctx, hash, content); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+func (s *BlobStorage) Get(ctx context.Context, hash string, offset, length int64) (io.ReadCloser, error) {
+	if err := file.ValidateBlobKey(hash); err != nil {
+		return nil, err
+	}
+
+	content, err := os.Open(s.blobPath(hash))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, file.ErrBlobNotFound
+		}
+		return nil, err
+	}
+
+	if offset == 0 && length == 0 {
+		return content, nil
+	}
+
+	if _, err := content.Seek(offset, io.SeekStart); err != nil {
+		content.Close()
+		return nil, err
+	}
+
+	var reader io.Reader = content
+	if length > 0 {
+		reader = io.LimitReader(content, length)
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{reader, content}, nil
+}
+
+func (s *BlobStorage) Stat(ctx context.Context, hash string) (*file.BlobInfo, error) {
+	if err := file.ValidateBlobKey(hash); err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(s.blobPath(hash))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, file.ErrBlobNotFound
+		}
+		return nil, err
+	}
+
+	return &file.BlobInfo{
+		Hash:    hash,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	}, nil
+}
+
+func (s *BlobStorage) Delete(ctx context.Context, hash string) error {
+	if err := file.ValidateBlobKey(hash); err != nil {
+		return err
+	}
+
+	err := os.Remove(s.blobPath(hash))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// List walks the hash[:2]/hash[2:4]/hash layout, skipping anything else
+// stored in the storage path.
+func (s *BlobStorage) List(ctx context.Context, fn func(file.BlobInfo) error) error {
+	firsts, err := os.ReadDir(s.path)
+	if err != nil {
+		return err
+	}
+
+	for _, first := range firsts {
+		if !first.IsDir() || len(first.Name()) != 2 {
+			continue
+		}
+		firstPath := filepath.Join(s.path, first.Name())
+		seconds, err := os.ReadDir(firstPath)
+		if err != nil {
+			return err
+		}
+
+		for _, second := range seconds {
+			if !second.IsDir() || len(second.Name()) != 2 {
+				continue
+			}
+			secondPath := filepath.Join(firstPath, second.Name())
+			entries, err := os.ReadDir(secondPath)
+			if err != nil {
+				return err
+			}
+
+			for _, entry := range entries {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+
+				hash := entry.Name()
+				if entry.IsDir() || file.ValidateBlobKey(hash) != nil ||
+					hash[:2] != first.Name() || hash[2:4] != second.Name() {
+					continue
+				}
+				info, err := entry.Info()
+				if err != nil {
+					if errors.Is(err, os.ErrNotExist) {
+						continue
+					}
+					return err
+				}
+
+				err = fn(file.BlobInfo{
+					Hash:    hash,
+					Size:    info.Size(),
+					ModTime: info.ModTime(),
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// RemoveStaleTemp removes content spooled by writers that were never
+// committed or aborted, e.g. because the process crashed. A dry run only
+// counts it.
+func (s *BlobStorage) RemoveStaleTemp(olderThan time.Duration, dryRun bool) (int, error) {
+	entries, err := os.ReadDir(filepath.Join(s.path, TEMP_DIR))
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	removed := 0
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		if dryRun {
+			removed++
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.path, TEMP_DIR, entry.Name())); err != nil {
+			continue
+		}
+		removed++
+	}
+
+	return removed, nil
+}
+
+func (s *BlobStorage) blobPath(hash string) string {
+	return filepath.Join(s.path, hash[:2], hash[2:4], hash)
+}
+
+type blobWriter struct {
+	storage *BlobStorage
+	temp    *os.File
+	done    bool
+}
+
+func (w *blobWriter) Write(p []byte) (int, error) {
+	return w.temp.Write(p)
+}
+
+func (w *blobWriter) Commit(ctx context.Context, hash string) error {
+	if err := file.ValidateBlobKey(hash); err != nil {
+		return err
+	}
+	if err := w.temp.Close(); err != nil {
+		return err
+	}
+
+	path := w.storage.blobPath(hash)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(w.temp.Name(), path); err != nil {
+		return err
+	}
+	w.done = true
+
+	// Spooled content can be old, refresh it so the garbage collector grace
+	// period covers the transaction that is saving its metadata.
+	now := time.Now()
+	return os.Chtimes(path, now, now)
+}
+
+func (w *blobWriter) Abort() error {
+	if w.done {
+		return nil
+	}
+	w.done = true
+	w.temp.Close()
+	return os.Remove(w.temp.Name())
+}
@@ -0,0 +1,169 @@
+package disk
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"file-service/internal/file"
+)
+
+func TestBlobStorage(t *testing.T) {
+	ctx := context.Background()
+	path := t.TempDir()
+
+	storage, err := NewBlobStorage(path)
+	require.NoError(t, err)
+
+	content := []byte("hello, content-addressed world")
+	hasher := file.NewHasher()
+	hasher.Write(content)
+	hash := hasher.Hash()
+
+	t.Run("Create and commit", func(t *testing.T) {
+		writer, err := storage.Create(ctx)
+		require.NoError(t, err)
+		_, err = writer.Write(content)
+		require.NoError(t, err)
+		require.NoError(t, writer.Commit(ctx, hash))
+		require.NoError(t, writer.Abort())
+
+		require.FileExists(t, filepath.Join(path, hash[:2], hash[2:4], hash))
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		reader, err := storage.Get(ctx, hash, 0, 0)
+		require.NoError(t, err)
+		data, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		require.NoError(t, reader.Close())
+		require.Equal(t, content, data)
+	})
+
+	t.Run("Get range", func(t *testing.T) {
+		reader, err := storage.Get(ctx, hash, 7, 7)
+		require.NoError(t, err)
+		data, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		require.NoError(t, reader.Close())
+		require.Equal(t, content[7:14], data)
+	})
+
+	t.Run("Stat", func(t *testing.T) {
+		info, err := storage.Stat(ctx, hash)
+		require.NoError(t, err)
+		require.Equal(t, hash, info.Hash)
+		require.Equal(t, int64(len(content)), info.Size)
+	})
+
+	t.Run("List skips foreign files", func(t *testing.T) {
+		require.NoError(t, os.MkdirAll(filepath.Join(path, "sessions"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(path, "sessions", "x.part"), content, 0644))
+
+		var hashes []string
+		err := storage.List(ctx, func(info file.BlobInfo) error {
+			hashes = append(hashes, info.Hash)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{hash}, hashes)
+	})
+
+	t.Run("Derived content", func(t *testing.T) {
+		key := file.DerivedKey(hash, "thumb64")
+		require.NoError(t, storage.Put(ctx, key, strings.NewReader("thumbnail")))
+
+		var keys []string
+		err := storage.List(ctx, func(info file.BlobInfo) error {
+			keys = append(keys, info.Hash)
+			return nil
+		})
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{hash, key}, keys)
+
+		require.NoError(t, storage.Delete(ctx, key))
+		_, err = storage.Stat(ctx, key)
+		require.ErrorIs(t, err, file.ErrBlobNotFound)
+
+		for _, invalid := range []string{hash + "_", hash + "_Thumb", hash + "_a/../b"} {
+			err := storage.Put(ctx, invalid, strings.NewReader("x"))
+			require.ErrorIs(t, err, file.ErrHashInvalid, invalid)
+		}
+	})
+
+	t.Run("Abort", func(t *testing.T) {
+		writer, err := storage.Create(ctx)
+		require.NoError(t, err)
+		_, err = writer.Write([]byte("discarded"))
+		require.NoError(t, err)
+		require.NoError(t, writer.Abort())
+
+		entries, err := os.ReadDir(filepath.Join(path, TEMP_DIR))
+		require.NoError(t, err)
+		require.Empty(t, entries)
+	})
+
+	t.Run("Remove stale temp", func(t *testing.T) {
+		_, err := storage.Create(ctx)
+		require.NoError(t, err)
+
+		removed, err := storage.RemoveStaleTemp(time.Hour, false)
+		require.NoError(t, err)
+		require.Zero(t, removed)
+
+		removed, err = storage.RemoveStaleTemp(0, true)
+		require.NoError(t, err)
+		require.Equal(t, 1, removed)
+
+		removed, err = storage.RemoveStaleTemp(0, false)
+		require.NoError(t, err)
+		require.Equal(t, 1, removed)
+	})
+
+	t.Run("Move", func(t *testing.T) {
+		staged := filepath.Join(t.TempDir(), "staged")
+		require.NoError(t, os.WriteFile(staged, []byte("staged content"), 0644))
+		hasher := file.NewHasher()
+		hasher.Write([]byte("staged content"))
+
+		require.NoError(t, storage.Move(ctx, hasher.Hash(), staged))
+		require.NoFileExists(t, staged)
+		info, err := storage.Stat(ctx, hasher.Hash())
+		require.NoError(t, err)
+		require.Equal(t, int64(len("staged content")), info.Size)
+	})
+
+	t.Run("Invalid hash", func(t *testing.T) {
+		_, err := storage.Get(ctx, "../../etc/passwd", 0, 0)
+		require.ErrorIs(t, err, file.ErrHashInvalid)
+
+		err = storage.Put(ctx, strings.Repeat("A", 64), strings.NewReader("x"))
+		require.ErrorIs(t, err, file.ErrHashInvalid)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		require.NoError(t, storage.Delete(ctx, hash))
+		require.NoError(t, storage.Delete(ctx, hash))
+
+		_, err := storage.Stat(ctx, hash)
+		require.ErrorIs(t, err, file.ErrBlobNotFound)
+		require.ErrorIs(t, err, file.ErrFileNotFound)
+
+		_, err = storage.Get(ctx, hash, 0, 0)
+		require.ErrorIs(t, err, file.ErrFileNotFound)
+	})
+
+	t.Run("Put", func(t *testing.T) {
+		require.NoError(t, storage.Put(ctx, hash, strings.NewReader(string(content))))
+
+		info, err := storage.Stat(ctx, hash)
+		require.NoError(t, err)
+		require.Equal(t, int64(len(content)), info.Size)
+	})
+}
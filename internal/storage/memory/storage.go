@@ -1,32 +1,58 @@
 package memory
 
 import (
+	"bytes"
 	"context"
 	"file-service/internal/file"
+	"slices"
 	"sync"
 
+	trmcontext "github.com/avito-tech/go-transaction-manager/trm/v2/context"
 	"github.com/google/uuid"
 )
 
 // Storage implements the file.FileMetaRepository interface using in-memory storage
 type Storage struct {
 	mu      sync.RWMutex
-	entries map[string]file.FileMeta
+	entries map[uuid.UUID]file.FileMeta
+
+	hashLocks hashLocks
 }
 
 // New creates a new in-memory storage instance
 func New() *Storage {
 	return &Storage{
-		entries: make(map[string]file.FileMeta),
+		entries: make(map[uuid.UUID]file.FileMeta),
 	}
 }
 
-// Save adds a new file meta
-func (s *Storage) Save(ctx context.Context, entry file.FileMeta) error {
+// Save adds a new file meta or replaces the one with the same ID
+func (s *Storage) Save(ctx context.Context, entry *file.FileMeta) error {
+	id := entry.ID
+	stored := *entry
+	if entry.Image != nil {
+		image := *entry.Image
+		stored.Image = &image
+	}
+
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	previous, existed := s.entries[id]
+	s.entries[id] = stored
+	s.mu.Unlock()
+
+	if tx := transaction(ctx); tx != nil {
+		tx.onRollback(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			if existed {
+				s.entries[id] = previous
+			} else {
+				delete(s.entries, id)
+			}
+		})
+	}
 
-	s.entries[entry.ID.String()] = entry
 	return nil
 }
 
@@ -35,7 +61,7 @@ func (s *Storage) FindById(ctx context.Context, id uuid.UUID) (*file.FileMeta, e
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	entry, exists := s.entries[id.String()]
+	entry, exists := s.entries[id]
 	if !exists {
 		return nil, file.ErrFileNotFound
 	}
@@ -43,17 +69,136 @@ func (s *Storage) FindById(ctx context.Context, id uuid.UUID) (*file.FileMeta, e
 	return &entry, nil
 }
 
-// FindAll retrieves all file meta
-func (s *Storage) FindAll(ctx context.Context, page file.Page) ([]file.FileMeta, error) {
+// FindAll retrieves a page of file meta passing the filter in the sort order
+func (s *Storage) FindAll(ctx context.Context, filter file.Filter, order file.Sort, page file.Page) ([]*file.FileMeta, error) {
+	s.mu.RLock()
+	entries := make([]*file.FileMeta, 0, len(s.entries))
+	for _, entry := range s.entries {
+		if !filter.Match(&entry) || page.After != nil && !page.After.Precedes(&entry) {
+			continue
+		}
+		entries = append(entries, &entry)
+	}
+	s.mu.RUnlock()
+
+	slices.SortFunc(entries, order.Compare)
+
+	offset := (page.Number - 1) * page.Size
+	if offset >= len(entries) {
+		return []*file.FileMeta{}, nil
+	}
+
+	return entries[offset:min(offset+page.Size, len(entries))], nil
+}
+
+// Delete removes a file meta by ID
+func (s *Storage) Delete(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	previous, exists := s.entries[id]
+	delete(s.entries, id)
+	s.mu.Unlock()
+
+	if !exists {
+		return file.ErrFileNotFound
+	}
+
+	if tx := transaction(ctx); tx != nil {
+		tx.onRollback(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			s.entries[previous.ID] = previous
+		})
+	}
+
+	return nil
+}
+
+// CountByHash counts file meta referencing the content hash or the original
+// content hash
+func (s *Storage) CountByHash(ctx context.Context, hash string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for _, entry := range s.entries {
+		if entry.Hash == hash || entry.OriginalHash == hash {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// CountByContentHash counts file meta referencing the content hash, original
+// content hashes are not counted
+func (s *Storage) CountByContentHash(ctx context.Context, hash string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for _, entry := range s.entries {
+		if entry.Hash == hash {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// FindIncomplete retrieves up to limit file meta without a content type with
+// ids after the given one
+func (s *Storage) FindIncomplete(ctx context.Context, after uuid.UUID, limit int) ([]*file.FileMeta, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	entries := make([]file.FileMeta, 0, len(s.entries))
+	entries := make([]*file.FileMeta, 0)
 	for _, entry := range s.entries {
-		entries = append(entries, entry)
+		if entry.ContentType == "" && bytes.Compare(entry.ID[:], after[:]) > 0 {
+			entries = append(entries, &entry)
+		}
 	}
+	slices.SortFunc(entries, func(a, b *file.FileMeta) int {
+		return bytes.Compare(a.ID[:], b.ID[:])
+	})
+	return entries[:min(limit, len(entries))], nil
+}
+
+// FindAllHashes retrieves the distinct content and original content hashes
+func (s *Storage) FindAllHashes(ctx context.Context) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	return entries, nil
+	seen := make(map[string]struct{})
+	hashes := make([]string, 0)
+	for _, entry := range s.entries {
+		for _, hash := range entry.ContentHashes() {
+			if _, ok := seen[hash]; ok {
+				continue
+			}
+			seen[hash] = struct{}{}
+			hashes = append(hashes, hash)
+		}
+	}
+
+	return hashes, nil
+}
+
+// LockHash locks the content hash until the current transaction ends, it is
+// a no-op outside of a transaction
+func (s *Storage) LockHash(ctx context.Context, hash string) error {
+	tx := transaction(ctx)
+	if tx == nil || tx.holdsHash(hash) {
+		return nil
+	}
+
+	unlock, err := s.hashLocks.lock(ctx, hash)
+	if err != nil {
+		return err
+	}
+	tx.holdHash(hash, unlock)
+
+	return nil
 }
 
 // Close performs any necessary cleanup
@@ -61,6 +206,61 @@ func (s *Storage) Close() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.entries = make(map[string]file.FileMeta)
+	s.entries = make(map[uuid.UUID]file.FileMeta)
+	return nil
+}
+
+func transaction(ctx context.Context) *Transaction {
+	if tr := trmcontext.DefaultManager.Default(ctx); tr != nil {
+		if tx, ok := tr.Transaction().(*Transaction); ok {
+			return tx
+		}
+	}
 	return nil
 }
+
+// hashLocks is a set of mutexes by hash keeping no state for unlocked hashes
+type hashLocks struct {
+	mu    sync.Mutex
+	locks map[string]*hashLock
+}
+
+type hashLock struct {
+	ch      chan struct{}
+	holders int
+}
+
+func (l *hashLocks) lock(ctx context.Context, hash string) (func(), error) {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*hashLock)
+	}
+	lock, ok := l.locks[hash]
+	if !ok {
+		lock = &hashLock{ch: make(chan struct{}, 1)}
+		l.locks[hash] = lock
+	}
+	lock.holders++
+	l.mu.Unlock()
+
+	release := func() {
+		l.mu.Lock()
+		lock.holders--
+		if lock.holders == 0 {
+			delete(l.locks, hash)
+		}
+		l.mu.Unlock()
+	}
+
+	select {
+	case lock.ch <- struct{}{}:
+	case <-ctx.Done():
+		release()
+		return nil, ctx.Err()
+	}
+
+	return func() {
+		<-lock.ch
+		release()
+	}, nil
+}
@@ -0,0 +1,16 @@
+package memory
+
+import (
+	"testing"
+
+	tx "github.com/avito-tech/go-transaction-manager/trm/v2/manager"
+
+	"file-service/internal/file"
+	"file-service/internal/file/filetest"
+)
+
+func TestStorage(t *testing.T) {
+	filetest.RunRepositorySuite(t, func(t *testing.T) (file.FileMetaRepository, *tx.Manager) {
+		return New(), tx.Must(NewDefaultFactory())
+	}, filetest.SuiteOptions{})
+}
@@ -0,0 +1,116 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/avito-tech/go-transaction-manager/trm/v2"
+)
+
+// Transaction implements trm.Transaction for Storage. Changes are visible to
+// other callers right away and are undone on rollback, hash locks are held
+// until the transaction ends.
+type Transaction struct {
+	mu       sync.Mutex
+	undo     []func()
+	release  []func()
+	hashes   map[string]struct{}
+	isActive bool
+	closed   chan struct{}
+}
+
+// NewDefaultFactory creates a trm.TrFactory of Transaction for tx.Manager
+func NewDefaultFactory() trm.TrFactory {
+	return func(ctx context.Context, _ trm.Settings) (context.Context, trm.Transaction, error) {
+		return ctx, &Transaction{
+			hashes:   make(map[string]struct{}),
+			isActive: true,
+			closed:   make(chan struct{}),
+		}, nil
+	}
+}
+
+// Transaction returns the Transaction itself
+func (t *Transaction) Transaction() interface{} {
+	return t
+}
+
+// Commit keeps the changes and releases the hash locks
+func (t *Transaction) Commit(context.Context) error {
+	_, release, err := t.close()
+	if err != nil {
+		return err
+	}
+	for _, unlock := range release {
+		unlock()
+	}
+
+	return nil
+}
+
+// Rollback undoes the changes in reverse order and releases the hash locks
+func (t *Transaction) Rollback(context.Context) error {
+	undo, release, err := t.close()
+	if err != nil {
+		return err
+	}
+	for i := len(undo) - 1; i >= 0; i-- {
+		undo[i]()
+	}
+	for _, unlock := range release {
+		unlock()
+	}
+
+	return nil
+}
+
+// IsActive returns true if the transaction is neither committed nor rolled back
+func (t *Transaction) IsActive() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.isActive
+}
+
+// Closed returns a channel closed when the transaction ends
+func (t *Transaction) Closed() <-chan struct{} {
+	return t.closed
+}
+
+func (t *Transaction) close() (undo []func(), release []func(), err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.isActive {
+		return nil, nil, trm.ErrAlreadyClosed
+	}
+	undo, release = t.undo, t.release
+	t.undo, t.release = nil, nil
+	t.isActive = false
+	close(t.closed)
+
+	return undo, release, nil
+}
+
+func (t *Transaction) onRollback(undo func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.undo = append(t.undo, undo)
+}
+
+func (t *Transaction) holdsHash(hash string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	_, ok := t.hashes[hash]
+	return ok
+}
+
+func (t *Transaction) holdHash(hash string, unlock func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.hashes[hash] = struct{}{}
+	t.release = append(t.release, unlock)
+}
@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	tx "github.com/avito-tech/go-transaction-manager/trm/v2/manager"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"file-service/internal/file"
+)
+
+func TestTransaction(t *testing.T) {
+	ctx := context.Background()
+
+	newMeta := func(name string) *file.FileMeta {
+		meta, err := file.NewFileMeta(uuid.New(), name, "hash", 1, "text/plain; charset=utf-8")
+		require.NoError(t, err)
+		return &meta
+	}
+
+	t.Run("Commit keeps changes", func(t *testing.T) {
+		storage := New()
+		manager := tx.Must(NewDefaultFactory())
+		meta := newMeta("committed")
+
+		err := manager.Do(ctx, func(ctx context.Context) error {
+			return storage.Save(ctx, meta)
+		})
+		require.NoError(t, err)
+
+		_, err = storage.FindById(ctx, meta.ID)
+		require.NoError(t, err)
+	})
+
+	t.Run("Rollback undoes changes", func(t *testing.T) {
+		storage := New()
+		manager := tx.Must(NewDefaultFactory())
+
+		kept := newMeta("kept")
+		require.NoError(t, storage.Save(ctx, kept))
+
+		added := newMeta("added")
+		updated := *kept
+		updated.Filename = "updated"
+
+		errRollback := errors.New("rollback")
+		err := manager.Do(ctx, func(ctx context.Context) error {
+			require.NoError(t, storage.Save(ctx, added))
+			require.NoError(t, storage.Save(ctx, &updated))
+			require.NoError(t, storage.Delete(ctx, kept.ID))
+			return errRollback
+		})
+		require.ErrorIs(t, err, errRollback)
+
+		_, err = storage.FindById(ctx, added.ID)
+		require.ErrorIs(t, err, file.ErrFileNotFound)
+
+		found, err := storage.FindById(ctx, kept.ID)
+		require.NoError(t, err)
+		require.Equal(t, "kept", found.Filename)
+	})
+
+	t.Run("Hash lock is held until the transaction ends", func(t *testing.T) {
+		storage := New()
+		manager := tx.Must(NewDefaultFactory())
+
+		locked := make(chan struct{})
+		release := make(chan struct{})
+		go func() {
+			manager.Do(ctx, func(ctx context.Context) error {
+				require.NoError(t, storage.LockHash(ctx, "hash"))
+				// Locking again in the same transaction must not block.
+				require.NoError(t, storage.LockHash(ctx, "hash"))
+				close(locked)
+				<-release
+				return nil
+			})
+		}()
+		<-locked
+
+		timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		err := manager.Do(timeout, func(ctx context.Context) error {
+			return storage.LockHash(ctx, "hash")
+		})
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+
+		close(release)
+		err = manager.Do(ctx, func(ctx context.Context) error {
+			return storage.LockHash(ctx, "hash")
+		})
+		require.NoError(t, err)
+	})
+}
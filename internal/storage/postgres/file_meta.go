@@ -3,7 +3,10 @@ package postgres
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"strings"
+	"time"
 
 	pgxtx "github.com/avito-tech/go-transaction-manager/drivers/pgxv5/v2"
 	"github.com/google/uuid"
@@ -11,8 +14,26 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"file-service/internal/file"
+	"file-service/internal/storage/sqlmeta"
 )
 
+// dialect spells the queries of sqlmeta for PostgreSQL. Substrings of names
+// are found with the trigram index.
+var dialect = sqlmeta.Dialect{
+	Placeholder: func(n int) string { return fmt.Sprintf("$%d", n) },
+	NamePrefix: func(prefix string) (string, any) {
+		return `filename COLLATE "C" LIKE %s`, likeEscaper.Replace(prefix) + "%"
+	},
+	NameContains: func(substring string) (string, any) {
+		return `filename LIKE %s`, "%" + likeEscaper.Replace(substring) + "%"
+	},
+	Filename: `filename COLLATE "C"`,
+	Time:     func(t time.Time) any { return t },
+	ID:       func(id uuid.UUID) any { return id },
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
 type FileMetaStorage struct {
 	pool   *pgxpool.Pool
 	logger *slog.Logger
@@ -29,63 +50,197 @@ func NewFileMetaStorage(pool *pgxpool.Pool, transaction *pgxtx.CtxGetter, logger
 
 func (s *FileMetaStorage) Save(ctx context.Context, file *file.FileMeta) error {
 	query := `
-	INSERT INTO file_meta (id, filename, hash, created_at, updated_at)
-	VALUES ($1, $2, $3, $4, $5)
+	INSERT INTO file_meta (id, filename, hash, original_hash, size, content_type, created_at, updated_at,
+		image_width, image_height, image_color_model, image_format, image_taken_at,
+		image_camera_model, image_orientation, image_has_gps)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
 	ON CONFLICT (id) DO UPDATE
 	SET filename = EXCLUDED.filename,
 		hash = EXCLUDED.hash,
+		original_hash = EXCLUDED.original_hash,
+		size = EXCLUDED.size,
+		content_type = EXCLUDED.content_type,
 		created_at = EXCLUDED.created_at,
-		updated_at = EXCLUDED.updated_at
+		updated_at = EXCLUDED.updated_at,
+		image_width = EXCLUDED.image_width,
+		image_height = EXCLUDED.image_height,
+		image_color_model = EXCLUDED.image_color_model,
+		image_format = EXCLUDED.image_format,
+		image_taken_at = EXCLUDED.image_taken_at,
+		image_camera_model = EXCLUDED.image_camera_model,
+		image_orientation = EXCLUDED.image_orientation,
+		image_has_gps = EXCLUDED.image_has_gps
 	RETURNING id`
 
 	db := s.tx.DefaultTrOrDB(ctx, s.pool)
 
-	return db.QueryRow(ctx, query, file.ID, file.Filename, file.Hash, file.CreatedAt, file.UpdatedAt).Scan(&file.ID)
+	args := append([]any{file.ID, file.Filename, file.Hash, file.OriginalHash, file.Size, file.ContentType, file.CreatedAt, file.UpdatedAt}, sqlmeta.ImageValues(dialect, file.Image)...)
+	return db.QueryRow(ctx, query, args...).Scan(&file.ID)
+}
+
+func (s *FileMetaStorage) FindAll(ctx context.Context, filter file.Filter, order file.Sort, page file.Page) ([]*file.FileMeta, error) {
+	query, args := sqlmeta.ListQuery(dialect, filter, order, page)
+
+	db := s.tx.DefaultTrOrDB(ctx, s.pool)
+
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return collectFileMetas(rows)
 }
 
-func (s *FileMetaStorage) FindAll(ctx context.Context, page file.Page) ([]*file.FileMeta, error) {
+func (s *FileMetaStorage) FindById(ctx context.Context, id uuid.UUID) (*file.FileMeta, error) {
 	query := `
-	SELECT id, filename, hash, created_at, updated_at
+	SELECT ` + sqlmeta.FILE_META_COLUMNS + `
 	FROM file_meta
-	ORDER BY updated_at DESC, created_at DESC
-	LIMIT $1 OFFSET $2`
+	WHERE id = $1`
 
 	db := s.tx.DefaultTrOrDB(ctx, s.pool)
 
-	rows, err := db.Query(ctx, query, page.Size, (page.Number-1)*page.Size)
+	meta, err := scanFileMeta(db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, file.ErrFileNotFound
+		}
+		return nil, err
+	}
+	return meta, nil
+}
+
+func (s *FileMetaStorage) Delete(ctx context.Context, id uuid.UUID) error {
+	query := `
+	DELETE FROM file_meta
+	WHERE id = $1`
+
+	db := s.tx.DefaultTrOrDB(ctx, s.pool)
+
+	tag, err := db.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return file.ErrFileNotFound
+	}
+	return nil
+}
+
+func (s *FileMetaStorage) CountByHash(ctx context.Context, hash string) (int, error) {
+	query := `
+	SELECT count(*)
+	FROM file_meta
+	WHERE hash = $1 OR original_hash = $1`
+
+	db := s.tx.DefaultTrOrDB(ctx, s.pool)
+
+	var count int
+	err := db.QueryRow(ctx, query, hash).Scan(&count)
+	return count, err
+}
+
+func (s *FileMetaStorage) CountByContentHash(ctx context.Context, hash string) (int, error) {
+	query := `
+	SELECT count(*)
+	FROM file_meta
+	WHERE hash = $1`
+
+	db := s.tx.DefaultTrOrDB(ctx, s.pool)
+
+	var count int
+	err := db.QueryRow(ctx, query, hash).Scan(&count)
+	return count, err
+}
+
+func (s *FileMetaStorage) FindIncomplete(ctx context.Context, after uuid.UUID, limit int) ([]*file.FileMeta, error) {
+	query := `
+	SELECT ` + sqlmeta.FILE_META_COLUMNS + `
+	FROM file_meta
+	WHERE (content_type = '' OR image_format IS NULL) AND id > $1
+	ORDER BY id
+	LIMIT $2`
+
+	db := s.tx.DefaultTrOrDB(ctx, s.pool)
+
+	rows, err := db.Query(ctx, query, after, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return collectFileMetas(rows)
+}
+
+func (s *FileMetaStorage) FindAllHashes(ctx context.Context) ([]string, error) {
+	query := `
+	SELECT hash
+	FROM file_meta
+	UNION
+	SELECT original_hash
+	FROM file_meta
+	WHERE original_hash <> ''`
+
+	db := s.tx.DefaultTrOrDB(ctx, s.pool)
+
+	rows, err := db.Query(ctx, query)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	files := make([]*file.FileMeta, 0)
+	hashes := make([]string, 0)
 	for rows.Next() {
-		var meta file.FileMeta
-		err = rows.Scan(&meta.ID, &meta.Filename, &meta.Hash, &meta.CreatedAt, &meta.UpdatedAt)
-		if err != nil {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
 			return nil, err
 		}
-		files = append(files, &meta)
+		hashes = append(hashes, hash)
 	}
 
-	return files, nil
+	return hashes, rows.Err()
 }
 
-func (s *FileMetaStorage) FindById(ctx context.Context, id uuid.UUID) (*file.FileMeta, error) {
-	query := `
-	SELECT id, filename, hash, created_at, updated_at
-	FROM file_meta
-	WHERE id = $1`
+// LockHash takes a transaction level advisory lock, so it is a no-op outside
+// of a transaction.
+func (s *FileMetaStorage) LockHash(ctx context.Context, hash string) error {
+	query := `SELECT pg_advisory_xact_lock(hashtextextended($1, 0))`
 
 	db := s.tx.DefaultTrOrDB(ctx, s.pool)
 
-	var meta file.FileMeta
-	err := db.QueryRow(ctx, query, id).Scan(&meta.ID, &meta.Filename, &meta.Hash, &meta.CreatedAt, &meta.UpdatedAt)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, file.ErrFileNotFound
+	_, err := db.Exec(ctx, query, hash)
+	return err
+}
+
+func collectFileMetas(rows pgx.Rows) ([]*file.FileMeta, error) {
+	defer rows.Close()
+
+	files := make([]*file.FileMeta, 0)
+	for rows.Next() {
+		meta, err := scanFileMeta(rows)
+		if err != nil {
+			return nil, err
 		}
+		files = append(files, meta)
+	}
+
+	return files, rows.Err()
+}
+
+func scanFileMeta(row pgx.Row) (*file.FileMeta, error) {
+	var (
+		meta    file.FileMeta
+		image   file.ImageMeta
+		takenAt *time.Time
+	)
+	err := row.Scan(&meta.ID, &meta.Filename, &meta.Hash, &meta.OriginalHash, &meta.Size, &meta.ContentType, &meta.CreatedAt, &meta.UpdatedAt,
+		&image.Width, &image.Height, &image.ColorModel, &image.Format, &takenAt, &image.CameraModel, &image.Orientation, &image.HasGPS)
+	if err != nil {
 		return nil, err
 	}
+	if image.Format != "" {
+		if takenAt != nil {
+			image.TakenAt = *takenAt
+		}
+		meta.Image = &image
+	}
 	return &meta, nil
 }
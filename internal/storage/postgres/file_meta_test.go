@@ -0,0 +1,36 @@
+package postgres
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+
+	pgxtx "github.com/avito-tech/go-transaction-manager/drivers/pgxv5/v2"
+	tx "github.com/avito-tech/go-transaction-manager/trm/v2/manager"
+	"github.com/stretchr/testify/require"
+
+	"file-service/internal/file"
+	"file-service/internal/file/filetest"
+)
+
+func TestFileMetaStorage(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	ctx := context.Background()
+	db, err := New(ctx, databaseURL, "../../../migrations")
+	require.NoError(t, err)
+	t.Cleanup(db.Close)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	filetest.RunRepositorySuite(t, func(t *testing.T) (file.FileMetaRepository, *tx.Manager) {
+		_, err := db.Exec(ctx, "TRUNCATE file_meta")
+		require.NoError(t, err)
+
+		return NewFileMetaStorage(db, pgxtx.DefaultCtxGetter, logger), tx.Must(pgxtx.NewDefaultFactory(db))
+	}, filetest.SuiteOptions{})
+}
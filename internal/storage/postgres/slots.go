@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// SlotStorage shares the concurrent requests of clients between the replicas
+// of the service, see ratelimit.SlotStore.
+type SlotStorage struct {
+	pool *pgxpool.Pool
+}
+
+func NewSlotStorage(pool *pgxpool.Pool) *SlotStorage {
+	return &SlotStorage{pool: pool}
+}
+
+// Acquire takes a slot of the client in the group unless limit slots are
+// taken. Slots of the client and group are counted under an advisory lock,
+// expired ones are freed first.
+func (s *SlotStorage) Acquire(ctx context.Context, client, group string, limit int, lease time.Duration) (string, bool, error) {
+	id := uuid.New()
+	acquired := false
+
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		// Keys colliding in the hash only share the lock.
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended($1::text || '/' || $2::text, 0))`, client, group); err != nil {
+			return err
+		}
+
+		query := `
+		DELETE FROM rate_limit_slots
+		WHERE client = $1 AND group_name = $2 AND expires_at <= now()`
+		if _, err := tx.Exec(ctx, query, client, group); err != nil {
+			return err
+		}
+
+		var taken int
+		query = `
+		SELECT count(*)
+		FROM rate_limit_slots
+		WHERE client = $1 AND group_name = $2`
+		if err := tx.QueryRow(ctx, query, client, group).Scan(&taken); err != nil {
+			return err
+		}
+		if taken >= limit {
+			return nil
+		}
+
+		query = `
+		INSERT INTO rate_limit_slots (id, client, group_name, expires_at)
+		VALUES ($1, $2, $3, now() + make_interval(secs => $4))`
+		if _, err := tx.Exec(ctx, query, id, client, group, lease.Seconds()); err != nil {
+			return err
+		}
+		acquired = true
+		return nil
+	})
+	if err != nil || !acquired {
+		return "", false, err
+	}
+	return id.String(), true, nil
+}
+
+// Renew extends the leases of the slots and frees the expired slots of all
+// replicas, including the crashed ones.
+func (s *SlotStorage) Renew(ctx context.Context, ids []string, lease time.Duration) error {
+	slots := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		slot, err := uuid.Parse(id)
+		if err != nil {
+			return err
+		}
+		slots = append(slots, slot)
+	}
+
+	query := `
+	UPDATE rate_limit_slots
+	SET expires_at = now() + make_interval(secs => $2)
+	WHERE id = ANY($1)`
+	if len(slots) > 0 {
+		if _, err := s.pool.Exec(ctx, query, slots, lease.Seconds()); err != nil {
+			return err
+		}
+	}
+
+	query = `
+	DELETE FROM rate_limit_slots
+	WHERE expires_at <= now()`
+	_, err := s.pool.Exec(ctx, query)
+	return err
+}
+
+func (s *SlotStorage) Release(ctx context.Context, id string) error {
+	slot, err := uuid.Parse(id)
+	if err != nil {
+		return err
+	}
+
+	query := `
+	DELETE FROM rate_limit_slots
+	WHERE id = $1`
+
+	_, err = s.pool.Exec(ctx, query, slot)
+	return err
+}
@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlotStorage(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	ctx := context.Background()
+	db, err := New(ctx, databaseURL, "../../../migrations")
+	require.NoError(t, err)
+	t.Cleanup(db.Close)
+	_, err = db.Exec(ctx, "TRUNCATE rate_limit_slots")
+	require.NoError(t, err)
+
+	slots := NewSlotStorage(db)
+
+	first, ok, err := slots.Acquire(ctx, "client", "transfer", 2, time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+	_, ok, err = slots.Acquire(ctx, "client", "transfer", 2, time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+	_, ok, err = slots.Acquire(ctx, "client", "transfer", 2, time.Minute)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// Groups and clients are counted separately.
+	_, ok, err = slots.Acquire(ctx, "client", "listing", 2, time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+	_, ok, err = slots.Acquire(ctx, "other", "transfer", 2, time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	require.NoError(t, slots.Release(ctx, first))
+	_, ok, err = slots.Acquire(ctx, "client", "transfer", 2, time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	t.Run("Expired leases", func(t *testing.T) {
+		renewed, ok, err := slots.Acquire(ctx, "crashed", "transfer", 2, 100*time.Millisecond)
+		require.NoError(t, err)
+		require.True(t, ok)
+		_, ok, err = slots.Acquire(ctx, "crashed", "transfer", 2, 100*time.Millisecond)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		time.Sleep(50 * time.Millisecond)
+		require.NoError(t, slots.Renew(ctx, []string{renewed}, time.Minute))
+		time.Sleep(100 * time.Millisecond)
+
+		// Only the renewed slot is left.
+		_, ok, err = slots.Acquire(ctx, "crashed", "transfer", 2, time.Minute)
+		require.NoError(t, err)
+		require.True(t, ok)
+		_, ok, err = slots.Acquire(ctx, "crashed", "transfer", 2, time.Minute)
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		var expired int
+		require.NoError(t, db.QueryRow(ctx, "SELECT count(*) FROM rate_limit_slots WHERE expires_at <= now()").Scan(&expired))
+		require.Zero(t, expired)
+	})
+}
@@ -0,0 +1,318 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+
+	"file-service/internal/file"
+)
+
+// PART_SIZE is the part size of multipart uploads, content larger than it is
+// uploaded in parts.
+const PART_SIZE = 64 * 1024 * 1024
+
+type Config struct {
+	Endpoint  string
+	Bucket    string
+	Region    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+	// Prefix is prepended to the keys of all objects.
+	Prefix string
+	// TempDir is a local directory where new content is spooled until its
+	// hash is known.
+	TempDir string
+	// PartSize overrides PART_SIZE.
+	PartSize uint64
+	// Transport overrides the default HTTP transport, e.g. to trust a
+	// private CA.
+	Transport http.RoundTripper
+}
+
+// BlobStorage implements the file.BlobStore interface on an S3-compatible
+// object storage, storing content under the hash[:2]/hash[2:4]/hash keys.
+type BlobStorage struct {
+	client   *minio.Client
+	bucket   string
+	prefix   string
+	tempDir  string
+	partSize uint64
+}
+
+func NewBlobStorage(ctx context.Context, config Config) (*BlobStorage, error) {
+	const op = "storage.s3.NewBlobStorage"
+
+	client, err := minio.New(config.Endpoint, &minio.Options{
+		Creds:     credentials.NewStaticV4(config.AccessKey, config.SecretKey, ""),
+		Secure:    config.UseSSL,
+		Region:    config.Region,
+		Transport: config.Transport,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	exists, err := client.BucketExists(ctx, config.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !exists {
+		err := client.MakeBucket(ctx, config.Bucket, minio.MakeBucketOptions{Region: config.Region})
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to create bucket: %w", op, err)
+		}
+	}
+
+	tempDir := config.TempDir
+	if tempDir == "" {
+		tempDir = os.TempDir()
+	}
+	if err := os.MkdirAll(tempDir, 0755); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	partSize := config.PartSize
+	if partSize == 0 {
+		partSize = PART_SIZE
+	}
+
+	return &BlobStorage{
+		client:   client,
+		bucket:   config.Bucket,
+		prefix:   strings.Trim(config.Prefix, "/"),
+		tempDir:  tempDir,
+		partSize: partSize,
+	}, nil
+}
+
+func (s *BlobStorage) Create(ctx context.Context) (file.BlobWriter, error) {
+	temp, err := os.CreateTemp(s.tempDir, "s3-upload-*")
+	if err != nil {
+		return nil, err
+	}
+	return &blobWriter{storage: s, temp: temp}, nil
+}
+
+func (s *BlobStorage) Put(ctx context.Context, hash string, content io.Reader) error {
+	if err := file.ValidateBlobKey(hash); err != nil {
+		return err
+	}
+
+	// Content of unknown size is buffered in memory part by part.
+	_, err := s.client.PutObject(ctx, s.bucket, s.objectKey(hash), content, contentSize(content), minio.PutObjectOptions{
+		PartSize: s.partSize,
+	})
+	return err
+}
+
+func (s *BlobStorage) Get(ctx context.Context, hash string, offset, length int64) (io.ReadCloser, error) {
+	if err := file.ValidateBlobKey(hash); err != nil {
+		return nil, err
+	}
+
+	options := minio.GetObjectOptions{}
+	if offset > 0 || length > 0 {
+		end := int64(0)
+		if length > 0 {
+			end = offset + length - 1
+		}
+		if err := options.SetRange(offset, end); err != nil {
+			return nil, err
+		}
+	}
+
+	// Unlike Client.GetObject, the core request is sent right away, so a
+	// missing object is reported here rather than on the first read.
+	core := minio.Core{Client: s.client}
+	object, _, _, err := core.GetObject(ctx, s.bucket, s.objectKey(hash), options)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return object, nil
+}
+
+func (s *BlobStorage) Stat(ctx context.Context, hash string) (*file.BlobInfo, error) {
+	if err := file.ValidateBlobKey(hash); err != nil {
+		return nil, err
+	}
+
+	info, err := s.client.StatObject(ctx, s.bucket, s.objectKey(hash), minio.StatObjectOptions{})
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &file.BlobInfo{
+		Hash:    hash,
+		Size:    info.Size,
+		ModTime: info.LastModified,
+	}, nil
+}
+
+func (s *BlobStorage) Delete(ctx context.Context, hash string) error {
+	if err := file.ValidateBlobKey(hash); err != nil {
+		return err
+	}
+
+	return s.client.RemoveObject(ctx, s.bucket, s.objectKey(hash), minio.RemoveObjectOptions{})
+}
+
+func (s *BlobStorage) List(ctx context.Context, fn func(file.BlobInfo) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	prefix := ""
+	if s.prefix != "" {
+		prefix = s.prefix + "/"
+	}
+
+	objects := s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{
+		Prefix:    prefix,
+		Recursive: true,
+	})
+	for object := range objects {
+		if object.Err != nil {
+			return object.Err
+		}
+
+		hash := path.Base(object.Key)
+		if file.ValidateBlobKey(hash) != nil || object.Key != s.objectKey(hash) {
+			continue
+		}
+
+		err := fn(file.BlobInfo{
+			Hash:    hash,
+			Size:    object.Size,
+			ModTime: object.LastModified,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RemoveStaleTemp removes content spooled by writers that were never
+// committed or aborted, e.g. because the process crashed. A dry run only
+// counts it.
+func (s *BlobStorage) RemoveStaleTemp(olderThan time.Duration, dryRun bool) (int, error) {
+	entries, err := os.ReadDir(s.tempDir)
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	removed := 0
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), "s3-upload-") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		if dryRun {
+			removed++
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.tempDir, entry.Name())); err != nil {
+			continue
+		}
+		removed++
+	}
+
+	return removed, nil
+}
+
+// contentSize returns the size of the unread content, -1 when it is unknown.
+func contentSize(content io.Reader) int64 {
+	switch content := content.(type) {
+	case interface{ Len() int }: // bytes.Reader, strings.Reader
+		return int64(content.Len())
+	case *os.File:
+		info, err := content.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return -1
+		}
+		offset, err := content.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		return info.Size() - offset
+	default:
+		return -1
+	}
+}
+
+func (s *BlobStorage) objectKey(hash string) string {
+	return path.Join(s.prefix, hash[:2], hash[2:4], hash)
+}
+
+func mapError(err error) error {
+	switch minio.ToErrorResponse(err).Code {
+	case "NoSuchKey", "NotFound":
+		return file.ErrBlobNotFound
+	}
+	return err
+}
+
+// blobWriter spools the content to a local file, since the object key is
+// only known on Commit.
+type blobWriter struct {
+	storage *BlobStorage
+	temp    *os.File
+	done    bool
+}
+
+func (w *blobWriter) Write(p []byte) (int, error) {
+	return w.temp.Write(p)
+}
+
+func (w *blobWriter) Commit(ctx context.Context, hash string) error {
+	if err := file.ValidateBlobKey(hash); err != nil {
+		return err
+	}
+
+	info, err := w.temp.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := w.temp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	_, err = w.storage.client.PutObject(ctx, w.storage.bucket, w.storage.objectKey(hash), w.temp, info.Size(),
+		minio.PutObjectOptions{PartSize: w.storage.partSize},
+	)
+	if err != nil {
+		return err
+	}
+
+	return w.Abort()
+}
+
+func (w *blobWriter) Abort() error {
+	if w.done {
+		return nil
+	}
+	w.done = true
+	w.temp.Close()
+	err := os.Remove(w.temp.Name())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
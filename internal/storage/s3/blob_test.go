@@ -0,0 +1,167 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+	"github.com/minio/minio-go/v7"
+	"github.com/stretchr/testify/require"
+
+	"file-service/internal/file"
+)
+
+func setupTest(t *testing.T, prefix string) *BlobStorage {
+	t.Helper()
+
+	server := httptest.NewTLSServer(gofakes3.New(s3mem.New()).Server())
+	t.Cleanup(server.Close)
+
+	storage, err := NewBlobStorage(context.Background(), Config{
+		Endpoint:  strings.TrimPrefix(server.URL, "https://"),
+		UseSSL:    true,
+		Transport: server.Client().Transport,
+		Bucket:    "files",
+		Region:    "us-east-1",
+		AccessKey: "access-key",
+		SecretKey: "secret-key",
+		Prefix:    prefix,
+		TempDir:   t.TempDir(),
+		PartSize:  5 * 1024 * 1024,
+	})
+	require.NoError(t, err)
+
+	return storage
+}
+
+func hashOf(content []byte) string {
+	hasher := file.NewHasher()
+	hasher.Write(content)
+	return hasher.Hash()
+}
+
+func TestBlobStorage(t *testing.T) {
+	ctx := context.Background()
+	storage := setupTest(t, "blobs")
+
+	content := []byte("hello, content-addressed world")
+	hash := hashOf(content)
+
+	t.Run("Create and commit", func(t *testing.T) {
+		writer, err := storage.Create(ctx)
+		require.NoError(t, err)
+		_, err = writer.Write(content)
+		require.NoError(t, err)
+		require.NoError(t, writer.Commit(ctx, hash))
+		require.NoError(t, writer.Abort())
+
+		info, err := storage.client.StatObject(ctx, "files", "blobs/"+hash[:2]+"/"+hash[2:4]+"/"+hash, minio.StatObjectOptions{})
+		require.NoError(t, err)
+		require.Equal(t, int64(len(content)), info.Size)
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		reader, err := storage.Get(ctx, hash, 0, 0)
+		require.NoError(t, err)
+		data, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		require.NoError(t, reader.Close())
+		require.Equal(t, content, data)
+	})
+
+	t.Run("Get range", func(t *testing.T) {
+		reader, err := storage.Get(ctx, hash, 7, 7)
+		require.NoError(t, err)
+		data, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		require.NoError(t, reader.Close())
+		require.Equal(t, content[7:14], data)
+
+		reader, err = storage.Get(ctx, hash, 7, 0)
+		require.NoError(t, err)
+		data, err = io.ReadAll(reader)
+		require.NoError(t, err)
+		require.NoError(t, reader.Close())
+		require.Equal(t, content[7:], data)
+	})
+
+	t.Run("Stat", func(t *testing.T) {
+		info, err := storage.Stat(ctx, hash)
+		require.NoError(t, err)
+		require.Equal(t, hash, info.Hash)
+		require.Equal(t, int64(len(content)), info.Size)
+	})
+
+	t.Run("Multipart put", func(t *testing.T) {
+		large := bytes.Repeat([]byte("0123456789abcdef"), 11*1024*1024/16)
+		largeHash := hashOf(large)
+
+		require.NoError(t, storage.Put(ctx, largeHash, bytes.NewReader(large)))
+
+		reader, err := storage.Get(ctx, largeHash, 0, 0)
+		require.NoError(t, err)
+		data, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		require.NoError(t, reader.Close())
+		require.Equal(t, largeHash, hashOf(data))
+
+		require.NoError(t, storage.Delete(ctx, largeHash))
+	})
+
+	t.Run("List", func(t *testing.T) {
+		var hashes []string
+		err := storage.List(ctx, func(info file.BlobInfo) error {
+			hashes = append(hashes, info.Hash)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{hash}, hashes)
+	})
+
+	t.Run("Abort", func(t *testing.T) {
+		writer, err := storage.Create(ctx)
+		require.NoError(t, err)
+		_, err = writer.Write([]byte("discarded"))
+		require.NoError(t, err)
+		require.NoError(t, writer.Abort())
+
+		removed, err := storage.RemoveStaleTemp(0, false)
+		require.NoError(t, err)
+		require.Zero(t, removed)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		require.NoError(t, storage.Delete(ctx, hash))
+		require.NoError(t, storage.Delete(ctx, hash))
+
+		_, err := storage.Stat(ctx, hash)
+		require.ErrorIs(t, err, file.ErrBlobNotFound)
+
+		_, err = storage.Get(ctx, hash, 0, 0)
+		require.ErrorIs(t, err, file.ErrFileNotFound)
+	})
+}
+
+func TestContentSize(t *testing.T) {
+	reader := strings.NewReader("content")
+	_, err := reader.Read(make([]byte, 3))
+	require.NoError(t, err)
+	require.Equal(t, int64(4), contentSize(reader))
+
+	temp, err := os.CreateTemp(t.TempDir(), "content")
+	require.NoError(t, err)
+	defer temp.Close()
+	_, err = temp.WriteString("content")
+	require.NoError(t, err)
+	_, err = temp.Seek(1, io.SeekStart)
+	require.NoError(t, err)
+	require.Equal(t, int64(6), contentSize(temp))
+
+	require.Equal(t, int64(-1), contentSize(io.MultiReader(reader)))
+}
@@ -0,0 +1,253 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	sqltx "github.com/avito-tech/go-transaction-manager/drivers/sql/v2"
+	"github.com/google/uuid"
+
+	"file-service/internal/file"
+	"file-service/internal/storage/sqlmeta"
+)
+
+// dialect spells the queries of sqlmeta for SQLite. It has no index for
+// substrings of names, they are found by scanning the filtered rows.
+var dialect = sqlmeta.Dialect{
+	Placeholder: func(int) string { return "?" },
+	NamePrefix: func(prefix string) (string, any) {
+		return `filename GLOB %s`, globEscaper.Replace(prefix) + "*"
+	},
+	NameContains: func(substring string) (string, any) {
+		return `instr(filename, %s) > 0`, substring
+	},
+	Filename: "filename",
+	Time:     func(t time.Time) any { return t.UnixNano() },
+	ID:       func(id uuid.UUID) any { return id.String() },
+}
+
+var globEscaper = strings.NewReplacer(`*`, `[*]`, `?`, `[?]`, `[`, `[[]`)
+
+// FileMetaStorage keeps file metadata in a SQLite database. Timestamps are
+// stored as unix nanoseconds.
+type FileMetaStorage struct {
+	db     *sql.DB
+	logger *slog.Logger
+	tx     *sqltx.CtxGetter
+}
+
+func NewFileMetaStorage(db *sql.DB, transaction *sqltx.CtxGetter, logger *slog.Logger) *FileMetaStorage {
+	return &FileMetaStorage{
+		db:     db,
+		tx:     transaction,
+		logger: logger,
+	}
+}
+
+func (s *FileMetaStorage) Save(ctx context.Context, file *file.FileMeta) error {
+	query := `
+	INSERT INTO file_meta (id, filename, hash, original_hash, size, content_type, created_at, updated_at,
+		image_width, image_height, image_color_model, image_format, image_taken_at,
+		image_camera_model, image_orientation, image_has_gps)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT (id) DO UPDATE
+	SET filename = excluded.filename,
+		hash = excluded.hash,
+		original_hash = excluded.original_hash,
+		size = excluded.size,
+		content_type = excluded.content_type,
+		created_at = excluded.created_at,
+		updated_at = excluded.updated_at,
+		image_width = excluded.image_width,
+		image_height = excluded.image_height,
+		image_color_model = excluded.image_color_model,
+		image_format = excluded.image_format,
+		image_taken_at = excluded.image_taken_at,
+		image_camera_model = excluded.image_camera_model,
+		image_orientation = excluded.image_orientation,
+		image_has_gps = excluded.image_has_gps`
+
+	db := s.tx.DefaultTrOrDB(ctx, s.db)
+
+	args := append([]any{file.ID.String(), file.Filename, file.Hash, file.OriginalHash, file.Size, file.ContentType, file.CreatedAt.UnixNano(), file.UpdatedAt.UnixNano()}, sqlmeta.ImageValues(dialect, file.Image)...)
+	_, err := db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (s *FileMetaStorage) FindAll(ctx context.Context, filter file.Filter, order file.Sort, page file.Page) ([]*file.FileMeta, error) {
+	query, args := sqlmeta.ListQuery(dialect, filter, order, page)
+
+	db := s.tx.DefaultTrOrDB(ctx, s.db)
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return collectFileMetas(rows)
+}
+
+func (s *FileMetaStorage) FindById(ctx context.Context, id uuid.UUID) (*file.FileMeta, error) {
+	query := `
+	SELECT ` + sqlmeta.FILE_META_COLUMNS + `
+	FROM file_meta
+	WHERE id = ?`
+
+	db := s.tx.DefaultTrOrDB(ctx, s.db)
+
+	meta, err := scanFileMeta(db.QueryRowContext(ctx, query, id.String()))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, file.ErrFileNotFound
+		}
+		return nil, err
+	}
+	return meta, nil
+}
+
+func (s *FileMetaStorage) Delete(ctx context.Context, id uuid.UUID) error {
+	query := `
+	DELETE FROM file_meta
+	WHERE id = ?`
+
+	db := s.tx.DefaultTrOrDB(ctx, s.db)
+
+	result, err := db.ExecContext(ctx, query, id.String())
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return file.ErrFileNotFound
+	}
+	return nil
+}
+
+func (s *FileMetaStorage) CountByHash(ctx context.Context, hash string) (int, error) {
+	query := `
+	SELECT count(*)
+	FROM file_meta
+	WHERE hash = ? OR original_hash = ?`
+
+	db := s.tx.DefaultTrOrDB(ctx, s.db)
+
+	var count int
+	err := db.QueryRowContext(ctx, query, hash, hash).Scan(&count)
+	return count, err
+}
+
+func (s *FileMetaStorage) CountByContentHash(ctx context.Context, hash string) (int, error) {
+	query := `
+	SELECT count(*)
+	FROM file_meta
+	WHERE hash = ?`
+
+	db := s.tx.DefaultTrOrDB(ctx, s.db)
+
+	var count int
+	err := db.QueryRowContext(ctx, query, hash).Scan(&count)
+	return count, err
+}
+
+func (s *FileMetaStorage) FindIncomplete(ctx context.Context, after uuid.UUID, limit int) ([]*file.FileMeta, error) {
+	query := `
+	SELECT ` + sqlmeta.FILE_META_COLUMNS + `
+	FROM file_meta
+	WHERE (content_type = '' OR image_format IS NULL) AND id > ?
+	ORDER BY id
+	LIMIT ?`
+
+	db := s.tx.DefaultTrOrDB(ctx, s.db)
+
+	rows, err := db.QueryContext(ctx, query, after.String(), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return collectFileMetas(rows)
+}
+
+func (s *FileMetaStorage) FindAllHashes(ctx context.Context) ([]string, error) {
+	query := `
+	SELECT hash
+	FROM file_meta
+	UNION
+	SELECT original_hash
+	FROM file_meta
+	WHERE original_hash <> ''`
+
+	db := s.tx.DefaultTrOrDB(ctx, s.db)
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hashes := make([]string, 0)
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+
+	return hashes, rows.Err()
+}
+
+// LockHash is a no-op, transactions hold the write lock of the database from
+// their beginning, see open, so they already exclude each other, also across
+// processes.
+func (s *FileMetaStorage) LockHash(ctx context.Context, hash string) error {
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanFileMeta(row scanner) (*file.FileMeta, error) {
+	var (
+		meta                 file.FileMeta
+		image                file.ImageMeta
+		createdAt, updatedAt int64
+		takenAt              sql.NullInt64
+	)
+	err := row.Scan(&meta.ID, &meta.Filename, &meta.Hash, &meta.OriginalHash, &meta.Size, &meta.ContentType, &createdAt, &updatedAt,
+		&image.Width, &image.Height, &image.ColorModel, &image.Format, &takenAt, &image.CameraModel, &image.Orientation, &image.HasGPS)
+	if err != nil {
+		return nil, err
+	}
+	meta.CreatedAt = time.Unix(0, createdAt).UTC()
+	meta.UpdatedAt = time.Unix(0, updatedAt).UTC()
+	if image.Format != "" {
+		if takenAt.Valid {
+			image.TakenAt = time.Unix(0, takenAt.Int64).UTC()
+		}
+		meta.Image = &image
+	}
+	return &meta, nil
+}
+
+func collectFileMetas(rows *sql.Rows) ([]*file.FileMeta, error) {
+	defer rows.Close()
+
+	files := make([]*file.FileMeta, 0)
+	for rows.Next() {
+		meta, err := scanFileMeta(rows)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, meta)
+	}
+
+	return files, rows.Err()
+}
@@ -0,0 +1,66 @@
+package sqlite
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	sqltx "github.com/avito-tech/go-transaction-manager/drivers/sql/v2"
+	tx "github.com/avito-tech/go-transaction-manager/trm/v2/manager"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"file-service/internal/file"
+	"file-service/internal/file/filetest"
+)
+
+func TestFileMetaStorage(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	filetest.RunRepositorySuite(t, func(t *testing.T) (file.FileMetaRepository, *tx.Manager) {
+		db, err := New(context.Background(), filepath.Join(t.TempDir(), "file-service.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		return NewFileMetaStorage(db, sqltx.DefaultCtxGetter, logger), tx.Must(sqltx.NewDefaultFactory(db))
+	}, filetest.SuiteOptions{SerialTransactions: true})
+}
+
+func TestNew_Reopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "file-service.db")
+
+	db, err := New(ctx, path)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	db, err = New(ctx, path)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+}
+
+func TestFileMetaStorage_FindIncompleteNotInspected(t *testing.T) {
+	ctx := context.Background()
+	db, err := New(ctx, filepath.Join(t.TempDir(), "file-service.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	// A file stored before images were inspected has no image format.
+	_, err = db.ExecContext(ctx, `
+	INSERT INTO file_meta (id, filename, hash, size, content_type, created_at, updated_at)
+	VALUES (?, 'photo.jpg', 'hash', 1, 'image/jpeg', 0, 0)`, uuid.NewString())
+	require.NoError(t, err)
+
+	storage := NewFileMetaStorage(db, sqltx.DefaultCtxGetter, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	found, err := storage.FindIncomplete(ctx, uuid.Nil, 10)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	require.Equal(t, "image/jpeg", found[0].ContentType)
+	require.Nil(t, found[0].Image)
+
+	require.NoError(t, storage.Save(ctx, found[0]))
+	found, err = storage.FindIncomplete(ctx, uuid.Nil, 10)
+	require.NoError(t, err)
+	require.Empty(t, found)
+}
@@ -0,0 +1 @@
+drop table file_meta;
\ No newline at end of file
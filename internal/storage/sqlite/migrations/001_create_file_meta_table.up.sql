@@ -0,0 +1,10 @@
+create table if not exists file_meta (
+    id text primary key,
+    filename text not null default '',
+    hash text not null default '',
+    created_at integer not null,
+    updated_at integer not null
+);
+
+create index idx_file_meta_updated_at_created_at on file_meta (updated_at desc, created_at desc);
+create index idx_file_meta_hash on file_meta (hash);
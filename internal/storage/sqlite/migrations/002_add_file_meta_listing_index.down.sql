@@ -0,0 +1,3 @@
+drop index if exists idx_file_meta_updated_at_created_at_id;
+
+create index if not exists idx_file_meta_updated_at_created_at on file_meta (updated_at desc, created_at desc);
@@ -0,0 +1,5 @@
+drop index if exists idx_file_meta_size_id;
+drop index if exists idx_file_meta_filename_id;
+drop index if exists idx_file_meta_created_at_id;
+
+alter table file_meta drop column size;
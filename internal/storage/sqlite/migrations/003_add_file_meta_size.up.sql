@@ -0,0 +1,5 @@
+alter table file_meta add column size integer not null default 0;
+
+create index if not exists idx_file_meta_created_at_id on file_meta (created_at, id);
+create index if not exists idx_file_meta_filename_id on file_meta (filename, id);
+create index if not exists idx_file_meta_size_id on file_meta (size, id);
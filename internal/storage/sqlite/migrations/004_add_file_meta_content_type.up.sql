@@ -0,0 +1,5 @@
+alter table file_meta add column content_type text not null default '';
+
+-- Files stored before this migration have no size and content type yet, they
+-- are backfilled from the blobs on startup.
+create index if not exists idx_file_meta_incomplete on file_meta (id) where content_type = '';
@@ -0,0 +1,11 @@
+drop index if exists idx_file_meta_incomplete;
+create index if not exists idx_file_meta_incomplete on file_meta (id) where content_type = '';
+
+alter table file_meta drop column image_has_gps;
+alter table file_meta drop column image_orientation;
+alter table file_meta drop column image_camera_model;
+alter table file_meta drop column image_taken_at;
+alter table file_meta drop column image_format;
+alter table file_meta drop column image_color_model;
+alter table file_meta drop column image_height;
+alter table file_meta drop column image_width;
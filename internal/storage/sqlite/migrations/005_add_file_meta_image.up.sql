@@ -0,0 +1,13 @@
+alter table file_meta add column image_width integer;
+alter table file_meta add column image_height integer;
+alter table file_meta add column image_color_model text;
+alter table file_meta add column image_format text;
+alter table file_meta add column image_taken_at integer;
+alter table file_meta add column image_camera_model text;
+alter table file_meta add column image_orientation integer;
+alter table file_meta add column image_has_gps integer;
+
+-- The image format is empty for files which are not images and null for files
+-- stored before this migration, they are inspected by the backfill.
+drop index if exists idx_file_meta_incomplete;
+create index if not exists idx_file_meta_incomplete on file_meta (id) where content_type = '' or image_format is null;
@@ -0,0 +1,3 @@
+drop index if exists idx_file_meta_original_hash;
+
+alter table file_meta drop column original_hash;
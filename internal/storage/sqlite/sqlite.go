@@ -0,0 +1,93 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/golang-migrate/migrate/v4"
+	migratesqlite "github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	_ "modernc.org/sqlite"
+)
+
+// BUSY_TIMEOUT_MS is how long a connection waits for the database write lock.
+const BUSY_TIMEOUT_MS = 5000
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// New opens the database file, creating it if needed, and applies the
+// embedded migrations.
+func New(ctx context.Context, path string) (*sql.DB, error) {
+	const op = "storage.sqlite.New"
+
+	db, err := open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := applyMigrations(path); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return db, nil
+}
+
+func open(path string) (*sql.DB, error) {
+	query := url.Values{}
+	query.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", BUSY_TIMEOUT_MS))
+	query.Add("_pragma", "journal_mode(WAL)")
+	query.Add("_pragma", "synchronous(NORMAL)")
+	// Transactions take the write lock when they begin, a deferred one
+	// failing to upgrade its read lock would get SQLITE_BUSY right away.
+	query.Add("_txlock", "immediate")
+
+	return sql.Open("sqlite", "file:"+path+"?"+query.Encode())
+}
+
+func applyMigrations(path string) error {
+	const op = "storage.sqlite.applyMigrations"
+
+	// The migrate driver closes the database it was given, so it gets its own.
+	db, err := open(path)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	source, err := iofs.New(migrations, "migrations")
+	if err != nil {
+		db.Close()
+		return fmt.Errorf("%s: failed to read migrations: %w", op, err)
+	}
+
+	driver, err := migratesqlite.WithInstance(db, &migratesqlite.Config{})
+	if err != nil {
+		db.Close()
+		return fmt.Errorf("%s: failed to create migrate driver: %w", op, err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", source, "sqlite", driver)
+	if err != nil {
+		driver.Close()
+		return fmt.Errorf("%s: failed to create migrate instance: %w", op, err)
+	}
+	defer m.Close()
+
+	if err := m.Up(); err != nil {
+		if !errors.Is(err, migrate.ErrNoChange) {
+			return fmt.Errorf("%s: failed to run migrations: %w", op, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+// Package sqlmeta holds the queries of the file_meta table shared by the SQL
+// stores, which only differ in a few expressions and the types of arguments.
+package sqlmeta
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"file-service/internal/file"
+)
+
+// FILE_META_COLUMNS are the columns read by the stores, the image columns are
+// null for files which are not images.
+const FILE_META_COLUMNS = `id, filename, hash, original_hash, size, content_type, created_at, updated_at,
+	coalesce(image_width, 0), coalesce(image_height, 0), coalesce(image_color_model, ''),
+	coalesce(image_format, ''), image_taken_at, coalesce(image_camera_model, ''),
+	coalesce(image_orientation, 0), coalesce(image_has_gps, false)`
+
+// Dialect spells the parts of the queries that differ between databases.
+type Dialect struct {
+	// Placeholder returns the placeholder of the nth argument, counted from 1.
+	Placeholder func(n int) string
+	// NamePrefix and NameContains return a condition on the filename with %s
+	// in place of the placeholder of the returned argument.
+	NamePrefix   func(prefix string) (string, any)
+	NameContains func(substring string) (string, any)
+	// Filename is the sort key of the filename, ordering it by bytes.
+	Filename string
+	// Time and ID convert the arguments of the timestamp and id columns.
+	Time func(time.Time) any
+	ID   func(uuid.UUID) any
+}
+
+// ImageValues returns the values of the image columns in FILE_META_COLUMNS
+// order. The format of files which are not images is empty, as null marks
+// files which were not inspected yet.
+func ImageValues(dialect Dialect, image *file.ImageMeta) []any {
+	if image == nil {
+		return []any{nil, nil, nil, "", nil, nil, nil, nil}
+	}
+	var takenAt any
+	if !image.TakenAt.IsZero() {
+		takenAt = dialect.Time(image.TakenAt)
+	}
+	return []any{image.Width, image.Height, image.ColorModel, image.Format, takenAt, image.CameraModel, image.Orientation, image.HasGPS}
+}
@@ -0,0 +1,108 @@
+package sqlmeta
+
+import (
+	"fmt"
+	"strings"
+
+	"file-service/internal/file"
+)
+
+type sortColumn struct {
+	expression string
+	value      func(cursor *file.Cursor) any
+}
+
+// ListQuery builds the query of a listing page. Rows are compared by the
+// whole sort key, so cursor pages are keyset queries on the sort indexes.
+func ListQuery(dialect Dialect, filter file.Filter, order file.Sort, page file.Page) (string, []any) {
+	var (
+		conditions []string
+		args       []any
+	)
+	placeholder := func(arg any) string {
+		args = append(args, arg)
+		return dialect.Placeholder(len(args))
+	}
+	where := func(condition string, arg any) {
+		conditions = append(conditions, fmt.Sprintf(condition, placeholder(arg)))
+	}
+
+	if filter.NamePrefix != "" {
+		where(dialect.NamePrefix(filter.NamePrefix))
+	}
+	if filter.NameContains != "" {
+		where(dialect.NameContains(filter.NameContains))
+	}
+	if !filter.CreatedFrom.IsZero() {
+		where(`created_at >= %s`, dialect.Time(filter.CreatedFrom))
+	}
+	if !filter.CreatedTo.IsZero() {
+		where(`created_at < %s`, dialect.Time(filter.CreatedTo))
+	}
+	if !filter.UpdatedFrom.IsZero() {
+		where(`updated_at >= %s`, dialect.Time(filter.UpdatedFrom))
+	}
+	if !filter.UpdatedTo.IsZero() {
+		where(`updated_at < %s`, dialect.Time(filter.UpdatedTo))
+	}
+	if filter.MinSize != nil {
+		where(`size >= %s`, *filter.MinSize)
+	}
+	if filter.MaxSize != nil {
+		where(`size <= %s`, *filter.MaxSize)
+	}
+
+	var (
+		updatedAt = sortColumn{"updated_at", func(cursor *file.Cursor) any { return dialect.Time(cursor.UpdatedAt) }}
+		createdAt = sortColumn{"created_at", func(cursor *file.Cursor) any { return dialect.Time(cursor.CreatedAt) }}
+		filename  = sortColumn{dialect.Filename, func(cursor *file.Cursor) any { return cursor.Filename }}
+		size      = sortColumn{"size", func(cursor *file.Cursor) any { return cursor.Size }}
+		id        = sortColumn{"id", func(cursor *file.Cursor) any { return dialect.ID(cursor.ID) }}
+	)
+	var columns []sortColumn
+	switch order.Field {
+	case file.SORT_BY_CREATED_AT:
+		columns = []sortColumn{createdAt, id}
+	case file.SORT_BY_NAME:
+		columns = []sortColumn{filename, id}
+	case file.SORT_BY_SIZE:
+		columns = []sortColumn{size, id}
+	default:
+		columns = []sortColumn{updatedAt, createdAt, id}
+	}
+	direction, comparison := "ASC", ">"
+	if order.Desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	expressions := make([]string, len(columns))
+	orderBy := make([]string, len(columns))
+	for i, column := range columns {
+		expressions[i] = column.expression
+		orderBy[i] = column.expression + " " + direction
+	}
+
+	offset := (page.Number - 1) * page.Size
+	if cursor := page.After; cursor != nil {
+		values := make([]string, len(columns))
+		for i, column := range columns {
+			values[i] = placeholder(column.value(cursor))
+		}
+		conditions = append(conditions, fmt.Sprintf("(%s) %s (%s)",
+			strings.Join(expressions, ", "), comparison, strings.Join(values, ", ")))
+		offset = 0
+	}
+
+	query := `
+	SELECT ` + FILE_META_COLUMNS + `
+	FROM file_meta`
+	if len(conditions) > 0 {
+		query += `
+	WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += fmt.Sprintf(`
+	ORDER BY %s
+	LIMIT %s OFFSET %s`, strings.Join(orderBy, ", "), placeholder(page.Size), placeholder(offset))
+
+	return query, args
+}
@@ -0,0 +1,44 @@
+package sqlmeta
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"file-service/internal/file"
+)
+
+func TestListQuery(t *testing.T) {
+	dialect := Dialect{
+		Placeholder:  func(n int) string { return fmt.Sprintf("$%d", n) },
+		NamePrefix:   func(prefix string) (string, any) { return "filename LIKE %s", prefix + "%" },
+		NameContains: func(substring string) (string, any) { return "strpos(filename, %s) > 0", substring },
+		Filename:     "filename",
+		Time:         func(t time.Time) any { return t.Unix() },
+		ID:           func(id uuid.UUID) any { return id.String() },
+	}
+	minSize := int64(10)
+	createdAt := time.Unix(100, 0)
+	id := uuid.New()
+
+	t.Run("Offset", func(t *testing.T) {
+		query, args := ListQuery(dialect, file.Filter{NamePrefix: "a", MinSize: &minSize}, file.Sort{Field: file.SORT_BY_NAME, Desc: true}, file.NewPage(3, 10))
+		require.Contains(t, query, "WHERE filename LIKE $1 AND size >= $2")
+		require.Contains(t, query, "ORDER BY filename DESC, id DESC")
+		require.True(t, strings.HasSuffix(query, "LIMIT $3 OFFSET $4"))
+		require.Equal(t, []any{"a%", minSize, 10, 20}, args)
+	})
+
+	t.Run("Cursor", func(t *testing.T) {
+		page := file.NewPage(3, 10)
+		page.After = &file.Cursor{CreatedAt: createdAt, ID: id}
+		query, args := ListQuery(dialect, file.Filter{NameContains: "b"}, file.Sort{Field: file.SORT_BY_CREATED_AT}, page)
+		require.Contains(t, query, "WHERE strpos(filename, $1) > 0 AND (created_at, id) > ($2, $3)")
+		require.Contains(t, query, "ORDER BY created_at ASC, id ASC")
+		require.Equal(t, []any{"b", int64(100), id.String(), 10, 0}, args)
+	})
+}
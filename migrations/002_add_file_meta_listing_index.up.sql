@@ -0,0 +1,4 @@
+-- Keyset pages of the default order compare the whole sort key, including id.
+drop index if exists idx_file_meta_updated_at_created_at;
+
+create index if not exists idx_file_meta_updated_at_created_at_id on file_meta (updated_at, created_at, id);
@@ -0,0 +1,6 @@
+drop index if exists idx_file_meta_filename_trgm;
+drop index if exists idx_file_meta_size_id;
+drop index if exists idx_file_meta_filename_id;
+drop index if exists idx_file_meta_created_at_id;
+
+alter table file_meta drop column if exists size;
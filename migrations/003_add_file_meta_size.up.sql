@@ -0,0 +1,9 @@
+create extension if not exists pg_trgm;
+
+alter table file_meta add column if not exists size bigint not null default 0;
+
+create index if not exists idx_file_meta_created_at_id on file_meta (created_at, id);
+create index if not exists idx_file_meta_filename_id on file_meta (filename collate "C", id);
+create index if not exists idx_file_meta_size_id on file_meta (size, id);
+-- Substrings of file names.
+create index if not exists idx_file_meta_filename_trgm on file_meta using gin (filename gin_trgm_ops);
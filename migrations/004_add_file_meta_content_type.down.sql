@@ -0,0 +1,3 @@
+drop index if exists idx_file_meta_incomplete;
+
+alter table file_meta drop column if exists content_type;
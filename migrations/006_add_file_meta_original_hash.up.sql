@@ -0,0 +1,3 @@
+alter table file_meta add column if not exists original_hash text not null default '';
+
+create index if not exists idx_file_meta_original_hash on file_meta (original_hash) where original_hash <> '';
@@ -0,0 +1,11 @@
+-- Concurrent requests of clients shared by the replicas of the service, a slot
+-- is freed when the request ends or its lease expires.
+create table if not exists rate_limit_slots (
+    id uuid primary key,
+    client text not null,
+    group_name text not null,
+    expires_at timestamp with time zone not null
+);
+
+create index if not exists idx_rate_limit_slots_client_group on rate_limit_slots (client, group_name);
+create index if not exists idx_rate_limit_slots_expires_at on rate_limit_slots (expires_at);